@@ -0,0 +1,52 @@
+//go:build dev
+
+package main
+
+import (
+	"context"
+	"go-bank-app/internal/transactions"
+	"log"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Development builds (go build -tags dev) can simulate the funding source, so deposits
+// and withdrawals work locally without an external processor.
+func init() {
+	fundingSources["inmemory"] = func() transactions.FundingSource {
+		log.Println("⚠️ FUNDING_SOURCE=inmemory simulates deposits and withdrawals, never use it in production")
+		return &inMemoryFundingSource{references: map[string]string{}}
+	}
+}
+
+// inMemoryFundingSource approves every operation without moving any real money.
+type inMemoryFundingSource struct {
+	mu         sync.Mutex
+	references map[string]string
+}
+
+// Collect implements transactions.FundingSource.
+func (f *inMemoryFundingSource) Collect(ctx context.Context, req transactions.FundingRequest) (string, error) {
+	return f.apply(req)
+}
+
+// Payout implements transactions.FundingSource.
+func (f *inMemoryFundingSource) Payout(ctx context.Context, req transactions.FundingRequest) (string, error) {
+	return f.apply(req)
+}
+
+func (f *inMemoryFundingSource) apply(req transactions.FundingRequest) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Retries of the same transaction are not applied twice.
+	if ref, ok := f.references[req.TransactionID]; ok {
+		return ref, nil
+	}
+
+	ref := uuid.New().String()
+	f.references[req.TransactionID] = ref
+	log.Printf("🏦 In-memory funding source moved %s %s for account [%s] (source %s)", req.Amount, req.Currency, req.AccountID, req.Source)
+	return ref, nil
+}
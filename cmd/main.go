@@ -3,14 +3,25 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"expvar"
 	"fmt"
 	config "go-bank-app/configs"
 	"go-bank-app/internal/accounts"
 	"go-bank-app/internal/auth"
+	"go-bank-app/internal/beneficiaries"
 	"go-bank-app/internal/transactions"
+	"go-bank-app/pkg/aws/s3"
+	"go-bank-app/pkg/fx"
+	"go-bank-app/pkg/mail"
 	"go-bank-app/pkg/middleware"
+	"go-bank-app/pkg/money"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -30,7 +41,18 @@ func main() {
 	if err != nil {
 		log.Fatal("failed to connect to DB:", err)
 	}
-	defer conn.Close()
+
+	shutdownTimeout, err := config.GetDurationOrDefault("SHUTDOWN_TIMEOUT", 30*time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	adminOnly := func(h http.HandlerFunc) http.Handler {
+		return middleware.AuthMiddleware(middleware.RequireRole(auth.RoleAdmin, h))
+	}
+	// Runtime and balance worker metrics
+	mux.Handle("GET /debug/vars", adminOnly(expvar.Handler().ServeHTTP))
 
 	// ─── AUTH ─────────────────────────────────────────────
 	authRepo := auth.NewAuthRepository(conn)
@@ -38,11 +60,11 @@ func main() {
 	authHandler := auth.NewAuthHandler(authService)
 
 	// Rutas públicas
-	http.HandleFunc("/auth/register", authHandler.Register)
-	http.HandleFunc("/auth/login", authHandler.Login)
+	mux.HandleFunc("/auth/register", authHandler.Register)
+	mux.HandleFunc("/auth/login", authHandler.Login)
 
 	// Ruta protegida con middleware
-	http.Handle("/auth/me", middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/auth/me", middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		userID := r.Context().Value(middleware.ContextUserIDKey).(string)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -54,56 +76,424 @@ func main() {
 	accountService := accounts.NewAccountService(accountRepo)
 	accountHandler := accounts.NewAccountHandler(accountService)
 
-	// Init listener to process commands
-	accounts.StartAccountBalanceWorker(accountRepo)
+	// Init listeners to process commands
+	workerShards, err := config.GetIntOrDefault("BALANCE_WORKER_SHARDS", 8)
+	if err != nil {
+		log.Fatal(err)
+	}
+	workerQueueSize, err := config.GetIntOrDefault("BALANCE_WORKER_QUEUE_SIZE", 100)
+	if err != nil {
+		log.Fatal(err)
+	}
+	balanceWorkers := accounts.StartAccountBalanceWorkers(accountRepo, workerShards, workerQueueSize)
 
-	http.Handle("/accounts", middleware.AuthMiddleware(http.HandlerFunc(accountHandler.Create)))
-	http.Handle("/accounts/balance", middleware.AuthMiddleware(http.HandlerFunc(accountHandler.GetBalance)))
+	mux.Handle("POST /accounts", middleware.AuthMiddleware(http.HandlerFunc(accountHandler.Create)))
+	mux.Handle("GET /accounts", middleware.AuthMiddleware(http.HandlerFunc(accountHandler.List)))
+	mux.Handle("POST /accounts/default", middleware.AuthMiddleware(http.HandlerFunc(accountHandler.SetDefault)))
+	mux.Handle("/accounts/balance", middleware.AuthMiddleware(http.HandlerFunc(accountHandler.GetBalance)))
+	mux.Handle("/accounts/ledger", middleware.AuthMiddleware(http.HandlerFunc(accountHandler.GetLedger)))
+	// Admin-only account lifecycle
+	mux.Handle("POST /admin/accounts/{id}/freeze", adminOnly(accountHandler.Freeze))
+	mux.Handle("POST /admin/accounts/{id}/unfreeze", adminOnly(accountHandler.Unfreeze))
+	mux.Handle("POST /admin/accounts/{id}/close", adminOnly(accountHandler.Close))
+	mux.Handle("GET /admin/accounts/{id}/audit", adminOnly(accountHandler.GetStatusAudit))
 	// Open banking public endpoint
-	http.HandleFunc("/open/accounts", accountHandler.GetPublic)
+	mux.HandleFunc("/open/accounts", accountHandler.GetPublic)
+
+	// ─── BENEFICIARIES ────────────────────────────────────
+	beneficiaryRepo := beneficiaries.NewBeneficiaryRepository(conn)
+	beneficiaryService := beneficiaries.NewBeneficiaryService(beneficiaryRepo, &PayeeDirectoryAdapter{accountService: accountService, authService: authService})
+	beneficiaryHandler := beneficiaries.NewBeneficiaryHandler(beneficiaryService)
+
+	mux.Handle("POST /beneficiaries", middleware.AuthMiddleware(http.HandlerFunc(beneficiaryHandler.Create)))
+	mux.Handle("GET /beneficiaries", middleware.AuthMiddleware(http.HandlerFunc(beneficiaryHandler.List)))
+	mux.Handle("GET /beneficiaries/confirm", middleware.AuthMiddleware(http.HandlerFunc(beneficiaryHandler.Confirm)))
+	mux.Handle("PATCH /beneficiaries/{id}", middleware.AuthMiddleware(http.HandlerFunc(beneficiaryHandler.Rename)))
+	mux.Handle("DELETE /beneficiaries/{id}", middleware.AuthMiddleware(http.HandlerFunc(beneficiaryHandler.Delete)))
 
 	// ─── TRANSACTIONS ─────────────────────────────────────
-	txPublisher := &AccountTransferChannelAdapter{}
-	accountReader := &AccountReaderAdapter{accountService: accountService}
+	txPublisher := &AccountTransferPoolAdapter{pool: balanceWorkers}
+	accountReader := &AccountReaderAdapter{accountService: accountService, beneficiaryService: beneficiaryService, authService: authService}
+
+	idempotencyTTL, err := config.GetDurationOrDefault("IDEMPOTENCY_KEY_TTL", 24*time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rates, err := fx.NewFileProvider(config.GetStringOrDefault("FX_RATES_FILE", "configs/fx_rates.json"))
+	if err != nil {
+		log.Fatalf("❌ Failed to load exchange rates: %v", err)
+	}
 
 	txRepo := transactions.NewTransactionRepository(conn)
-	txService := transactions.NewTransactionService(txRepo, txPublisher, accountReader)
+	idempotencyRepo := transactions.NewIdempotencyRepository(conn, idempotencyTTL)
+	// No external processor is integrated yet. Deposits and withdrawals stay disabled
+	// unless FUNDING_SOURCE picks one, and then both limits are required.
+	fundingSource, err := loadFundingSource()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var fundingLimits transactions.FundingLimits
+	if fundingSource != nil {
+		if fundingLimits, err = loadFundingLimits(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	tierLimits, err := transactions.LoadTierLimits(config.GetStringOrDefault("TRANSFER_LIMITS_FILE", "configs/transfer_limits.json"),
+		string(accounts.AccountTierStandard), string(accounts.AccountTierPremium), string(accounts.AccountTierBusiness))
+	if err != nil {
+		log.Fatalf("❌ Failed to load transfer limits: %v", err)
+	}
+	limitPolicy := transactions.NewTransferLimitPolicy(transactions.NewLimitRepository(conn), tierLimits)
+	limitHandler := transactions.NewLimitHandler(limitPolicy, accountReader)
+
+	fraudRules, err := transactions.LoadFraudRules(config.GetStringOrDefault("FRAUD_RULES_FILE", "configs/fraud_rules.json"))
+	if err != nil {
+		log.Fatalf("❌ Failed to load fraud rules: %v", err)
+	}
+	screener := transactions.NewRuleScreener(fraudRules, txRepo)
+	reviewRepo := transactions.NewReviewRepository(conn)
+
+	txService := transactions.NewTransactionService(txRepo, txPublisher, accountReader, idempotencyRepo, rates, fundingSource, fundingLimits, limitPolicy, screener, reviewRepo)
 	txHandler := transactions.NewTransactionHandler(txService)
 
-	http.Handle("/transactions/transfer", middleware.AuthMiddleware(http.HandlerFunc(txHandler.Transfer)))
-	http.Handle("/transactions/history", middleware.AuthMiddleware(http.HandlerFunc(txHandler.GetHistory)))
-	http.Handle("/transactions/statement/pdf", middleware.AuthMiddleware(http.HandlerFunc(txHandler.GetStatementPDF)))
+	mux.Handle("/transactions/transfer", middleware.AuthMiddleware(http.HandlerFunc(txHandler.Transfer)))
+	var depositReconciler *transactions.DepositReconciler
+	if fundingSource != nil {
+		mux.Handle("POST /transactions/deposit", middleware.AuthMiddleware(http.HandlerFunc(txHandler.Deposit)))
+		mux.Handle("POST /transactions/withdraw", middleware.AuthMiddleware(http.HandlerFunc(txHandler.Withdraw)))
+
+		// Deposits collected but not credited, e.g. because the balance workers were full
+		reconcileInterval, err := config.GetDurationOrDefault("DEPOSIT_RECONCILE_INTERVAL", time.Minute)
+		if err != nil {
+			log.Fatal(err)
+		}
+		depositReconciler = transactions.NewDepositReconciler(txService, reconcileInterval, 5*time.Minute)
+		depositReconciler.Start()
+	} else {
+		log.Println("⚠️ FUNDING_SOURCE is not set, deposits and withdrawals are disabled")
+	}
+	mux.Handle("POST /admin/transactions/{id}/reverse", adminOnly(txHandler.Reverse))
+	// Transfer limits of an account
+	mux.Handle("GET /admin/accounts/{id}/limits", adminOnly(limitHandler.GetLimits))
+	mux.Handle("PUT /admin/accounts/{id}/limits", adminOnly(limitHandler.SetLimits))
+	// Transfers held by fraud screening
+	mux.Handle("GET /admin/reviews", adminOnly(txHandler.ListReviews))
+	mux.Handle("POST /admin/reviews/{id}/approve", adminOnly(txHandler.ApproveReview))
+	mux.Handle("POST /admin/reviews/{id}/reject", adminOnly(txHandler.RejectReview))
+	// Scheduled and recurring transfers
+	scheduleRepo := transactions.NewScheduledTransferRepository(conn)
+	scheduleService := transactions.NewScheduledTransferService(scheduleRepo, accountReader)
+	scheduleHandler := transactions.NewScheduledTransferHandler(scheduleService)
+
+	mux.Handle("POST /transactions/scheduled", middleware.AuthMiddleware(http.HandlerFunc(scheduleHandler.Create)))
+	mux.Handle("GET /transactions/scheduled", middleware.AuthMiddleware(http.HandlerFunc(scheduleHandler.List)))
+	mux.Handle("GET /transactions/scheduled/{id}", middleware.AuthMiddleware(http.HandlerFunc(scheduleHandler.Get)))
+	mux.Handle("PUT /transactions/scheduled/{id}", middleware.AuthMiddleware(http.HandlerFunc(scheduleHandler.Update)))
+	mux.Handle("DELETE /transactions/scheduled/{id}", middleware.AuthMiddleware(http.HandlerFunc(scheduleHandler.Cancel)))
+
+	schedulerConfig := transactions.DefaultSchedulerConfig
+	schedulerConfig.Interval, err = config.GetDurationOrDefault("SCHEDULER_INTERVAL", schedulerConfig.Interval)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Users are emailed about delayed and failed scheduled transfers
+	var notifier transactions.Notifier = transactions.LogNotifier{}
+	if addr := config.GetStringOrDefault("SMTP_ADDR", ""); addr != "" {
+		mailer, err := mail.New(mail.Config{
+			Addr:     addr,
+			From:     config.GetStringOrDefault("SMTP_FROM", ""),
+			Username: config.GetStringOrDefault("SMTP_USERNAME", ""),
+			Password: config.GetStringOrDefault("SMTP_PASSWORD", ""),
+		})
+		if err != nil {
+			log.Fatalf("❌ Failed to create SMTP client: %v", err)
+		}
+		notifier = transactions.NewEmailNotifier(accountReader, mailer)
+	} else {
+		log.Println("⚠️ SMTP_ADDR is not set, scheduled transfer notifications are only logged")
+	}
+	scheduler := transactions.NewScheduler(scheduleRepo, txService, notifier, schedulerConfig)
+	scheduler.Start()
+
+	mux.Handle("/transactions/history", middleware.AuthMiddleware(http.HandlerFunc(txHandler.GetHistory)))
+	mux.Handle("/transactions/statement", middleware.AuthMiddleware(http.HandlerFunc(txHandler.GetStatement)))
+	mux.Handle("/transactions/statement/pdf", middleware.AuthMiddleware(http.HandlerFunc(txHandler.GetStatementPDF)))
+	mux.Handle("/transactions/statement/csv", middleware.AuthMiddleware(http.HandlerFunc(txHandler.GetStatementCSV)))
+
+	// Monthly statements archived to S3, disabled unless a bucket is configured
+	var archiver *transactions.StatementArchiver
+	if bucket := config.GetStringOrDefault("S3_BUCKET", ""); bucket != "" {
+		endpoint := config.GetStringOrDefault("S3_ENDPOINT", "")
+		store, err := s3.New(context.Background(), s3.Config{
+			Bucket:          bucket,
+			Region:          config.GetStringOrDefault("AWS_REGION", "us-east-1"),
+			Endpoint:        endpoint,
+			PresignEndpoint: config.GetStringOrDefault("S3_PRESIGN_ENDPOINT", ""),
+			UsePathStyle:    endpoint != "",
+		})
+		if err != nil {
+			log.Fatalf("❌ Failed to create S3 client: %v", err)
+		}
+		linkExpiry, err := config.GetDurationOrDefault("STATEMENT_LINK_EXPIRY", 15*time.Minute)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		archiveRepo := transactions.NewStatementArchiveRepository(conn)
+		archiveService := transactions.NewStatementArchiveService(archiveRepo, accountReader, store, linkExpiry)
+		archiveHandler := transactions.NewStatementArchiveHandler(archiveService)
+		mux.Handle("GET /transactions/statement/archive", middleware.AuthMiddleware(http.HandlerFunc(archiveHandler.List)))
+
+		archiverConfig := transactions.DefaultArchiverConfig
+		archiverConfig.Interval, err = config.GetDurationOrDefault("STATEMENT_ARCHIVE_INTERVAL", archiverConfig.Interval)
+		if err != nil {
+			log.Fatal(err)
+		}
+		archiver = transactions.NewStatementArchiver(archiveRepo, accountReader, txService, store, archiverConfig)
+		archiver.Start()
+	}
 
 	// ─── SERVER ───────────────────────────────────────────
 	port := ":8070"
-	fmt.Println("🚀 Server running at http://localhost" + port)
-	log.Fatal(http.ListenAndServe(port, nil))
+	server := &http.Server{Addr: port, Handler: mux}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		fmt.Println("🚀 Server running at http://localhost" + port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+
+	// ─── SHUTDOWN ─────────────────────────────────────────
+	log.Printf("🛑 Shutting down, waiting up to %s for in-flight requests", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Stop accepting connections, scheduled runs, archiving and deposit credits, wait for
+	// running handlers and runs, which in turn wait for their transfers, then drain whatever
+	// is still queued for the balance workers.
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("❌ HTTP server did not stop cleanly: %v", err)
+	}
+	if err := scheduler.Shutdown(shutdownCtx); err != nil {
+		log.Printf("❌ Scheduler did not stop in time: %v", err)
+	}
+	if archiver != nil {
+		if err := archiver.Shutdown(shutdownCtx); err != nil {
+			log.Printf("❌ Statement archiver did not stop in time: %v", err)
+		}
+	}
+	if depositReconciler != nil {
+		if err := depositReconciler.Shutdown(shutdownCtx); err != nil {
+			log.Printf("❌ Deposit reconciler did not stop in time: %v", err)
+		}
+	}
+	if err := balanceWorkers.Shutdown(shutdownCtx); err != nil {
+		log.Printf("❌ Balance workers did not drain in time: %v", err)
+	}
+	if err := conn.Close(); err != nil {
+		log.Printf("❌ Failed to close database pool: %v", err)
+	}
+
+	if shutdownCtx.Err() != nil {
+		os.Exit(1)
+	}
+	log.Println("👋 Server stopped")
+}
+
+// fundingSources builds the funding sources FUNDING_SOURCE can name. No external
+// processor is integrated yet, so production builds have none; development builds add a
+// simulated one.
+var fundingSources = map[string]func() transactions.FundingSource{}
+
+// loadFundingSource returns the FundingSource named by FUNDING_SOURCE, or nil when it is
+// unset. A name this build does not provide is an error, so a deployment that expects
+// deposits and withdrawals does not start without a real funding source.
+func loadFundingSource() (transactions.FundingSource, error) {
+	name := config.GetStringOrDefault("FUNDING_SOURCE", "")
+	if name == "" {
+		return nil, nil
+	}
+	newSource, ok := fundingSources[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown FUNDING_SOURCE %q", name)
+	}
+	return newSource(), nil
+}
+
+// loadFundingLimits reads DEPOSIT_MAX_AMOUNT and WITHDRAWAL_MAX_AMOUNT, which are both
+// required and must be positive.
+func loadFundingLimits() (transactions.FundingLimits, error) {
+	var limits transactions.FundingLimits
+	for key, limit := range map[string]*money.Amount{
+		"DEPOSIT_MAX_AMOUNT":    &limits.MaxDeposit,
+		"WITHDRAWAL_MAX_AMOUNT": &limits.MaxWithdrawal,
+	} {
+		value, err := config.GetString(key)
+		if err != nil {
+			return limits, err
+		}
+		amount, err := money.Parse(value)
+		if err != nil {
+			return limits, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		if !amount.IsPositive() {
+			return limits, fmt.Errorf("%s must be greater than zero", key)
+		}
+		*limit = amount
+	}
+	return limits, nil
 }
 
 // ─── ADAPTERS ───────────────────────────────────────────────
 
-type AccountTransferChannelAdapter struct{}
+type AccountTransferPoolAdapter struct {
+	pool *accounts.BalanceWorkerPool
+}
 
-func (a *AccountTransferChannelAdapter) PublishTransfer(cmd transactions.UpdateAccountBalanceCommand) error {
+func (a *AccountTransferPoolAdapter) PublishTransfer(ctx context.Context, cmd transactions.UpdateAccountBalanceCommand) error {
 	internalCmd := accounts.UpdateAccountBalanceCommand{
+		Ctx:           ctx,
 		FromAccountID: cmd.FromAccountID,
 		ToAccountID:   cmd.ToAccountID,
 		Amount:        cmd.Amount,
+		CreditAmount:  cmd.CreditAmount,
+		Reference:     cmd.Reference,
+		Record:        cmd.Record,
 		ErrChan:       cmd.ErrChan,
 	}
 
-	accounts.AccountUpdateChannel <- internalCmd
-	return nil
+	err := a.pool.Submit(internalCmd)
+	switch {
+	case errors.Is(err, accounts.ErrQueueFull):
+		return transactions.ErrTransferQueueFull
+	case errors.Is(err, accounts.ErrPoolClosed):
+		return transactions.ErrTransfersUnavailable
+	}
+	return err
 }
 
 type AccountReaderAdapter struct {
+	accountService     accounts.AccountService
+	beneficiaryService beneficiaries.BeneficiaryService
+	authService        auth.AuthService
+}
+
+func toAccountInfo(account *accounts.Account) *transactions.AccountInfo {
+	return &transactions.AccountInfo{
+		ID:        account.ID,
+		UserID:    account.UserID,
+		Status:    string(account.Status),
+		Currency:  account.Currency,
+		Tier:      string(account.Tier),
+		CreatedAt: account.CreatedAt,
+	}
+}
+
+func (a *AccountReaderAdapter) GetAccountForUser(ctx context.Context, userID, accountID string) (*transactions.AccountInfo, error) {
+	account, err := a.accountService.GetAccountForUser(ctx, userID, accountID)
+	if err != nil || account == nil {
+		return nil, err
+	}
+	return toAccountInfo(account), nil
+}
+
+func (a *AccountReaderAdapter) GetClearingAccount(ctx context.Context, currency money.Currency) (*transactions.AccountInfo, error) {
+	account, err := a.accountService.GetClearingAccount(ctx, currency)
+	if err != nil || account == nil {
+		return nil, err
+	}
+	return toAccountInfo(account), nil
+}
+
+func (a *AccountReaderAdapter) GetAccountByID(ctx context.Context, accountID string) (*transactions.AccountInfo, error) {
+	account, err := a.accountService.GetAccountByID(ctx, accountID)
+	if err != nil || account == nil {
+		return nil, err
+	}
+	return toAccountInfo(account), nil
+}
+
+func (a *AccountReaderAdapter) GetBeneficiaryAccount(ctx context.Context, userID, beneficiaryID string) (*transactions.AccountInfo, error) {
+	beneficiary, err := a.beneficiaryService.Get(ctx, userID, beneficiaryID)
+	if errors.Is(err, beneficiaries.ErrBeneficiaryNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.GetAccountByID(ctx, beneficiary.AccountID)
+}
+
+func (a *AccountReaderAdapter) GetHolderName(ctx context.Context, userID string) (string, error) {
+	user, err := a.authService.GetUser(ctx, userID)
+	if err != nil || user == nil {
+		return "", err
+	}
+	return user.FullName, nil
+}
+
+func (a *AccountReaderAdapter) GetUserEmail(ctx context.Context, userID string) (string, error) {
+	user, err := a.authService.GetUser(ctx, userID)
+	if err != nil || user == nil {
+		return "", err
+	}
+	return user.Email, nil
+}
+
+func (a *AccountReaderAdapter) GetBalanceAt(ctx context.Context, accountID string, at time.Time) (money.Amount, error) {
+	return a.accountService.GetBalanceAt(ctx, accountID, at)
+}
+
+func (a *AccountReaderAdapter) ListStatementAccounts(ctx context.Context, openedBefore time.Time, afterID string, limit int) ([]transactions.AccountInfo, error) {
+	list, err := a.accountService.ListCustomerAccounts(ctx, openedBefore, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]transactions.AccountInfo, len(list))
+	for i := range list {
+		infos[i] = *toAccountInfo(&list[i])
+	}
+	return infos, nil
+}
+
+type PayeeDirectoryAdapter struct {
 	accountService accounts.AccountService
+	authService    auth.AuthService
 }
 
-func (a *AccountReaderAdapter) GetAccountByUserID(ctx context.Context, userID string) (*transactions.AccountInfo, error) {
-	account, err := a.accountService.GetAccountByUserID(ctx, userID)
+func (a *PayeeDirectoryAdapter) LookupAccount(ctx context.Context, accountID string) (*beneficiaries.PayeeAccount, error) {
+	account, err := a.accountService.GetAccountByID(ctx, accountID)
 	if err != nil || account == nil {
 		return nil, err
 	}
-	return &transactions.AccountInfo{ID: account.ID}, nil
+
+	payee := &beneficiaries.PayeeAccount{
+		AccountID: account.ID,
+		UserID:    account.UserID,
+		Currency:  account.Currency,
+		Active:    account.Status == accounts.AccountStatusActive,
+	}
+	if account.UserID != "" {
+		owner, err := a.authService.GetUser(ctx, account.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if owner != nil {
+			payee.OwnerName = owner.FullName
+		}
+	}
+	return payee, nil
 }
@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 )
 
 type ErrEnvVarNotSet struct {
@@ -34,3 +36,33 @@ func GetStringOrDefault(key string, defaultValue string) string {
 
 	return value
 }
+
+// GetDurationOrDefault reads a duration such as "24h" or "90s" from the environment variables. If the environment variable is not set the provided default value will be used.
+func GetDurationOrDefault(key string, defaultValue time.Duration) (time.Duration, error) {
+	value, err := GetString(key)
+	if err != nil {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("Environment variable with key %s is not a valid duration: %w", key, err)
+	}
+
+	return duration, nil
+}
+
+// GetIntOrDefault reads an integer from the environment variables. If the environment variable is not set the provided default value will be used.
+func GetIntOrDefault(key string, defaultValue int) (int, error) {
+	value, err := GetString(key)
+	if err != nil {
+		return defaultValue, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("Environment variable with key %s is not a valid integer: %w", key, err)
+	}
+
+	return number, nil
+}
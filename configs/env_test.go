@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 )
 
 const (
-	PostgresDbURI = "POSTGRES_DB_URI"
+	PostgresDbURI     = "POSTGRES_DB_URI"
+	IdempotencyKeyTTL = "IDEMPOTENCY_KEY_TTL"
 )
 
 func TestGetString(t *testing.T) {
@@ -78,3 +80,41 @@ func TestErrGetString(t *testing.T) {
 		})
 	}
 }
+
+func TestGetDurationOrDefault(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		set      bool
+		expected time.Duration
+		wantErr  bool
+	}{
+		{name: "It should use the default when the variable is not set.", expected: time.Hour},
+		{name: "It should parse the environment variable.", envValue: "90m", set: true, expected: 90 * time.Minute},
+		{name: "It should fail on an invalid duration.", envValue: "one day", set: true, wantErr: true},
+	}
+
+	for idx, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.set {
+				os.Setenv(IdempotencyKeyTTL, test.envValue)
+				defer os.Unsetenv(IdempotencyKeyTTL)
+			}
+
+			val, err := GetDurationOrDefault(IdempotencyKeyTTL, time.Hour)
+
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Case [%d]: Expected an error but received none.", idx)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Case [%d]: Expected %s. Received error %s.", idx, test.expected, err)
+			}
+			if val != test.expected {
+				t.Fatalf("Case [%d]: Expected %s. Received %s.", idx, test.expected, val)
+			}
+		})
+	}
+}
@@ -3,7 +3,9 @@ CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 CREATE TABLE IF NOT EXISTS users (
   id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
   email TEXT UNIQUE NOT NULL,
+  full_name TEXT NOT NULL DEFAULT '',
   hashed_password TEXT NOT NULL,
+  role TEXT NOT NULL DEFAULT 'customer' CHECK (role IN ('customer', 'admin')),
   created_at TIMESTAMP DEFAULT now(),
   updated_at TIMESTAMP DEFAULT now()
 );
@@ -11,20 +13,153 @@ CREATE TABLE IF NOT EXISTS users (
 CREATE TABLE IF NOT EXISTS accounts (
   id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
   user_id UUID REFERENCES users(id),
+  type TEXT NOT NULL DEFAULT 'checking',
+  tier TEXT NOT NULL DEFAULT 'standard' CHECK (tier IN ('standard', 'premium', 'business')),
+  is_default BOOLEAN NOT NULL DEFAULT false,
+  status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'frozen', 'closed')),
   balance NUMERIC(14, 2) DEFAULT 0,
   currency TEXT DEFAULT 'MXN',
   created_at TIMESTAMP DEFAULT now(),
   updated_at TIMESTAMP DEFAULT now()
 );
 
+CREATE INDEX IF NOT EXISTS idx_accounts_user_id ON accounts(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_one_default_per_user ON accounts(user_id) WHERE is_default;
+
+CREATE TABLE IF NOT EXISTS account_status_audit (
+  id UUID PRIMARY KEY,
+  account_id UUID NOT NULL REFERENCES accounts(id),
+  previous_status TEXT NOT NULL,
+  new_status TEXT NOT NULL,
+  changed_by UUID REFERENCES users(id),
+  reason TEXT DEFAULT '',
+  swept_to_account_id UUID REFERENCES accounts(id),
+  swept_amount NUMERIC(14, 2),
+  created_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_status_audit_account_id ON account_status_audit(account_id, created_at);
+
+CREATE TABLE IF NOT EXISTS beneficiaries (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id),
+  account_id UUID NOT NULL REFERENCES accounts(id),
+  nickname TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT now(),
+  updated_at TIMESTAMP DEFAULT now(),
+  CONSTRAINT beneficiaries_user_account_key UNIQUE (user_id, account_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_beneficiaries_user_nickname ON beneficiaries(user_id, lower(nickname));
+
 CREATE TABLE IF NOT EXISTS transactions (
   id UUID PRIMARY KEY,
+  type TEXT NOT NULL DEFAULT 'transfer' CHECK (type IN ('transfer', 'deposit', 'withdrawal', 'fee', 'reversal')),
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed', 'failed', 'reversed', 'held', 'processing')),
+  failure_reason TEXT DEFAULT '',
+  original_transaction_id UUID REFERENCES transactions(id),
   from_account_id UUID REFERENCES accounts(id),
   to_account_id UUID REFERENCES accounts(id),
   amount NUMERIC(14, 2) NOT NULL CHECK (amount > 0),
   currency TEXT NOT NULL,
+  destination_amount NUMERIC(14, 2) NOT NULL CHECK (destination_amount > 0),
+  destination_currency TEXT NOT NULL,
+  exchange_rate NUMERIC(20, 8) NOT NULL DEFAULT 1,
   description TEXT DEFAULT '',
   category TEXT DEFAULT '',
+  external_reference TEXT DEFAULT '',
   created_at TIMESTAMP DEFAULT now(),
   updated_at TIMESTAMP DEFAULT now()
-);
\ No newline at end of file
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_from_account_id ON transactions(from_account_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_original_transaction_id ON transactions(original_transaction_id) WHERE original_transaction_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_collected_deposits ON transactions(updated_at) WHERE type = 'deposit' AND status = 'processing';
+
+CREATE TABLE IF NOT EXISTS fraud_reviews (
+  transaction_id UUID PRIMARY KEY REFERENCES transactions(id),
+  rules TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+  reviewed_by UUID REFERENCES users(id),
+  note TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT now(),
+  reviewed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_fraud_reviews_status ON fraud_reviews(status, created_at);
+
+-- Per-account overrides of the tier transfer limits. Zero keeps the tier default.
+CREATE TABLE IF NOT EXISTS account_limits (
+  account_id UUID PRIMARY KEY REFERENCES accounts(id),
+  per_transaction NUMERIC(14, 2) NOT NULL DEFAULT 0 CHECK (per_transaction >= 0),
+  daily NUMERIC(14, 2) NOT NULL DEFAULT 0 CHECK (daily >= 0),
+  monthly NUMERIC(14, 2) NOT NULL DEFAULT 0 CHECK (monthly >= 0),
+  updated_by UUID REFERENCES users(id),
+  updated_at TIMESTAMP DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  user_id UUID NOT NULL REFERENCES users(id),
+  key TEXT NOT NULL,
+  fingerprint TEXT NOT NULL,
+  transaction_id UUID REFERENCES transactions(id),
+  created_at TIMESTAMP DEFAULT now(),
+  expires_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (user_id, key)
+);
+
+CREATE TABLE IF NOT EXISTS scheduled_transfers (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id),
+  from_account_id UUID NOT NULL REFERENCES accounts(id),
+  to_account_id UUID NOT NULL REFERENCES accounts(id),
+  amount NUMERIC(14, 2) NOT NULL CHECK (amount > 0),
+  currency TEXT NOT NULL DEFAULT '',
+  description TEXT DEFAULT '',
+  category TEXT DEFAULT '',
+  recurrence TEXT NOT NULL CHECK (recurrence IN ('once', 'weekly', 'monthly')),
+  day_of_month INT NOT NULL DEFAULT 0,
+  scheduled_at TIMESTAMP NOT NULL,
+  next_run_at TIMESTAMP NOT NULL,
+  status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'completed', 'failed', 'cancelled')),
+  run_count INT NOT NULL DEFAULT 0,
+  attempts INT NOT NULL DEFAULT 0,
+  last_error TEXT NOT NULL DEFAULT '',
+  last_transaction_id UUID REFERENCES transactions(id),
+  locked_until TIMESTAMP,
+  version INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT now(),
+  updated_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_due ON scheduled_transfers(next_run_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_user_id ON scheduled_transfers(user_id);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+  id UUID PRIMARY KEY,
+  reference TEXT DEFAULT '',
+  description TEXT DEFAULT '',
+  created_at TIMESTAMP DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS postings (
+  id UUID PRIMARY KEY,
+  journal_entry_id UUID NOT NULL REFERENCES journal_entries(id),
+  account_id UUID NOT NULL REFERENCES accounts(id),
+  direction TEXT NOT NULL CHECK (direction IN ('debit', 'credit')),
+  amount NUMERIC(14, 2) NOT NULL CHECK (amount > 0),
+  currency TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_postings_account_id ON postings(account_id, created_at);
+
+CREATE TABLE IF NOT EXISTS statement_archives (
+  id UUID PRIMARY KEY,
+  account_id UUID NOT NULL REFERENCES accounts(id),
+  period TEXT NOT NULL,
+  key TEXT NOT NULL,
+  size BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT now(),
+  UNIQUE (account_id, period)
+);
@@ -0,0 +1,3 @@
+#!/bin/sh
+# Creates the bucket monthly statements are archived to.
+awslocal s3 mb s3://bank-statements
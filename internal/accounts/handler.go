@@ -2,6 +2,7 @@ package accounts
 
 import (
 	"encoding/json"
+	"errors"
 	"go-bank-app/pkg/middleware"
 	"net/http"
 )
@@ -15,7 +16,17 @@ func NewAccountHandler(service AccountService) *AccountHandler {
 }
 
 type createAccountRequest struct {
-	Currency Currency `json:"currency"`
+	Currency Currency    `json:"currency"`
+	Type     AccountType `json:"type"` // Optional, defaults to checking
+}
+
+type setDefaultAccountRequest struct {
+	AccountID string `json:"account_id"`
+}
+
+type changeStatusRequest struct {
+	Reason           string `json:"reason"`
+	SweepToAccountID string `json:"sweep_to_account_id"` // Only when closing an account with funds
 }
 
 func (h *AccountHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -27,7 +38,7 @@ func (h *AccountHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	account, err := h.service.Create(r.Context(), userID, req.Currency)
+	account, err := h.service.Create(r.Context(), userID, req.Currency, req.Type)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -37,10 +48,50 @@ func (h *AccountHandler) Create(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(account)
 }
 
+// List returns every account of the caller, the default one first.
+func (h *AccountHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	accounts, err := h.service.ListAccounts(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Error retrieving accounts", http.StatusInternalServerError)
+		return
+	}
+	if accounts == nil {
+		accounts = []Account{}
+	}
+
+	json.NewEncoder(w).Encode(accounts)
+}
+
+// SetDefault makes one of the caller's accounts the default for transfers and balance lookups.
+func (h *AccountHandler) SetDefault(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	var req setDefaultAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AccountID == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetDefaultAccount(r.Context(), userID, req.AccountID); err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			http.Error(w, "Account not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error updating default account", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetBalance returns the balance of the account given in the account_id query parameter,
+// or of the caller's default account.
 func (h *AccountHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
 
-	account, err := h.service.GetAccountByUserID(r.Context(), userID)
+	account, err := h.service.GetAccountForUser(r.Context(), userID, r.URL.Query().Get("account_id"))
 	if err != nil || account == nil {
 		http.Error(w, "Account not found", http.StatusNotFound)
 		return
@@ -53,6 +104,95 @@ func (h *AccountHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetLedger returns the ledger postings of the caller's account so every balance change can be traced.
+func (h *AccountHandler) GetLedger(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	account, err := h.service.GetAccountForUser(r.Context(), userID, r.URL.Query().Get("account_id"))
+	if err != nil || account == nil {
+		http.Error(w, "Account not found", http.StatusNotFound)
+		return
+	}
+
+	postings, err := h.service.GetPostings(r.Context(), account.ID)
+	if err != nil {
+		http.Error(w, "Error retrieving ledger", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"account_id": account.ID,
+		"balance":    account.Balance,
+		"postings":   postings,
+	})
+}
+
+// Freeze blocks every transfer from or to the account in the path. Admin only.
+func (h *AccountHandler) Freeze(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, AccountStatusFrozen)
+}
+
+// Unfreeze reactivates a frozen account. Admin only.
+func (h *AccountHandler) Unfreeze(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, AccountStatusActive)
+}
+
+// Close closes the account for good. Its balance must be zero or swept to another account
+// of the same currency. Admin only.
+func (h *AccountHandler) Close(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, AccountStatusClosed)
+}
+
+func (h *AccountHandler) changeStatus(w http.ResponseWriter, r *http.Request, status AccountStatus) {
+	adminID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	var req changeStatusRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	audit, err := h.service.ChangeStatus(r.Context(), StatusChange{
+		AccountID:        r.PathValue("id"),
+		Status:           status,
+		ChangedBy:        adminID,
+		Reason:           req.Reason,
+		SweepToAccountID: req.SweepToAccountID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrAccountNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrInvalidStatusTransition), errors.Is(err, ErrNonZeroBalance),
+			errors.Is(err, ErrSweepAccountNotAvailable), errors.Is(err, ErrSweepCurrencyMismatch):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, ErrSameAccount):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Error updating account status", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(audit)
+}
+
+// GetStatusAudit lists every status change of the account in the path. Admin only.
+func (h *AccountHandler) GetStatusAudit(w http.ResponseWriter, r *http.Request) {
+	audits, err := h.service.GetStatusAudit(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Error retrieving status audit", http.StatusInternalServerError)
+		return
+	}
+	if audits == nil {
+		audits = []StatusAudit{}
+	}
+
+	json.NewEncoder(w).Encode(audits)
+}
+
 // GetPublic exposes account balance without authentication, emulating an open banking endpoint.
 func (h *AccountHandler) GetPublic(w http.ResponseWriter, r *http.Request) {
 	accountID := r.URL.Query().Get("id")
@@ -2,29 +2,169 @@ package accounts
 
 import (
 	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"go-bank-app/pkg/database"
+	"go-bank-app/pkg/money"
+	"hash/fnv"
 	"log"
+	"sync"
+	"time"
+)
+
+var (
+	ErrQueueFull  = errors.New("account balance queue is full")
+	ErrPoolClosed = errors.New("account balance worker pool is shutting down")
 )
 
 type UpdateAccountBalanceCommand struct {
+	// Ctx is the context of the request that issued the command. Commands whose request
+	// was cancelled before a worker picked them up are dropped.
+	Ctx           context.Context
 	FromAccountID string
 	ToAccountID   string
-	Amount        float64
-	ErrChan       chan error
+	Amount        money.Amount
+	CreditAmount  money.Amount
+	Reference     string
+	Record        func(ctx context.Context, exec database.Executor) error
+	// ErrChan receives exactly one result. It should be buffered so the worker never
+	// blocks on a caller that stopped waiting.
+	ErrChan chan error
+
+	enqueuedAt time.Time
+}
+
+// balanceWorkerMetrics is published at /debug/vars.
+var balanceWorkerMetrics = expvar.NewMap("account_balance_worker")
+
+// BalanceWorkerPool applies balance updates with one goroutine per shard. Commands are
+// sharded by the sending account, so updates on the same account keep their order while
+// unrelated transfers run in parallel.
+type BalanceWorkerPool struct {
+	repo   AccountRepository
+	shards []chan UpdateAccountBalanceCommand
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// StartAccountBalanceWorkers starts shards workers, each with a queue of queueSize commands.
+func StartAccountBalanceWorkers(repo AccountRepository, shards, queueSize int) *BalanceWorkerPool {
+	if shards < 1 {
+		shards = 1
+	}
+
+	p := &BalanceWorkerPool{
+		repo:   repo,
+		shards: make([]chan UpdateAccountBalanceCommand, shards),
+	}
+
+	for i := range p.shards {
+		p.shards[i] = make(chan UpdateAccountBalanceCommand, queueSize)
+		p.wg.Add(1)
+		go p.work(i)
+	}
+
+	return p
 }
 
-var AccountUpdateChannel = make(chan UpdateAccountBalanceCommand)
+// Submit queues the command without blocking. It returns ErrQueueFull when the shard of
+// the sending account is saturated, so callers can push back instead of piling up.
+func (p *BalanceWorkerPool) Submit(cmd UpdateAccountBalanceCommand) error {
+	if cmd.Ctx == nil {
+		cmd.Ctx = context.Background()
+	}
+	cmd.enqueuedAt = time.Now()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	shard := p.shardFor(cmd.FromAccountID)
+	select {
+	case p.shards[shard] <- cmd:
+		balanceWorkerMetrics.Add("submitted", 1)
+		balanceWorkerMetrics.Add(queueDepthKey(shard), 1)
+		return nil
+	default:
+		balanceWorkerMetrics.Add("rejected", 1)
+		return ErrQueueFull
+	}
+}
 
-func StartAccountBalanceWorker(repo AccountRepository) {
+// Shutdown stops accepting commands and waits until every queued one has been applied,
+// or until ctx expires.
+func (p *BalanceWorkerPool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		for _, shard := range p.shards {
+			close(shard)
+		}
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
 	go func() {
-		for cmd := range AccountUpdateChannel {
-			ctx := context.Background()
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *BalanceWorkerPool) shardFor(accountID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(accountID))
+	return int(h.Sum32() % uint32(len(p.shards)))
+}
+
+func (p *BalanceWorkerPool) work(shard int) {
+	defer p.wg.Done()
 
-			err := repo.Transfer(ctx, cmd.FromAccountID, cmd.ToAccountID, cmd.Amount)
-			if err != nil {
-				log.Printf("❌ Transfer error: %v", err)
-			}
+	for cmd := range p.shards[shard] {
+		balanceWorkerMetrics.Add(queueDepthKey(shard), -1)
+		balanceWorkerMetrics.Add("queue_wait_ms_total", time.Since(cmd.enqueuedAt).Milliseconds())
 
+		if err := cmd.Ctx.Err(); err != nil {
+			log.Printf("⏭️ Skipping transfer [%s] -> [%s]: %v", cmd.FromAccountID, cmd.ToAccountID, err)
+			balanceWorkerMetrics.Add("cancelled", 1)
 			cmd.ErrChan <- err
+			continue
 		}
-	}()
+
+		start := time.Now()
+		err := p.repo.Transfer(cmd.Ctx, TransferParams{
+			FromAccountID: cmd.FromAccountID,
+			ToAccountID:   cmd.ToAccountID,
+			Amount:        cmd.Amount,
+			CreditAmount:  cmd.CreditAmount,
+			Reference:     cmd.Reference,
+			Record:        cmd.Record,
+		})
+		balanceWorkerMetrics.Add("transfer_ms_total", time.Since(start).Milliseconds())
+
+		if err != nil {
+			log.Printf("❌ Transfer error: %v", err)
+			balanceWorkerMetrics.Add("failed", 1)
+		} else {
+			balanceWorkerMetrics.Add("processed", 1)
+		}
+
+		cmd.ErrChan <- err
+	}
+}
+
+func queueDepthKey(shard int) string {
+	return fmt.Sprintf("queue_depth_shard_%d", shard)
 }
@@ -0,0 +1,153 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"go-bank-app/pkg/money"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTransferRepo records the transfers applied by the workers. Transfers to an account
+// listed in block wait until release is closed.
+type fakeTransferRepo struct {
+	AccountRepository
+
+	mu      sync.Mutex
+	applied []TransferParams
+	block   map[string]bool
+	release chan struct{}
+}
+
+func (f *fakeTransferRepo) Transfer(ctx context.Context, params TransferParams) error {
+	if f.block[params.ToAccountID] {
+		<-f.release
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.applied = append(f.applied, params)
+	return nil
+}
+
+func submitAndWait(t *testing.T, pool *BalanceWorkerPool, cmd UpdateAccountBalanceCommand) error {
+	t.Helper()
+
+	cmd.ErrChan = make(chan error, 1)
+	if err := pool.Submit(cmd); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-cmd.ErrChan:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the worker")
+		return nil
+	}
+}
+
+func TestBalanceWorkerPool_KeepsOrderPerAccount(t *testing.T) {
+	repo := &fakeTransferRepo{}
+	pool := StartAccountBalanceWorkers(repo, 4, 10)
+
+	var cmds []UpdateAccountBalanceCommand
+	for i := 1; i <= 5; i++ {
+		cmd := UpdateAccountBalanceCommand{FromAccountID: "acc-1", ToAccountID: "acc-2", Amount: money.FromMinor(int64(i)), ErrChan: make(chan error, 1)}
+		if err := pool.Submit(cmd); err != nil {
+			t.Fatalf("unexpected submit error: %v", err)
+		}
+		cmds = append(cmds, cmd)
+	}
+	for _, cmd := range cmds {
+		if err := <-cmd.ErrChan; err != nil {
+			t.Fatalf("unexpected transfer error: %v", err)
+		}
+	}
+
+	for i, params := range repo.applied {
+		if params.Amount != money.FromMinor(int64(i+1)) {
+			t.Fatalf("expected transfers in submission order, got %v at position %d", params.Amount, i)
+		}
+	}
+}
+
+func TestBalanceWorkerPool_RejectsWhenQueueIsFull(t *testing.T) {
+	repo := &fakeTransferRepo{block: map[string]bool{"slow": true}, release: make(chan struct{})}
+	pool := StartAccountBalanceWorkers(repo, 1, 1)
+	defer close(repo.release)
+
+	// The first command occupies the worker, the second fills the queue.
+	for i := 0; i < 2; i++ {
+		if err := pool.Submit(UpdateAccountBalanceCommand{FromAccountID: "acc-1", ToAccountID: "slow", ErrChan: make(chan error, 1)}); err != nil {
+			t.Fatalf("unexpected submit error: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	err := pool.Submit(UpdateAccountBalanceCommand{FromAccountID: "acc-1", ToAccountID: "acc-2", ErrChan: make(chan error, 1)})
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+}
+
+func TestBalanceWorkerPool_SkipsCancelledCommands(t *testing.T) {
+	repo := &fakeTransferRepo{}
+	pool := StartAccountBalanceWorkers(repo, 2, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := submitAndWait(t, pool, UpdateAccountBalanceCommand{Ctx: ctx, FromAccountID: "acc-1", ToAccountID: "acc-2"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(repo.applied) != 0 {
+		t.Errorf("expected the cancelled transfer not to be applied")
+	}
+}
+
+func TestBalanceWorkerPool_ShutdownDrainsQueue(t *testing.T) {
+	repo := &fakeTransferRepo{block: map[string]bool{"slow": true}, release: make(chan struct{})}
+	pool := StartAccountBalanceWorkers(repo, 1, 5)
+
+	for i := 0; i < 3; i++ {
+		if err := pool.Submit(UpdateAccountBalanceCommand{FromAccountID: "acc-1", ToAccountID: "slow", ErrChan: make(chan error, 1)}); err != nil {
+			t.Fatalf("unexpected submit error: %v", err)
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- pool.Shutdown(context.Background()) }()
+	close(repo.release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+	if len(repo.applied) != 3 {
+		t.Errorf("expected the 3 queued transfers to be applied, got %d", len(repo.applied))
+	}
+
+	err := pool.Submit(UpdateAccountBalanceCommand{FromAccountID: "acc-1", ToAccountID: "acc-2", ErrChan: make(chan error, 1)})
+	if !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("expected ErrPoolClosed after shutdown, got %v", err)
+	}
+}
+
+func TestBalanceWorkerPool_ShutdownHonoursDeadline(t *testing.T) {
+	repo := &fakeTransferRepo{block: map[string]bool{"slow": true}, release: make(chan struct{})}
+	pool := StartAccountBalanceWorkers(repo, 1, 5)
+	defer close(repo.release)
+
+	if err := pool.Submit(UpdateAccountBalanceCommand{FromAccountID: "acc-1", ToAccountID: "slow", ErrChan: make(chan error, 1)}); err != nil {
+		t.Fatalf("unexpected submit error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := pool.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
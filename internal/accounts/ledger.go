@@ -0,0 +1,166 @@
+package accounts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-bank-app/pkg/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Direction is the side of the ledger a posting lands on. Customer accounts are
+// liabilities of the bank, so a credit increases their balance and a debit decreases it.
+type Direction string
+
+const (
+	DirectionDebit  Direction = "debit"
+	DirectionCredit Direction = "credit"
+)
+
+// JournalEntry groups the postings of a single money movement. In every currency, the
+// sum of its debits equals the sum of its credits.
+type JournalEntry struct {
+	ID          string    `json:"id"`
+	Reference   string    `json:"reference"`
+	Description string    `json:"description"`
+	Postings    []Posting `json:"postings"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Posting is one line of a journal entry against a single account.
+type Posting struct {
+	ID             string       `json:"id"`
+	JournalEntryID string       `json:"journal_entry_id"`
+	AccountID      string       `json:"account_id"`
+	Direction      Direction    `json:"direction"`
+	Amount         money.Amount `json:"amount"`
+	Currency       Currency     `json:"currency"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+var ErrUnbalancedEntry = errors.New("journal entry is not balanced")
+
+func newJournalEntry(reference, description string) *JournalEntry {
+	return &JournalEntry{
+		ID:          uuid.New().String(),
+		Reference:   reference,
+		Description: description,
+		CreatedAt:   time.Now(),
+	}
+}
+
+func (e *JournalEntry) post(accountID string, direction Direction, currency Currency, amount money.Amount) {
+	e.Postings = append(e.Postings, Posting{
+		ID:             uuid.New().String(),
+		JournalEntryID: e.ID,
+		AccountID:      accountID,
+		Direction:      direction,
+		Amount:         amount,
+		Currency:       currency,
+		CreatedAt:      e.CreatedAt,
+	})
+}
+
+// newTransferEntry builds the journal entry for moving amount from one account to another
+// in the same currency.
+func newTransferEntry(fromID, toID string, currency Currency, amount money.Amount, reference string) *JournalEntry {
+	e := newJournalEntry(reference, fmt.Sprintf("transfer from %s to %s", fromID, toID))
+	e.post(fromID, DirectionDebit, currency, amount)
+	e.post(toID, DirectionCredit, currency, amount)
+	return e
+}
+
+// exchangeLeg is one side of a cross-currency transfer: the customer account, the FX
+// position account of its currency and the amount that moves in that currency.
+type exchangeLeg struct {
+	AccountID         string
+	PositionAccountID string
+	Currency          Currency
+	Amount            money.Amount
+}
+
+// newExchangeEntry builds the journal entry for a cross-currency transfer. The bank buys
+// the source currency into its position account and sells the destination currency out
+// of the other one, so each currency balances on its own.
+func newExchangeEntry(from, to exchangeLeg, reference string) *JournalEntry {
+	e := newJournalEntry(reference, fmt.Sprintf("exchange %s %s from %s to %s %s", from.Amount, from.Currency, from.AccountID, to.Amount, to.Currency))
+	e.post(from.AccountID, DirectionDebit, from.Currency, from.Amount)
+	e.post(from.PositionAccountID, DirectionCredit, from.Currency, from.Amount)
+	e.post(to.PositionAccountID, DirectionDebit, to.Currency, to.Amount)
+	e.post(to.AccountID, DirectionCredit, to.Currency, to.Amount)
+	return e
+}
+
+// validate checks that the entry has postings and that debits equal credits in every
+// currency.
+func (e *JournalEntry) validate() error {
+	if len(e.Postings) < 2 {
+		return ErrUnbalancedEntry
+	}
+
+	net := make(map[Currency]money.Amount)
+	for _, p := range e.Postings {
+		if !p.Amount.IsPositive() {
+			return fmt.Errorf("posting amount must be positive, got %s", p.Amount)
+		}
+		switch p.Direction {
+		case DirectionDebit:
+			net[p.Currency] = net[p.Currency].Add(p.Amount)
+		case DirectionCredit:
+			net[p.Currency] = net[p.Currency].Sub(p.Amount)
+		default:
+			return fmt.Errorf("unknown posting direction %q", p.Direction)
+		}
+	}
+
+	for _, amount := range net {
+		if !amount.IsZero() {
+			return ErrUnbalancedEntry
+		}
+	}
+	return nil
+}
+
+// insertJournalEntry writes the entry and its postings and applies them to the cached
+// account balances, all inside the caller's database transaction.
+func insertJournalEntry(ctx context.Context, tx *sql.Tx, e *JournalEntry) error {
+	if err := e.validate(); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO journal_entries (id, reference, description, created_at)
+	VALUES ($1, $2, $3, $4)
+`, e.ID, e.Reference, e.Description, e.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range e.Postings {
+		_, err := tx.ExecContext(ctx, `
+		INSERT INTO postings (id, journal_entry_id, account_id, direction, amount, currency, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, p.ID, p.JournalEntryID, p.AccountID, p.Direction, p.Amount, p.Currency, p.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		delta := p.Amount
+		if p.Direction == DirectionDebit {
+			delta = p.Amount.Neg()
+		}
+
+		res, err := tx.ExecContext(ctx, `UPDATE accounts SET balance = balance + $1, updated_at = $2 WHERE id = $3`, delta, p.CreatedAt, p.AccountID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("account %s not found", p.AccountID)
+		}
+	}
+
+	return nil
+}
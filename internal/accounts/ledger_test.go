@@ -0,0 +1,102 @@
+package accounts
+
+import (
+	"errors"
+	"go-bank-app/pkg/money"
+	"testing"
+)
+
+func TestNewTransferEntry_IsBalanced(t *testing.T) {
+	entry := newTransferEntry("acc-from", "acc-to", CurrencyMXN, money.MustParse("150.25"), "tx-1")
+
+	if err := entry.validate(); err != nil {
+		t.Fatalf("expected balanced entry, got: %v", err)
+	}
+	if len(entry.Postings) != 2 {
+		t.Fatalf("expected 2 postings, got %d", len(entry.Postings))
+	}
+
+	debit, credit := entry.Postings[0], entry.Postings[1]
+	if debit.AccountID != "acc-from" || debit.Direction != DirectionDebit {
+		t.Errorf("expected debit on acc-from, got %s on %s", debit.Direction, debit.AccountID)
+	}
+	if credit.AccountID != "acc-to" || credit.Direction != DirectionCredit {
+		t.Errorf("expected credit on acc-to, got %s on %s", credit.Direction, credit.AccountID)
+	}
+	if entry.Reference != "tx-1" {
+		t.Errorf("expected entry reference tx-1, got %s", entry.Reference)
+	}
+	for _, p := range entry.Postings {
+		if p.JournalEntryID != entry.ID {
+			t.Errorf("expected posting to reference entry %s, got %s", entry.ID, p.JournalEntryID)
+		}
+	}
+}
+
+func TestNewExchangeEntry_BalancesPerCurrency(t *testing.T) {
+	entry := newExchangeEntry(
+		exchangeLeg{AccountID: "acc-usd", PositionAccountID: "fx-usd", Currency: CurrencyUSD, Amount: money.MustParse("10.00")},
+		exchangeLeg{AccountID: "acc-mxn", PositionAccountID: "fx-mxn", Currency: CurrencyMXN, Amount: money.MustParse("182.50")},
+		"tx-2",
+	)
+
+	if err := entry.validate(); err != nil {
+		t.Fatalf("expected balanced entry, got: %v", err)
+	}
+	if len(entry.Postings) != 4 {
+		t.Fatalf("expected 4 postings, got %d", len(entry.Postings))
+	}
+
+	expected := []struct {
+		accountID string
+		direction Direction
+		currency  Currency
+	}{
+		{"acc-usd", DirectionDebit, CurrencyUSD},
+		{"fx-usd", DirectionCredit, CurrencyUSD},
+		{"fx-mxn", DirectionDebit, CurrencyMXN},
+		{"acc-mxn", DirectionCredit, CurrencyMXN},
+	}
+	for i, e := range expected {
+		p := entry.Postings[i]
+		if p.AccountID != e.accountID || p.Direction != e.direction || p.Currency != e.currency {
+			t.Errorf("posting %d: expected %s %s in %s, got %s %s in %s", i, e.direction, e.accountID, e.currency, p.Direction, p.AccountID, p.Currency)
+		}
+	}
+}
+
+func TestJournalEntry_ValidateRejectsUnbalanced(t *testing.T) {
+	tests := []struct {
+		name     string
+		postings []Posting
+	}{
+		{
+			name:     "single posting",
+			postings: []Posting{{AccountID: "a", Direction: DirectionDebit, Amount: money.MustParse("10")}},
+		},
+		{
+			name: "debits differ from credits",
+			postings: []Posting{
+				{AccountID: "a", Direction: DirectionDebit, Amount: money.MustParse("10")},
+				{AccountID: "b", Direction: DirectionCredit, Amount: money.MustParse("9.99")},
+			},
+		},
+		{
+			name: "balanced only across currencies",
+			postings: []Posting{
+				{AccountID: "a", Direction: DirectionDebit, Currency: CurrencyUSD, Amount: money.MustParse("10")},
+				{AccountID: "b", Direction: DirectionCredit, Currency: CurrencyMXN, Amount: money.MustParse("10")},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entry := &JournalEntry{ID: "entry", Postings: test.postings}
+
+			if err := entry.validate(); !errors.Is(err, ErrUnbalancedEntry) {
+				t.Errorf("expected ErrUnbalancedEntry, got %v", err)
+			}
+		})
+	}
+}
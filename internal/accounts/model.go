@@ -1,18 +1,75 @@
 package accounts
 
-import "time"
+import (
+	"context"
+	"go-bank-app/pkg/database"
+	"go-bank-app/pkg/money"
+	"time"
+)
 
-type Currency string
+type Currency = money.Currency
 
 const (
-	CurrencyMXN Currency = "MXN"
+	CurrencyMXN = money.CurrencyMXN
+	CurrencyUSD = money.CurrencyUSD
+	CurrencyEUR = money.CurrencyEUR
+	CurrencyGBP = money.CurrencyGBP
+	CurrencyCAD = money.CurrencyCAD
+)
+
+type AccountType string
+
+const (
+	AccountTypeChecking AccountType = "checking"
+	AccountTypeSavings  AccountType = "savings"
+	// AccountTypeFXPosition is a bank-owned account, one per currency, that takes the
+	// other side of cross-currency transfers. It has no user and may go negative.
+	AccountTypeFXPosition AccountType = "fx_position"
+	// AccountTypeClearing is a bank-owned account, one per currency, that money enters
+	// through on deposits and leaves through on withdrawals. It may go negative.
+	AccountTypeClearing AccountType = "clearing"
+)
+
+// isSystemAccount reports whether accounts of this type belong to the bank. They are not
+// subject to the insufficient funds check.
+func isSystemAccount(accountType AccountType) bool {
+	return accountType == AccountTypeFXPosition || accountType == AccountTypeClearing
+}
+
+// AccountTier sets the default transfer limits of an account.
+type AccountTier string
+
+const (
+	AccountTierStandard AccountTier = "standard"
+	AccountTierPremium  AccountTier = "premium"
+	AccountTierBusiness AccountTier = "business"
 )
 
 type Account struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	Balance   float64   `json:"balance"`
-	Currency  Currency  `json:"currency"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        string        `json:"id"`
+	UserID    string        `json:"user_id"`
+	Type      AccountType   `json:"type"`
+	Tier      AccountTier   `json:"tier"`
+	IsDefault bool          `json:"is_default"`
+	Status    AccountStatus `json:"status"`
+	Balance   money.Amount  `json:"balance"`
+	Currency  Currency      `json:"currency"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// TransferParams describes a balance movement between two accounts.
+type TransferParams struct {
+	FromAccountID string
+	ToAccountID   string
+	// Amount is debited from the source account, in its currency.
+	Amount money.Amount
+	// CreditAmount is credited to the destination account, in its currency. It defaults
+	// to Amount and must equal it when both accounts share a currency.
+	CreditAmount money.Amount
+	// Reference links the journal entry to whatever caused it, e.g. a transaction ID.
+	Reference string
+	// Record runs inside the same database transaction as the balance update, so the
+	// rows it writes commit or roll back together with the money movement.
+	Record func(ctx context.Context, exec database.Executor) error
 }
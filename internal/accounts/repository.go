@@ -4,24 +4,81 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"go-bank-app/pkg/money"
 	"log"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type AccountRepository interface {
 	CreateAccount(ctx context.Context, acc *Account) error
-	GetBalance(ctx context.Context, accountID string) (float64, error)
+	GetBalance(ctx context.Context, accountID string) (money.Amount, error)
 	GetAccountByUserID(ctx context.Context, userID string) (*Account, error)
 	GetAccountByID(ctx context.Context, accountID string) (*Account, error)
-	Transfer(ctx context.Context, fromID, toID string, amount float64) error
+	ListAccountsByUserID(ctx context.Context, userID string) ([]Account, error)
+	// ListCustomerAccounts pages through the accounts owned by users and opened before the
+	// given time, ordered by ID and starting after afterID.
+	ListCustomerAccounts(ctx context.Context, openedBefore time.Time, afterID string, limit int) ([]Account, error)
+	SetDefaultAccount(ctx context.Context, userID, accountID string) error
+	Transfer(ctx context.Context, params TransferParams) error
+	ChangeStatus(ctx context.Context, change StatusChange) (*StatusAudit, error)
+	// EnsureSystemAccount creates the bank-owned account of the given type and currency on
+	// first use and returns its ID.
+	EnsureSystemAccount(ctx context.Context, accountType AccountType, currency Currency) (string, error)
+	GetStatusAudit(ctx context.Context, accountID string) ([]StatusAudit, error)
+	GetPostings(ctx context.Context, accountID string) ([]Posting, error)
+	// GetBalanceAt returns the balance of the account from its postings made before at.
+	GetBalanceAt(ctx context.Context, accountID string, at time.Time) (money.Amount, error)
+	RebuildBalance(ctx context.Context, accountID string) (money.Amount, error)
 }
 
 type accountRepository struct {
 	db *sql.DB
 }
 
-// Transfer implements AccountRepository.
-func (r *accountRepository) Transfer(ctx context.Context, fromID string, toID string, amount float64) error {
-	log.Printf("💸 Starting transfer of %.2f from [%s] to [%s]", amount, fromID, toID)
+var (
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrAccountNotFound   = errors.New("account not found")
+	ErrSameAccount       = errors.New("cannot transfer to the same account")
+	ErrCurrencyMismatch  = errors.New("credit amount must equal the debit amount between accounts of the same currency")
+)
+
+const maxTransferAttempts = 5
+
+// Transfer implements AccountRepository. Serialization failures and deadlocks detected by
+// Postgres are retried with backoff, since the whole unit of work is safe to run again.
+func (r *accountRepository) Transfer(ctx context.Context, params TransferParams) error {
+	var err error
+	for attempt := 1; attempt <= maxTransferAttempts; attempt++ {
+		err = r.transfer(ctx, params)
+		if err == nil || !isRetryableError(err) {
+			return err
+		}
+
+		log.Printf("🔁 Transfer [%s] -> [%s] hit %v, retrying (attempt %d/%d)", params.FromAccountID, params.ToAccountID, err, attempt, maxTransferAttempts)
+		select {
+		case <-time.After(retryBackoff(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+func (r *accountRepository) transfer(ctx context.Context, params TransferParams) error {
+	fromID, toID, amount, creditAmount := params.FromAccountID, params.ToAccountID, params.Amount, params.CreditAmount
+	if creditAmount.IsZero() {
+		creditAmount = amount
+	}
+	log.Printf("💸 Starting transfer of %s from [%s] to [%s]", amount, fromID, toID)
+
+	if fromID == toID {
+		return ErrSameAccount
+	}
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -29,40 +86,81 @@ func (r *accountRepository) Transfer(ctx context.Context, fromID string, toID st
 		return err
 	}
 
-	// 1. Leer saldo del emisor
-	var fromBalance float64
-	err = tx.QueryRowContext(ctx, `SELECT balance FROM accounts WHERE id = $1`, fromID).Scan(&fromBalance)
-	if err != nil {
-		log.Printf("❌ Failed to get balance for fromAccount [%s]: %v", fromID, err)
+	// 1. Bloquear ambas cuentas, siempre en el mismo orden para evitar deadlocks
+	balances := make(map[string]money.Amount, 2)
+	currencies := make(map[string]Currency, 2)
+	statuses := make(map[string]AccountStatus, 2)
+	types := make(map[string]AccountType, 2)
+	for _, id := range lockOrder(fromID, toID) {
+		var balance money.Amount
+		var currency Currency
+		var status AccountStatus
+		var accountType AccountType
+		err = tx.QueryRowContext(ctx, `SELECT balance, currency, status, type FROM accounts WHERE id = $1 FOR UPDATE`, id).Scan(&balance, &currency, &status, &accountType)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = fmt.Errorf("%w: %s", ErrAccountNotFound, id)
+			}
+			log.Printf("❌ Failed to lock account [%s]: %v", id, err)
+			tx.Rollback()
+			return err
+		}
+		balances[id] = balance
+		currencies[id] = currency
+		statuses[id] = status
+		types[id] = accountType
+	}
+	fromBalance := balances[fromID]
+	log.Printf("💼 FromAccount balance: %s", fromBalance)
+
+	// 2. Verificar que ambas cuentas estén activas y que haya fondos
+	if err := checkTransferStatus(statuses, fromID, toID); err != nil {
+		log.Printf("❌ Transfer [%s] -> [%s] rejected: %v", fromID, toID, err)
 		tx.Rollback()
 		return err
 	}
-	log.Printf("💼 FromAccount balance: %.2f", fromBalance)
 
-	// 2. Verificar fondos
-	if fromBalance < amount {
-		log.Printf("❌ Insufficient funds in [%s]: has %.2f, needs %.2f", fromID, fromBalance, amount)
+	if fromBalance < amount && !isSystemAccount(types[fromID]) {
+		log.Printf("❌ Insufficient funds in [%s]: has %s, needs %s", fromID, fromBalance, amount)
 		tx.Rollback()
-		return errors.New("insufficient funds")
+		return ErrInsufficientFunds
 	}
 
-	// 3. Descontar del emisor
-	_, err = tx.ExecContext(ctx, `UPDATE accounts SET balance = balance - $1 WHERE id = $2`, amount, fromID)
-	if err != nil {
-		log.Printf("❌ Failed to debit fromAccount [%s]: %v", fromID, err)
+	// 3. Registrar asiento contable (debito al emisor, credito al receptor)
+	fromCurrency, toCurrency := currencies[fromID], currencies[toID]
+	var entry *JournalEntry
+	if fromCurrency == toCurrency {
+		if creditAmount != amount {
+			tx.Rollback()
+			return ErrCurrencyMismatch
+		}
+		entry = newTransferEntry(fromID, toID, fromCurrency, amount, params.Reference)
+	} else {
+		entry, err = r.exchangeEntry(ctx, tx,
+			exchangeLeg{AccountID: fromID, Currency: fromCurrency, Amount: amount},
+			exchangeLeg{AccountID: toID, Currency: toCurrency, Amount: creditAmount},
+			params.Reference)
+		if err != nil {
+			log.Printf("❌ Failed to prepare FX positions for transfer [%s] -> [%s]: %v", fromID, toID, err)
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := insertJournalEntry(ctx, tx, entry); err != nil {
+		log.Printf("❌ Failed to post journal entry for transfer [%s] -> [%s]: %v", fromID, toID, err)
 		tx.Rollback()
 		return err
 	}
-	log.Printf("✅ Debited %.2f from [%s]", amount, fromID)
+	log.Printf("📒 Posted journal entry [%s]: debited %s %s from [%s], credited %s %s to [%s]", entry.ID, amount, fromCurrency, fromID, creditAmount, toCurrency, toID)
 
-	// 4. Agregar al receptor
-	_, err = tx.ExecContext(ctx, `UPDATE accounts SET balance = balance + $1 WHERE id = $2`, amount, toID)
-	if err != nil {
-		log.Printf("❌ Failed to credit toAccount [%s]: %v", toID, err)
-		tx.Rollback()
-		return err
+	// 4. Registrar lo que originó el movimiento en la misma transacción
+	if params.Record != nil {
+		if err := params.Record(ctx, tx); err != nil {
+			log.Printf("❌ Failed to record transfer [%s] -> [%s]: %v", fromID, toID, err)
+			tx.Rollback()
+			return err
+		}
 	}
-	log.Printf("✅ Credited %.2f to [%s]", amount, toID)
 
 	// 5. Commit
 	err = tx.Commit()
@@ -71,68 +169,197 @@ func (r *accountRepository) Transfer(ctx context.Context, fromID string, toID st
 		return err
 	}
 
-	log.Printf("✅ Transfer of %.2f from [%s] to [%s] completed successfully", amount, fromID, toID)
+	log.Printf("✅ Transfer of %s from [%s] to [%s] completed successfully", amount, fromID, toID)
 	return nil
 }
 
+// exchangeEntry builds the journal entry of a cross-currency transfer. The FX position
+// accounts of both currencies are created on first use and locked after the customer
+// accounts, again in ascending ID order.
+func (r *accountRepository) exchangeEntry(ctx context.Context, tx *sql.Tx, from, to exchangeLeg, reference string) (*JournalEntry, error) {
+	var err error
+	if from.PositionAccountID, err = ensureSystemAccount(ctx, tx, AccountTypeFXPosition, from.Currency); err != nil {
+		return nil, err
+	}
+	if to.PositionAccountID, err = ensureSystemAccount(ctx, tx, AccountTypeFXPosition, to.Currency); err != nil {
+		return nil, err
+	}
+
+	for _, id := range lockOrder(from.PositionAccountID, to.PositionAccountID) {
+		if _, err := tx.ExecContext(ctx, `SELECT 1 FROM accounts WHERE id = $1 FOR UPDATE`, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return newExchangeEntry(from, to, reference), nil
+}
+
+// lockOrder returns the account IDs in the order their rows must be locked. Every
+// transfer locks in ascending ID order, so two transfers between the same accounts in
+// opposite directions cannot wait on each other.
+func lockOrder(a, b string) []string {
+	if a < b {
+		return []string{a, b}
+	}
+	return []string{b, a}
+}
+
+// isRetryableError reports whether Postgres aborted the transaction because of a
+// serialization failure or a deadlock.
+func isRetryableError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code {
+	case "40001", "40P01": // serialization_failure, deadlock_detected
+		return true
+	}
+	return false
+}
+
+func retryBackoff(attempt int) time.Duration {
+	base := 10 * time.Millisecond << (attempt - 1)
+	return base + time.Duration(rand.Int63n(int64(base)))
+}
+
 // CreateAccount implements AccountRepository.
 func (r *accountRepository) CreateAccount(ctx context.Context, acc *Account) error {
 	query := `
-	INSERT INTO accounts (id, user_id, balance, currency, created_at, updated_at)
-	VALUES ($1, $2, $3, $4, $5, $6)
+	INSERT INTO accounts (id, user_id, type, tier, is_default, status, balance, currency, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 `
-	_, err := r.db.ExecContext(ctx, query, acc.ID, acc.UserID, acc.Balance, acc.Currency, acc.CreatedAt, acc.UpdatedAt)
+	_, err := r.db.ExecContext(ctx, query, acc.ID, acc.UserID, acc.Type, acc.Tier, acc.IsDefault, acc.Status, acc.Balance, acc.Currency, acc.CreatedAt, acc.UpdatedAt)
 	return err
 }
 
-// GetAccountByUserID implements AccountRepository.
+const accountColumns = `id, COALESCE(user_id::text, ''), type, tier, is_default, status, balance, currency, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAccount(row rowScanner) (*Account, error) {
+	var acc Account
+	err := row.Scan(&acc.ID, &acc.UserID, &acc.Type, &acc.Tier, &acc.IsDefault, &acc.Status, &acc.Balance, &acc.Currency, &acc.CreatedAt, &acc.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &acc, nil
+}
+
+// GetAccountByUserID implements AccountRepository. It returns the user's default account,
+// falling back to the oldest open one.
 func (r *accountRepository) GetAccountByUserID(ctx context.Context, userID string) (*Account, error) {
 	query := `
-	SELECT id, user_id, balance, currency, created_at
+	SELECT ` + accountColumns + `
 	FROM accounts
 	WHERE user_id = $1
+	ORDER BY is_default DESC, status = 'closed', created_at
 	LIMIT 1
 `
-	row := r.db.QueryRowContext(ctx, query, userID)
+	return scanAccount(r.db.QueryRowContext(ctx, query, userID))
+}
 
-	var acc Account
-	err := row.Scan(&acc.ID, &acc.UserID, &acc.Balance, &acc.Currency, &acc.CreatedAt)
+// ListAccountsByUserID implements AccountRepository.
+func (r *accountRepository) ListAccountsByUserID(ctx context.Context, userID string) ([]Account, error) {
+	query := `
+	SELECT ` + accountColumns + `
+	FROM accounts
+	WHERE user_id = $1
+	ORDER BY is_default DESC, created_at
+`
+	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []Account
+	for rows.Next() {
+		acc, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
 		}
+		accounts = append(accounts, *acc)
+	}
+
+	return accounts, rows.Err()
+}
+
+// ListCustomerAccounts implements AccountRepository.
+func (r *accountRepository) ListCustomerAccounts(ctx context.Context, openedBefore time.Time, afterID string, limit int) ([]Account, error) {
+	query := `
+	SELECT ` + accountColumns + `
+	FROM accounts
+	WHERE user_id IS NOT NULL AND created_at < $1 AND id > $2
+	ORDER BY id
+	LIMIT $3
+`
+	if afterID == "" {
+		afterID = uuid.Nil.String()
+	}
+	rows, err := r.db.QueryContext(ctx, query, openedBefore, afterID, limit)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &acc, nil
+	var accounts []Account
+	for rows.Next() {
+		acc, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, *acc)
+	}
+
+	return accounts, rows.Err()
 }
 
 func (r *accountRepository) GetAccountByID(ctx context.Context, accountID string) (*Account, error) {
 	query := `
-        SELECT id, user_id, balance, currency, created_at
+        SELECT ` + accountColumns + `
         FROM accounts
         WHERE id = $1
         LIMIT 1
         `
-	row := r.db.QueryRowContext(ctx, query, accountID)
+	return scanAccount(r.db.QueryRowContext(ctx, query, accountID))
+}
 
-	var acc Account
-	err := row.Scan(&acc.ID, &acc.UserID, &acc.Balance, &acc.Currency, &acc.CreatedAt)
+// SetDefaultAccount implements AccountRepository.
+func (r *accountRepository) SetDefaultAccount(ctx context.Context, userID, accountID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
-		}
-		return nil, err
+		return err
 	}
-	return &acc, nil
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `UPDATE accounts SET is_default = false, updated_at = now() WHERE user_id = $1 AND is_default AND id <> $2`, userID, accountID)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `UPDATE accounts SET is_default = true, updated_at = now() WHERE user_id = $1 AND id = $2 AND status <> 'closed'`, userID, accountID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAccountNotFound
+	}
+
+	return tx.Commit()
 }
 
 // GetBalance implements AccountRepository.
-func (r *accountRepository) GetBalance(ctx context.Context, accountID string) (float64, error) {
+func (r *accountRepository) GetBalance(ctx context.Context, accountID string) (money.Amount, error) {
 	query := `SELECT balance FROM accounts WHERE id = $1`
 	row := r.db.QueryRowContext(ctx, query, accountID)
 
-	var balance float64
+	var balance money.Amount
 	err := row.Scan(&balance)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -144,6 +371,68 @@ func (r *accountRepository) GetBalance(ctx context.Context, accountID string) (f
 	return balance, nil
 }
 
+// GetPostings returns every ledger posting for the account, oldest first.
+func (r *accountRepository) GetPostings(ctx context.Context, accountID string) ([]Posting, error) {
+	query := `
+	SELECT id, journal_entry_id, account_id, direction, amount, currency, created_at
+	FROM postings
+	WHERE account_id = $1
+	ORDER BY created_at, id
+`
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var postings []Posting
+	for rows.Next() {
+		var p Posting
+		if err := rows.Scan(&p.ID, &p.JournalEntryID, &p.AccountID, &p.Direction, &p.Amount, &p.Currency, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		postings = append(postings, p)
+	}
+
+	return postings, rows.Err()
+}
+
+// GetBalanceAt implements AccountRepository.
+func (r *accountRepository) GetBalanceAt(ctx context.Context, accountID string, at time.Time) (money.Amount, error) {
+	query := `
+	SELECT COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END), 0)
+	FROM postings
+	WHERE account_id = $1 AND created_at < $2
+`
+	var balance money.Amount
+	err := r.db.QueryRowContext(ctx, query, accountID, at).Scan(&balance)
+	return balance, err
+}
+
+// RebuildBalance recomputes the cached balance of the account from its postings and stores it.
+func (r *accountRepository) RebuildBalance(ctx context.Context, accountID string) (money.Amount, error) {
+	query := `
+	UPDATE accounts
+	SET balance = COALESCE((
+		SELECT SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END)
+		FROM postings
+		WHERE account_id = $1
+	), 0), updated_at = now()
+	WHERE id = $1
+	RETURNING balance
+`
+	var balance money.Amount
+	err := r.db.QueryRowContext(ctx, query, accountID).Scan(&balance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrAccountNotFound
+		}
+		return 0, err
+	}
+
+	return balance, nil
+}
+
 func NewAccountRepository(db *sql.DB) AccountRepository {
 	return &accountRepository{db: db}
 }
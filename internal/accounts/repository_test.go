@@ -0,0 +1,190 @@
+package accounts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-bank-app/pkg/money"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// TestPostgresDbURI points the repository tests at a disposable database, e.g. the one
+// started by docker-compose. Tests that need it are skipped when it is not set.
+const TestPostgresDbURI = "TEST_POSTGRES_DB_URI"
+
+func TestLockOrder(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected []string
+	}{
+		{a: "aaa", b: "bbb", expected: []string{"aaa", "bbb"}},
+		{a: "bbb", b: "aaa", expected: []string{"aaa", "bbb"}},
+	}
+
+	for _, test := range tests {
+		if got := lockOrder(test.a, test.b); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("lockOrder(%s, %s): expected %v, got %v", test.a, test.b, test.expected, got)
+		}
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "serialization failure", err: &pq.Error{Code: "40001"}, expected: true},
+		{name: "deadlock", err: fmt.Errorf("wrapped: %w", &pq.Error{Code: "40P01"}), expected: true},
+		{name: "unique violation", err: &pq.Error{Code: "23505"}, expected: false},
+		{name: "insufficient funds", err: ErrInsufficientFunds, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isRetryableError(test.err); got != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+// TestTransfer_ConcurrentRingConservesMoney hammers transfers in both directions around
+// a ring of accounts and checks that no money is created, destroyed or overdrawn.
+func TestTransfer_ConcurrentRingConservesMoney(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewAccountRepository(db)
+	ctx := context.Background()
+
+	const (
+		ringSize  = 5
+		workers   = 20
+		transfers = 25
+	)
+	reference := "concurrency-test-" + uuid.New().String()
+	startBalance := money.MustParse("100.00")
+
+	ring := make([]string, ringSize)
+	for i := range ring {
+		ring[i] = createTestAccount(t, db, reference, startBalance)
+	}
+	total := startBalance * ringSize
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*transfers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < transfers; i++ {
+				from, to := ring[(w+i)%ringSize], ring[(w+i+1)%ringSize]
+				if w%2 == 1 {
+					from, to = to, from
+				}
+
+				err := repo.Transfer(ctx, TransferParams{
+					FromAccountID: from,
+					ToAccountID:   to,
+					Amount:        money.FromMinor(int64(1 + (w*i)%2500)),
+					Reference:     reference,
+				})
+				if err != nil && !errors.Is(err, ErrInsufficientFunds) {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected transfer error: %v", err)
+	}
+
+	var sum money.Amount
+	for _, id := range ring {
+		balance, err := repo.GetBalance(ctx, id)
+		if err != nil {
+			t.Fatalf("failed to read balance of %s: %v", id, err)
+		}
+		if balance.IsNegative() {
+			t.Errorf("account %s was overdrawn: %s", id, balance)
+		}
+
+		rebuilt, err := repo.RebuildBalance(ctx, id)
+		if err != nil {
+			t.Fatalf("failed to rebuild balance of %s: %v", id, err)
+		}
+		if rebuilt != balance {
+			t.Errorf("cached balance %s of %s does not match its postings %s", balance, id, rebuilt)
+		}
+
+		sum = sum.Add(balance)
+	}
+
+	if sum != total {
+		t.Errorf("expected the ring to hold %s, got %s", total, sum)
+	}
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	uri, ok := os.LookupEnv(TestPostgresDbURI)
+	if !ok {
+		t.Skipf("%s is not set", TestPostgresDbURI)
+	}
+
+	db, err := sql.Open("postgres", uri)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema, err := os.ReadFile("../../docker/local/init.sql")
+	if err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+	if _, err := db.Exec(string(schema)); err != nil {
+		t.Fatalf("failed to apply schema: %v", err)
+	}
+
+	return db
+}
+
+// createTestAccount opens an account funded through a balanced journal entry, so its
+// balance can be rebuilt from the ledger.
+func createTestAccount(t *testing.T, db *sql.DB, reference string, balance money.Amount) string {
+	t.Helper()
+	ctx := context.Background()
+
+	funding, account := uuid.New().String(), uuid.New().String()
+	for _, id := range []string{funding, account} {
+		_, err := db.ExecContext(ctx, `INSERT INTO accounts (id, balance, currency) VALUES ($1, 0, $2)`, id, CurrencyMXN)
+		if err != nil {
+			t.Fatalf("failed to create account: %v", err)
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	entry := newTransferEntry(funding, account, CurrencyMXN, balance, reference)
+	if err := insertJournalEntry(ctx, tx, entry); err != nil {
+		tx.Rollback()
+		t.Fatalf("failed to fund account: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("failed to commit funding: %v", err)
+	}
+
+	return account
+}
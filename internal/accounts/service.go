@@ -3,16 +3,36 @@ package accounts
 import (
 	"context"
 	"errors"
+	"go-bank-app/pkg/money"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type AccountService interface {
-	Create(ctx context.Context, userID string, currency Currency) (*Account, error)
-	GetBalance(ctx context.Context, accountID string) (float64, error)
+	Create(ctx context.Context, userID string, currency Currency, accountType AccountType) (*Account, error)
+	GetBalance(ctx context.Context, accountID string) (money.Amount, error)
+	// GetAccountByUserID returns the user's default account.
 	GetAccountByUserID(ctx context.Context, userID string) (*Account, error)
 	GetAccountByID(ctx context.Context, accountID string) (*Account, error)
+	// GetAccountForUser returns the given account if the user owns it, or the user's
+	// default account when accountID is empty.
+	GetAccountForUser(ctx context.Context, userID, accountID string) (*Account, error)
+	ListAccounts(ctx context.Context, userID string) ([]Account, error)
+	// ListCustomerAccounts pages through the accounts owned by users that were opened
+	// before the given time. Pass the last ID of a page as afterID to get the next one.
+	ListCustomerAccounts(ctx context.Context, openedBefore time.Time, afterID string, limit int) ([]Account, error)
+	SetDefaultAccount(ctx context.Context, userID, accountID string) error
+	GetPostings(ctx context.Context, accountID string) ([]Posting, error)
+	// GetBalanceAt returns the balance the account had at the given time.
+	GetBalanceAt(ctx context.Context, accountID string, at time.Time) (money.Amount, error)
+	RebuildBalance(ctx context.Context, accountID string) (money.Amount, error)
+	// ChangeStatus freezes, unfreezes or closes an account and records who did it.
+	ChangeStatus(ctx context.Context, change StatusChange) (*StatusAudit, error)
+	GetStatusAudit(ctx context.Context, accountID string) ([]StatusAudit, error)
+	// GetClearingAccount returns the bank's clearing account for the currency, which
+	// deposits are paid from and withdrawals paid into.
+	GetClearingAccount(ctx context.Context, currency Currency) (*Account, error)
 }
 
 type accountService struct {
@@ -20,27 +40,38 @@ type accountService struct {
 }
 
 // Create implements AccountService.
-func (s *accountService) Create(ctx context.Context, userID string, currency Currency) (*Account, error) {
-	if currency != CurrencyMXN {
+func (s *accountService) Create(ctx context.Context, userID string, currency Currency, accountType AccountType) (*Account, error) {
+	if !currency.Valid() {
 		return nil, errors.New("invalid currency")
 	}
 
-	// Verificar si ya tiene cuenta
-	existing, _ := s.repo.GetAccountByUserID(ctx, userID)
-	if existing != nil {
-		return nil, errors.New("user already has an account")
+	if accountType == "" {
+		accountType = AccountTypeChecking
+	}
+	if accountType != AccountTypeChecking && accountType != AccountTypeSavings {
+		return nil, errors.New("invalid account type")
+	}
+
+	// La primera cuenta abierta del usuario se vuelve la predeterminada
+	existing, err := s.repo.GetAccountByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
 	account := &Account{
 		ID:        uuid.New().String(),
 		UserID:    userID,
+		Type:      accountType,
+		Tier:      AccountTierStandard,
+		IsDefault: existing == nil || !existing.IsDefault,
+		Status:    AccountStatusActive,
 		Balance:   0,
 		Currency:  currency,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	err := s.repo.CreateAccount(ctx, account)
+	err = s.repo.CreateAccount(ctx, account)
 	if err != nil {
 		return nil, err
 	}
@@ -53,15 +84,93 @@ func (s *accountService) GetAccountByUserID(ctx context.Context, userID string)
 	return s.repo.GetAccountByUserID(ctx, userID)
 }
 
+// Malformed IDs, e.g. mistyped ones, are reported as missing instead of reaching the
+// database.
 func (s *accountService) GetAccountByID(ctx context.Context, accountID string) (*Account, error) {
+	if _, err := uuid.Parse(accountID); err != nil {
+		return nil, nil
+	}
 	return s.repo.GetAccountByID(ctx, accountID)
 }
 
+// GetAccountForUser implements AccountService.
+func (s *accountService) GetAccountForUser(ctx context.Context, userID, accountID string) (*Account, error) {
+	if accountID == "" {
+		return s.repo.GetAccountByUserID(ctx, userID)
+	}
+
+	account, err := s.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	// Someone else's account is reported as missing so IDs cannot be probed.
+	if account == nil || account.UserID != userID {
+		return nil, nil
+	}
+	return account, nil
+}
+
+// ListAccounts implements AccountService.
+func (s *accountService) ListAccounts(ctx context.Context, userID string) ([]Account, error) {
+	return s.repo.ListAccountsByUserID(ctx, userID)
+}
+
+// ListCustomerAccounts implements AccountService.
+func (s *accountService) ListCustomerAccounts(ctx context.Context, openedBefore time.Time, afterID string, limit int) ([]Account, error) {
+	return s.repo.ListCustomerAccounts(ctx, openedBefore, afterID, limit)
+}
+
+// SetDefaultAccount implements AccountService.
+func (s *accountService) SetDefaultAccount(ctx context.Context, userID, accountID string) error {
+	return s.repo.SetDefaultAccount(ctx, userID, accountID)
+}
+
 // GetBalance implements AccountService.
-func (s *accountService) GetBalance(ctx context.Context, accountID string) (float64, error) {
+func (s *accountService) GetBalance(ctx context.Context, accountID string) (money.Amount, error) {
 	return s.repo.GetBalance(ctx, accountID)
 }
 
+// GetPostings implements AccountService.
+func (s *accountService) GetPostings(ctx context.Context, accountID string) ([]Posting, error) {
+	return s.repo.GetPostings(ctx, accountID)
+}
+
+// GetBalanceAt implements AccountService.
+func (s *accountService) GetBalanceAt(ctx context.Context, accountID string, at time.Time) (money.Amount, error) {
+	return s.repo.GetBalanceAt(ctx, accountID, at)
+}
+
+// RebuildBalance implements AccountService.
+func (s *accountService) RebuildBalance(ctx context.Context, accountID string) (money.Amount, error) {
+	return s.repo.RebuildBalance(ctx, accountID)
+}
+
+// ChangeStatus implements AccountService.
+func (s *accountService) ChangeStatus(ctx context.Context, change StatusChange) (*StatusAudit, error) {
+	if change.SweepToAccountID != "" && change.Status != AccountStatusClosed {
+		return nil, errors.New("a sweep account is only allowed when closing")
+	}
+	return s.repo.ChangeStatus(ctx, change)
+}
+
+// GetStatusAudit implements AccountService.
+func (s *accountService) GetStatusAudit(ctx context.Context, accountID string) ([]StatusAudit, error) {
+	return s.repo.GetStatusAudit(ctx, accountID)
+}
+
+// GetClearingAccount implements AccountService.
+func (s *accountService) GetClearingAccount(ctx context.Context, currency Currency) (*Account, error) {
+	if !currency.Valid() {
+		return nil, errors.New("invalid currency")
+	}
+
+	id, err := s.repo.EnsureSystemAccount(ctx, AccountTypeClearing, currency)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetAccountByID(ctx, id)
+}
+
 func NewAccountService(repo AccountRepository) AccountService {
 	return &accountService{repo: repo}
 }
@@ -0,0 +1,207 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-bank-app/pkg/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountStatus controls whether an account can move money. Frozen accounts can neither
+// send nor receive until they are unfrozen; closed accounts never can again.
+type AccountStatus string
+
+const (
+	AccountStatusActive AccountStatus = "active"
+	AccountStatusFrozen AccountStatus = "frozen"
+	AccountStatusClosed AccountStatus = "closed"
+)
+
+var (
+	ErrAccountFrozen            = errors.New("account is frozen")
+	ErrAccountClosed            = errors.New("account is closed")
+	ErrInvalidStatusTransition  = errors.New("invalid account status transition")
+	ErrNonZeroBalance           = errors.New("account balance must be zero to close it, or swept to another account")
+	ErrSweepCurrencyMismatch    = errors.New("the sweep account must use the same currency")
+	ErrSweepAccountNotAvailable = errors.New("the sweep account is not active")
+)
+
+// allowedTransitions lists the statuses each status can move to.
+var allowedTransitions = map[AccountStatus][]AccountStatus{
+	AccountStatusActive: {AccountStatusFrozen, AccountStatusClosed},
+	AccountStatusFrozen: {AccountStatusActive},
+}
+
+func canTransition(from, to AccountStatus) bool {
+	for _, allowed := range allowedTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// statusError returns the error a transfer touching an account in the given status fails with.
+func statusError(accountID string, status AccountStatus) error {
+	switch status {
+	case AccountStatusActive:
+		return nil
+	case AccountStatusFrozen:
+		return fmt.Errorf("%w: %s", ErrAccountFrozen, accountID)
+	case AccountStatusClosed:
+		return fmt.Errorf("%w: %s", ErrAccountClosed, accountID)
+	}
+	return fmt.Errorf("account %s has unknown status %q", accountID, status)
+}
+
+// StatusChange asks to move an account to a new status.
+type StatusChange struct {
+	AccountID string
+	Status    AccountStatus
+	// ChangedBy is the ID of the user making the change, recorded in the audit trail.
+	ChangedBy string
+	Reason    string
+	// SweepToAccountID receives the remaining balance when closing. Without it, only
+	// accounts with a zero balance can be closed.
+	SweepToAccountID string
+}
+
+// StatusAudit is the audit trail entry written for every status change.
+type StatusAudit struct {
+	ID               string        `json:"id"`
+	AccountID        string        `json:"account_id"`
+	PreviousStatus   AccountStatus `json:"previous_status"`
+	NewStatus        AccountStatus `json:"new_status"`
+	ChangedBy        string        `json:"changed_by"`
+	Reason           string        `json:"reason"`
+	SweptToAccountID string        `json:"swept_to_account_id,omitempty"`
+	SweptAmount      money.Amount  `json:"swept_amount,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+}
+
+// ChangeStatus implements AccountRepository. The status update, the optional sweep of the
+// remaining balance and the audit entry commit together.
+func (r *accountRepository) ChangeStatus(ctx context.Context, change StatusChange) (*StatusAudit, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := []string{change.AccountID}
+	if change.SweepToAccountID != "" {
+		if change.SweepToAccountID == change.AccountID {
+			return nil, ErrSameAccount
+		}
+		ids = lockOrder(change.AccountID, change.SweepToAccountID)
+	}
+
+	locked := make(map[string]*Account, len(ids))
+	for _, id := range ids {
+		acc, err := scanAccount(tx.QueryRowContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE id = $1 FOR UPDATE`, id))
+		if err != nil {
+			return nil, err
+		}
+		if acc == nil {
+			return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, id)
+		}
+		locked[id] = acc
+	}
+
+	account := locked[change.AccountID]
+	if !canTransition(account.Status, change.Status) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, account.Status, change.Status)
+	}
+
+	audit := &StatusAudit{
+		ID:             uuid.New().String(),
+		AccountID:      account.ID,
+		PreviousStatus: account.Status,
+		NewStatus:      change.Status,
+		ChangedBy:      change.ChangedBy,
+		Reason:         change.Reason,
+		CreatedAt:      time.Now(),
+	}
+
+	if change.Status == AccountStatusClosed && !account.Balance.IsZero() {
+		if change.SweepToAccountID == "" {
+			return nil, ErrNonZeroBalance
+		}
+
+		sweep := locked[change.SweepToAccountID]
+		if sweep.Status != AccountStatusActive {
+			return nil, ErrSweepAccountNotAvailable
+		}
+		if sweep.Currency != account.Currency {
+			return nil, ErrSweepCurrencyMismatch
+		}
+
+		entry := newTransferEntry(account.ID, sweep.ID, account.Currency, account.Balance, "close:"+account.ID)
+		if err := insertJournalEntry(ctx, tx, entry); err != nil {
+			return nil, err
+		}
+		audit.SweptToAccountID, audit.SweptAmount = sweep.ID, account.Balance
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE accounts
+	SET status = $1, is_default = is_default AND $1 <> 'closed', updated_at = $2
+	WHERE id = $3
+`, change.Status, audit.CreatedAt, account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO account_status_audit (id, account_id, previous_status, new_status, changed_by, reason, swept_to_account_id, swept_amount, created_at)
+	VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid, $6, NULLIF($7, '')::uuid, NULLIF($8::numeric, 0), $9)
+`, audit.ID, audit.AccountID, audit.PreviousStatus, audit.NewStatus, audit.ChangedBy, audit.Reason, audit.SweptToAccountID, audit.SweptAmount, audit.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return audit, nil
+}
+
+// GetStatusAudit implements AccountRepository.
+func (r *accountRepository) GetStatusAudit(ctx context.Context, accountID string) ([]StatusAudit, error) {
+	query := `
+	SELECT id, account_id, previous_status, new_status, COALESCE(changed_by::text, ''), reason,
+		COALESCE(swept_to_account_id::text, ''), COALESCE(swept_amount, 0), created_at
+	FROM account_status_audit
+	WHERE account_id = $1
+	ORDER BY created_at, id
+`
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var audits []StatusAudit
+	for rows.Next() {
+		var a StatusAudit
+		if err := rows.Scan(&a.ID, &a.AccountID, &a.PreviousStatus, &a.NewStatus, &a.ChangedBy, &a.Reason, &a.SweptToAccountID, &a.SweptAmount, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		audits = append(audits, a)
+	}
+
+	return audits, rows.Err()
+}
+
+// checkTransferStatus rejects transfers that touch an account which is not active.
+func checkTransferStatus(statuses map[string]AccountStatus, ids ...string) error {
+	for _, id := range ids {
+		if err := statusError(id, statuses[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
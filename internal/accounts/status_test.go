@@ -0,0 +1,53 @@
+package accounts
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to AccountStatus
+		expected bool
+	}{
+		{from: AccountStatusActive, to: AccountStatusFrozen, expected: true},
+		{from: AccountStatusFrozen, to: AccountStatusActive, expected: true},
+		{from: AccountStatusActive, to: AccountStatusClosed, expected: true},
+		{from: AccountStatusFrozen, to: AccountStatusClosed, expected: false},
+		{from: AccountStatusClosed, to: AccountStatusActive, expected: false},
+		{from: AccountStatusActive, to: AccountStatusActive, expected: false},
+	}
+
+	for _, test := range tests {
+		if got := canTransition(test.from, test.to); got != test.expected {
+			t.Errorf("canTransition(%s, %s): expected %v, got %v", test.from, test.to, test.expected, got)
+		}
+	}
+}
+
+func TestCheckTransferStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		from, to    AccountStatus
+		expectedErr error
+	}{
+		{name: "both active", from: AccountStatusActive, to: AccountStatusActive},
+		{name: "frozen sender", from: AccountStatusFrozen, to: AccountStatusActive, expectedErr: ErrAccountFrozen},
+		{name: "frozen receiver", from: AccountStatusActive, to: AccountStatusFrozen, expectedErr: ErrAccountFrozen},
+		{name: "closed receiver", from: AccountStatusActive, to: AccountStatusClosed, expectedErr: ErrAccountClosed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statuses := map[string]AccountStatus{"from": test.from, "to": test.to}
+
+			err := checkTransferStatus(statuses, "from", "to")
+			if test.expectedErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !errors.Is(err, test.expectedErr) {
+				t.Errorf("expected %v, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package accounts
+
+import (
+	"context"
+	"go-bank-app/pkg/database"
+
+	"github.com/google/uuid"
+)
+
+// systemAccountNamespace seeds the deterministic IDs of bank-owned accounts, so every
+// instance of the app agrees on them without a lookup table.
+var systemAccountNamespace = uuid.MustParse("6f1c9a52-3f0e-4b7d-9a57-2d8e4c1b0f3a")
+
+// SystemAccountID returns the ID of the bank-owned account of the given type and currency.
+func SystemAccountID(accountType AccountType, currency Currency) string {
+	return uuid.NewSHA1(systemAccountNamespace, []byte(string(accountType)+":"+string(currency))).String()
+}
+
+// ensureSystemAccount creates the bank-owned account of the given type and currency if
+// it does not exist yet and returns its ID.
+func ensureSystemAccount(ctx context.Context, exec database.Executor, accountType AccountType, currency Currency) (string, error) {
+	id := SystemAccountID(accountType, currency)
+	_, err := exec.ExecContext(ctx, `
+	INSERT INTO accounts (id, type, balance, currency)
+	VALUES ($1, $2, 0, $3)
+	ON CONFLICT (id) DO NOTHING
+`, id, accountType, currency)
+	return id, err
+}
+
+// EnsureSystemAccount implements AccountRepository.
+func (r *accountRepository) EnsureSystemAccount(ctx context.Context, accountType AccountType, currency Currency) (string, error) {
+	return ensureSystemAccount(ctx, r.db, accountType, currency)
+}
@@ -21,6 +21,7 @@ type baseRequest struct {
 
 type registerRequest struct {
 	baseRequest
+	FullName string `json:"full_name"`
 }
 
 type loginRequest struct {
@@ -34,7 +35,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.service.Register(r.Context(), req.Email, req.Password)
+	user, err := h.service.Register(r.Context(), req.Email, req.Password, req.FullName)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -42,8 +43,9 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"id":    user.ID,
-		"email": user.Email,
+		"id":        user.ID,
+		"email":     user.Email,
+		"full_name": user.FullName,
 	})
 }
 
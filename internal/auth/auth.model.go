@@ -2,10 +2,17 @@ package auth
 
 import "time"
 
+const (
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+)
+
 type User struct {
 	ID             string    `json:"id"`
 	Email          string    `json:"email"`
+	FullName       string    `json:"full_name"`
 	HashedPassword string    `json:"-"`
+	Role           string    `json:"role"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
@@ -9,6 +9,7 @@ import (
 type AuthRepository interface {
 	CreateUser(ctx context.Context, user *User) error
 	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindByID(ctx context.Context, id string) (*User, error)
 }
 
 type authRepository struct {
@@ -21,26 +22,40 @@ func NewAuthRepository(db *sql.DB) AuthRepository {
 
 func (r *authRepository) CreateUser(ctx context.Context, user *User) error {
 	query := `
-		INSERT INTO users(id, email, hashed_password, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users(id, email, full_name, hashed_password, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
-	_, err := r.db.ExecContext(ctx, query, user.ID, user.Email, user.HashedPassword, user.CreatedAt, user.UpdatedAt)
+	_, err := r.db.ExecContext(ctx, query, user.ID, user.Email, user.FullName, user.HashedPassword, user.Role, user.CreatedAt, user.UpdatedAt)
 
 	return err
 }
 
 func (r *authRepository) FindByEmail(ctx context.Context, email string) (*User, error) {
 	query := `
-		SELECT id, email, hashed_password, created_at, updated_at
+		SELECT ` + userColumns + `
 		FROM users
 		where email = $1
 	`
 
-	row := r.db.QueryRowContext(ctx, query, email)
+	return scanUser(r.db.QueryRowContext(ctx, query, email))
+}
+
+func (r *authRepository) FindByID(ctx context.Context, id string) (*User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		where id = $1
+	`
+
+	return scanUser(r.db.QueryRowContext(ctx, query, id))
+}
+
+const userColumns = `id, email, full_name, hashed_password, role, created_at, updated_at`
 
+func scanUser(row *sql.Row) (*User, error) {
 	var user User
-	err := row.Scan(&user.ID, &user.Email, &user.HashedPassword, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(&user.ID, &user.Email, &user.FullName, &user.HashedPassword, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
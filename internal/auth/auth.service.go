@@ -6,16 +6,20 @@ import (
 	"go-bank-app/pkg/jwt"
 	"go-bank-app/utils"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type AuthService interface {
-	Register(ctx context.Context, email, password string) (*User, error)
+	Register(ctx context.Context, email, password, fullName string) (*User, error)
 
 	// Returns a JWT
 	Login(ctx context.Context, email, password string) (string, error)
+
+	// GetUser returns the user with the given ID, or nil when there is none.
+	GetUser(ctx context.Context, id string) (*User, error)
 }
 
 type authService struct {
@@ -38,7 +42,7 @@ func (s *authService) Login(ctx context.Context, email string, password string)
 		return "", errors.New("invalid credentials")
 	}
 
-	token, err := jwt.GenerateToken(user.ID, user.Email)
+	token, err := jwt.GenerateToken(user.ID, user.Email, user.Role)
 	if err != nil {
 		log.Println("❌ JWT error:", err)
 		return "", err
@@ -48,7 +52,7 @@ func (s *authService) Login(ctx context.Context, email string, password string)
 }
 
 // Register implements AuthService.
-func (s *authService) Register(ctx context.Context, email string, password string) (*User, error) {
+func (s *authService) Register(ctx context.Context, email string, password string, fullName string) (*User, error) {
 	existingUser, _ := s.repo.FindByEmail(ctx, email)
 	if existingUser != nil {
 		return nil, errors.New("User already exists")
@@ -62,7 +66,9 @@ func (s *authService) Register(ctx context.Context, email string, password strin
 	user := &User{
 		ID:             uuid.New().String(),
 		Email:          email,
+		FullName:       strings.TrimSpace(fullName),
 		HashedPassword: string(hashedPassword),
+		Role:           RoleCustomer,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
@@ -74,3 +80,8 @@ func (s *authService) Register(ctx context.Context, email string, password strin
 
 	return user, nil
 }
+
+// GetUser implements AuthService.
+func (s *authService) GetUser(ctx context.Context, id string) (*User, error) {
+	return s.repo.FindByID(ctx, id)
+}
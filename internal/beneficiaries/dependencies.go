@@ -0,0 +1,9 @@
+package beneficiaries
+
+import "context"
+
+type PayeeDirectory interface {
+	// LookupAccount returns the destination account with the given ID and its owner, or
+	// nil when it does not exist.
+	LookupAccount(ctx context.Context, accountID string) (*PayeeAccount, error)
+}
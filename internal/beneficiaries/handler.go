@@ -0,0 +1,107 @@
+package beneficiaries
+
+import (
+	"encoding/json"
+	"errors"
+	"go-bank-app/pkg/middleware"
+	"net/http"
+)
+
+type BeneficiaryHandler struct {
+	service BeneficiaryService
+}
+
+func NewBeneficiaryHandler(service BeneficiaryService) *BeneficiaryHandler {
+	return &BeneficiaryHandler{service: service}
+}
+
+type beneficiaryRequest struct {
+	AccountID string `json:"account_id"`
+	Nickname  string `json:"nickname"`
+}
+
+func (h *BeneficiaryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	var req beneficiaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	b, err := h.service.Add(r.Context(), userID, req.AccountID, req.Nickname)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(b)
+}
+
+func (h *BeneficiaryHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	list, err := h.service.List(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Error retrieving beneficiaries", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// Rename changes the nickname of the beneficiary in the path.
+func (h *BeneficiaryHandler) Rename(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	var req beneficiaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	b, err := h.service.Rename(r.Context(), userID, r.PathValue("id"), req.Nickname)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(b)
+}
+
+func (h *BeneficiaryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	if err := h.service.Remove(r.Context(), userID, r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Confirm returns the masked owner name of ?account_id= so the sender can check the
+// destination before paying it.
+func (h *BeneficiaryHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	confirmation, err := h.service.ConfirmPayee(r.Context(), r.URL.Query().Get("account_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(confirmation)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrBeneficiaryNotFound), errors.Is(err, ErrPayeeNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrDuplicateNickname), errors.Is(err, ErrDuplicateAccount):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrPayeeUnavailable):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
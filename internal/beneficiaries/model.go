@@ -0,0 +1,46 @@
+package beneficiaries
+
+import (
+	"go-bank-app/pkg/money"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Beneficiary is an account a user saved under a nickname to send transfers to.
+type Beneficiary struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	AccountID string    `json:"account_id"`
+	Nickname  string    `json:"nickname"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PayeeAccount is what the directory knows about a destination account.
+type PayeeAccount struct {
+	AccountID string
+	UserID    string // Empty for accounts owned by the bank
+	OwnerName string
+	Currency  money.Currency
+	Active    bool
+}
+
+// PayeeConfirmation lets a sender check who they are about to pay without revealing
+// the full name of the owner.
+type PayeeConfirmation struct {
+	AccountID  string         `json:"account_id"`
+	MaskedName string         `json:"masked_name"`
+	Currency   money.Currency `json:"currency"`
+}
+
+// maskName keeps the first letter of every word of name and hides the rest, e.g.
+// "María López" becomes "M**** L****".
+func maskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		first, size := utf8.DecodeRuneInString(word)
+		words[i] = string(first) + strings.Repeat("*", utf8.RuneCountInString(word[size:]))
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,102 @@
+package beneficiaries
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+type BeneficiaryRepository interface {
+	Create(ctx context.Context, b *Beneficiary) error
+	GetByID(ctx context.Context, id string) (*Beneficiary, error)
+	ListByUser(ctx context.Context, userID string) ([]Beneficiary, error)
+	UpdateNickname(ctx context.Context, b *Beneficiary) error
+	Delete(ctx context.Context, id string) error
+}
+
+type beneficiaryRepository struct {
+	db *sql.DB
+}
+
+func NewBeneficiaryRepository(db *sql.DB) BeneficiaryRepository {
+	return &beneficiaryRepository{db: db}
+}
+
+const beneficiaryColumns = `id, user_id, account_id, nickname, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBeneficiary(row rowScanner) (*Beneficiary, error) {
+	var b Beneficiary
+	err := row.Scan(&b.ID, &b.UserID, &b.AccountID, &b.Nickname, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *beneficiaryRepository) Create(ctx context.Context, b *Beneficiary) error {
+	query := `
+	INSERT INTO beneficiaries (id, user_id, account_id, nickname, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+`
+	_, err := r.db.ExecContext(ctx, query, b.ID, b.UserID, b.AccountID, b.Nickname, b.CreatedAt, b.UpdatedAt)
+	return duplicateError(err)
+}
+
+func (r *beneficiaryRepository) GetByID(ctx context.Context, id string) (*Beneficiary, error) {
+	query := `SELECT ` + beneficiaryColumns + ` FROM beneficiaries WHERE id = $1`
+	return scanBeneficiary(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *beneficiaryRepository) ListByUser(ctx context.Context, userID string) ([]Beneficiary, error) {
+	query := `SELECT ` + beneficiaryColumns + ` FROM beneficiaries WHERE user_id = $1 ORDER BY nickname`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Beneficiary
+	for rows.Next() {
+		b, err := scanBeneficiary(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *b)
+	}
+	return list, rows.Err()
+}
+
+func (r *beneficiaryRepository) UpdateNickname(ctx context.Context, b *Beneficiary) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE beneficiaries SET nickname = $1, updated_at = $2 WHERE id = $3`, b.Nickname, b.UpdatedAt, b.ID)
+	return duplicateError(err)
+}
+
+func (r *beneficiaryRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM beneficiaries WHERE id = $1`, id)
+	return err
+}
+
+// duplicateError reports a unique violation as the duplicate it stands for. The service
+// checks for duplicates first, but two concurrent requests can both pass that check.
+func duplicateError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" { // unique_violation
+		return err
+	}
+	switch pqErr.Constraint {
+	case "beneficiaries_user_account_key":
+		return ErrDuplicateAccount
+	case "idx_beneficiaries_user_nickname":
+		return ErrDuplicateNickname
+	}
+	return err
+}
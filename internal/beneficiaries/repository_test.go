@@ -0,0 +1,35 @@
+package beneficiaries
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestDuplicateError(t *testing.T) {
+	otherConstraint := &pq.Error{Code: "23505", Constraint: "beneficiaries_pkey"}
+	foreignKey := &pq.Error{Code: "23503", Constraint: "beneficiaries_account_id_fkey"}
+	other := errors.New("connection reset")
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{name: "same account", err: &pq.Error{Code: "23505", Constraint: "beneficiaries_user_account_key"}, expected: ErrDuplicateAccount},
+		{name: "same nickname", err: fmt.Errorf("wrapped: %w", &pq.Error{Code: "23505", Constraint: "idx_beneficiaries_user_nickname"}), expected: ErrDuplicateNickname},
+		{name: "other constraint", err: otherConstraint, expected: otherConstraint},
+		{name: "foreign key violation", err: foreignKey, expected: foreignKey},
+		{name: "other error", err: other, expected: other},
+		{name: "no error"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := duplicateError(test.err); got != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package beneficiaries
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrBeneficiaryNotFound = errors.New("beneficiary not found")
+	ErrDuplicateNickname   = errors.New("a beneficiary with this nickname already exists")
+	ErrDuplicateAccount    = errors.New("this account is already saved as a beneficiary")
+	// ErrPayeeNotFound covers accounts that do not exist and accounts owned by the bank,
+	// which cannot receive transfers.
+	ErrPayeeNotFound = errors.New("destination account not found")
+	// ErrPayeeUnavailable is returned for frozen or closed accounts.
+	ErrPayeeUnavailable = errors.New("destination account cannot receive transfers")
+)
+
+const maxNicknameLength = 64
+
+type BeneficiaryService interface {
+	// Add saves the account as a beneficiary of the user, after checking that it exists
+	// and can receive transfers.
+	Add(ctx context.Context, userID, accountID, nickname string) (*Beneficiary, error)
+	List(ctx context.Context, userID string) ([]Beneficiary, error)
+	// Get returns the user's beneficiary. Beneficiaries of other users are reported as
+	// missing.
+	Get(ctx context.Context, userID, id string) (*Beneficiary, error)
+	Rename(ctx context.Context, userID, id, nickname string) (*Beneficiary, error)
+	Remove(ctx context.Context, userID, id string) error
+	// ConfirmPayee returns the masked name of the owner of a destination account.
+	ConfirmPayee(ctx context.Context, accountID string) (*PayeeConfirmation, error)
+}
+
+type beneficiaryService struct {
+	repo      BeneficiaryRepository
+	directory PayeeDirectory
+}
+
+func NewBeneficiaryService(repo BeneficiaryRepository, directory PayeeDirectory) BeneficiaryService {
+	return &beneficiaryService{repo: repo, directory: directory}
+}
+
+// Add implements BeneficiaryService.
+func (s *beneficiaryService) Add(ctx context.Context, userID, accountID, nickname string) (*Beneficiary, error) {
+	nickname, err := normalizeNickname(nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.payee(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range existing {
+		if b.AccountID == accountID {
+			return nil, ErrDuplicateAccount
+		}
+		if strings.EqualFold(b.Nickname, nickname) {
+			return nil, ErrDuplicateNickname
+		}
+	}
+
+	now := time.Now()
+	b := &Beneficiary{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		AccountID: accountID,
+		Nickname:  nickname,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.Create(ctx, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// List implements BeneficiaryService.
+func (s *beneficiaryService) List(ctx context.Context, userID string) ([]Beneficiary, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// Get implements BeneficiaryService.
+func (s *beneficiaryService) Get(ctx context.Context, userID, id string) (*Beneficiary, error) {
+	b, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil || b.UserID != userID {
+		return nil, ErrBeneficiaryNotFound
+	}
+	return b, nil
+}
+
+// Rename implements BeneficiaryService.
+func (s *beneficiaryService) Rename(ctx context.Context, userID, id, nickname string) (*Beneficiary, error) {
+	nickname, err := normalizeNickname(nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range existing {
+		if other.ID != b.ID && strings.EqualFold(other.Nickname, nickname) {
+			return nil, ErrDuplicateNickname
+		}
+	}
+
+	b.Nickname, b.UpdatedAt = nickname, time.Now()
+	if err := s.repo.UpdateNickname(ctx, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Remove implements BeneficiaryService.
+func (s *beneficiaryService) Remove(ctx context.Context, userID, id string) error {
+	b, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, b.ID)
+}
+
+// ConfirmPayee implements BeneficiaryService.
+func (s *beneficiaryService) ConfirmPayee(ctx context.Context, accountID string) (*PayeeConfirmation, error) {
+	payee, err := s.payee(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return &PayeeConfirmation{
+		AccountID:  payee.AccountID,
+		MaskedName: maskName(payee.OwnerName),
+		Currency:   payee.Currency,
+	}, nil
+}
+
+// payee looks up a destination account and checks that it can receive transfers.
+func (s *beneficiaryService) payee(ctx context.Context, accountID string) (*PayeeAccount, error) {
+	payee, err := s.directory.LookupAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if payee == nil || payee.UserID == "" {
+		return nil, ErrPayeeNotFound
+	}
+	if !payee.Active {
+		return nil, ErrPayeeUnavailable
+	}
+	return payee, nil
+}
+
+func normalizeNickname(nickname string) (string, error) {
+	nickname = strings.TrimSpace(nickname)
+	if nickname == "" {
+		return "", errors.New("nickname is required")
+	}
+	if utf8.RuneCountInString(nickname) > maxNicknameLength {
+		return "", errors.New("nickname is too long")
+	}
+	return nickname, nil
+}
@@ -0,0 +1,150 @@
+package beneficiaries
+
+import (
+	"context"
+	"errors"
+	"go-bank-app/pkg/money"
+	"testing"
+)
+
+const (
+	aliceAccount  = "5b0c7f3e-1d2a-4c6b-9a8e-111111111111"
+	frozenAccount = "5b0c7f3e-1d2a-4c6b-9a8e-222222222222"
+	bankAccount   = "5b0c7f3e-1d2a-4c6b-9a8e-333333333333"
+	missing       = "5b0c7f3e-1d2a-4c6b-9a8e-444444444444"
+)
+
+type mockDirectory map[string]*PayeeAccount
+
+func (m mockDirectory) LookupAccount(ctx context.Context, accountID string) (*PayeeAccount, error) {
+	return m[accountID], nil
+}
+
+func newDirectory() mockDirectory {
+	return mockDirectory{
+		aliceAccount:  {AccountID: aliceAccount, UserID: "alice", OwnerName: "Alicia Ramírez", Currency: money.CurrencyMXN, Active: true},
+		frozenAccount: {AccountID: frozenAccount, UserID: "bob", OwnerName: "Bob Stone", Currency: money.CurrencyMXN},
+		bankAccount:   {AccountID: bankAccount, Currency: money.CurrencyMXN, Active: true},
+	}
+}
+
+type mockRepo struct {
+	saved map[string]*Beneficiary
+}
+
+func newMockRepo() *mockRepo {
+	return &mockRepo{saved: map[string]*Beneficiary{}}
+}
+
+func (m *mockRepo) Create(ctx context.Context, b *Beneficiary) error {
+	m.saved[b.ID] = b
+	return nil
+}
+
+func (m *mockRepo) GetByID(ctx context.Context, id string) (*Beneficiary, error) {
+	return m.saved[id], nil
+}
+
+func (m *mockRepo) ListByUser(ctx context.Context, userID string) ([]Beneficiary, error) {
+	var list []Beneficiary
+	for _, b := range m.saved {
+		if b.UserID == userID {
+			list = append(list, *b)
+		}
+	}
+	return list, nil
+}
+
+func (m *mockRepo) UpdateNickname(ctx context.Context, b *Beneficiary) error {
+	m.saved[b.ID] = b
+	return nil
+}
+
+func (m *mockRepo) Delete(ctx context.Context, id string) error {
+	delete(m.saved, id)
+	return nil
+}
+
+func TestMaskName(t *testing.T) {
+	tests := map[string]string{
+		"Alicia Ramírez":  "A***** R******",
+		"  Ñoño   Pérez ": "Ñ*** P****",
+		"X":               "X",
+		"":                "",
+	}
+	for name, want := range tests {
+		if got := maskName(name); got != want {
+			t.Errorf("maskName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestBeneficiaryService_Add(t *testing.T) {
+	tests := []struct {
+		name      string
+		accountID string
+		wantErr   error
+	}{
+		{"active account", aliceAccount, nil},
+		{"typo in the id", "not-a-uuid", ErrPayeeNotFound},
+		{"unknown account", missing, ErrPayeeNotFound},
+		{"bank account", bankAccount, ErrPayeeNotFound},
+		{"frozen account", frozenAccount, ErrPayeeUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewBeneficiaryService(newMockRepo(), newDirectory())
+			b, err := svc.Add(context.Background(), "user1", tt.accountID, " Rent ")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && b.Nickname != "Rent" {
+				t.Errorf("expected a trimmed nickname, got %q", b.Nickname)
+			}
+		})
+	}
+}
+
+func TestBeneficiaryService_Duplicates(t *testing.T) {
+	directory := newDirectory()
+	directory[missing] = &PayeeAccount{AccountID: missing, UserID: "carol", OwnerName: "Carol", Active: true}
+	svc := NewBeneficiaryService(newMockRepo(), directory)
+	ctx := context.Background()
+
+	first, err := svc.Add(ctx, "user1", aliceAccount, "Rent")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Add(ctx, "user1", aliceAccount, "Landlord"); !errors.Is(err, ErrDuplicateAccount) {
+		t.Errorf("expected ErrDuplicateAccount, got %v", err)
+	}
+	if _, err := svc.Add(ctx, "user1", missing, "rent"); !errors.Is(err, ErrDuplicateNickname) {
+		t.Errorf("expected ErrDuplicateNickname, got %v", err)
+	}
+	if _, err := svc.Add(ctx, "user2", aliceAccount, "Rent"); err != nil {
+		t.Errorf("expected other users to save the same payee, got %v", err)
+	}
+
+	if _, err := svc.Rename(ctx, "user2", first.ID, "Mine now"); !errors.Is(err, ErrBeneficiaryNotFound) {
+		t.Errorf("expected other users' beneficiaries to be hidden, got %v", err)
+	}
+	if err := svc.Remove(ctx, "user1", first.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Get(ctx, "user1", first.ID); !errors.Is(err, ErrBeneficiaryNotFound) {
+		t.Errorf("expected the beneficiary to be gone, got %v", err)
+	}
+}
+
+func TestBeneficiaryService_ConfirmPayee(t *testing.T) {
+	svc := NewBeneficiaryService(newMockRepo(), newDirectory())
+
+	confirmation, err := svc.ConfirmPayee(context.Background(), aliceAccount)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if confirmation.MaskedName != "A***** R******" || confirmation.Currency != money.CurrencyMXN {
+		t.Errorf("unexpected confirmation: %+v", confirmation)
+	}
+}
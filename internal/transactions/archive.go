@@ -0,0 +1,270 @@
+package transactions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatementStore keeps archived statement files. *s3.Client implements it.
+type StatementStore interface {
+	UploadFile(ctx context.Context, key string, content []byte, contentType string) error
+	// GeneratePresignedURL returns a link that downloads the file without credentials
+	// until it expires.
+	GeneratePresignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// StatementAccountLister pages through the accounts that get monthly statements.
+type StatementAccountLister interface {
+	// ListStatementAccounts returns up to limit accounts owned by users and opened before
+	// the given time, ordered by ID and starting after afterID.
+	ListStatementAccounts(ctx context.Context, openedBefore time.Time, afterID string, limit int) ([]AccountInfo, error)
+}
+
+// StatementArchive is a monthly statement stored as a PDF.
+type StatementArchive struct {
+	ID        string    `json:"id"`
+	AccountID string    `json:"account_id"`
+	Period    string    `json:"period"` // YYYY-MM
+	Key       string    `json:"-"`      // Location in the StatementStore
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	// URL downloads the PDF until URLExpiresAt. Only set when listing.
+	URL          string     `json:"url,omitempty"`
+	URLExpiresAt *time.Time `json:"url_expires_at,omitempty"`
+}
+
+// StatementArchiveList is the archived statements of an account, newest first.
+type StatementArchiveList struct {
+	AccountID  string             `json:"account_id"`
+	Statements []StatementArchive `json:"statements"`
+}
+
+// StatementArchiveKey returns where the statement of the account for the month is stored:
+// statements/<account id>/<year>/<year>-<month>.pdf.
+func StatementArchiveKey(accountID string, period StatementPeriod) string {
+	return fmt.Sprintf("statements/%s/%s/%s.pdf", accountID, period.Start.Format("2006"), period.Label())
+}
+
+type StatementArchiveService interface {
+	// List returns the archived statements of the user's account, or of the default
+	// account when accountID is empty, each with a fresh download link.
+	List(ctx context.Context, userID, accountID string) (*StatementArchiveList, error)
+}
+
+type statementArchiveService struct {
+	repo       StatementArchiveRepository
+	reader     AccountReader
+	store      StatementStore
+	linkExpiry time.Duration
+}
+
+func NewStatementArchiveService(repo StatementArchiveRepository, reader AccountReader, store StatementStore, linkExpiry time.Duration) StatementArchiveService {
+	return &statementArchiveService{repo: repo, reader: reader, store: store, linkExpiry: linkExpiry}
+}
+
+// List implements StatementArchiveService.
+func (s *statementArchiveService) List(ctx context.Context, userID, accountID string) (*StatementArchiveList, error) {
+	account, err := s.reader.GetAccountForUser(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	archives, err := s.repo.ListByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.linkExpiry)
+	for i := range archives {
+		archives[i].URL, err = s.store.GeneratePresignedURL(ctx, archives[i].Key, s.linkExpiry)
+		if err != nil {
+			return nil, err
+		}
+		archives[i].URLExpiresAt = &expiresAt
+	}
+
+	if archives == nil {
+		archives = []StatementArchive{}
+	}
+	return &StatementArchiveList{AccountID: account.ID, Statements: archives}, nil
+}
+
+// ArchiverConfig tunes how the StatementArchiver polls.
+type ArchiverConfig struct {
+	Interval  time.Duration // How often the archiver looks for statements to archive
+	BatchSize int           // Accounts listed per query
+	Timeout   time.Duration // Limit for generating and uploading one statement
+}
+
+var DefaultArchiverConfig = ArchiverConfig{
+	Interval:  time.Hour,
+	BatchSize: 100,
+	Timeout:   time.Minute,
+}
+
+// StatementArchiver stores the statement of the previous month of every customer account
+// in the StatementStore. Statements are recorded once uploaded, so failed ones are retried
+// on the next run; two instances archiving the same account upload the same key and only
+// one of them records it.
+type StatementArchiver struct {
+	repo       StatementArchiveRepository
+	accounts   StatementAccountLister
+	statements TransactionService
+	store      StatementStore
+	config     ArchiverConfig
+	now        func() time.Time
+
+	// archived is the last period every account was archived for, so the accounts are
+	// not listed again until the next month.
+	archived string
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+func NewStatementArchiver(repo StatementArchiveRepository, accounts StatementAccountLister, statements TransactionService, store StatementStore, config ArchiverConfig) *StatementArchiver {
+	return &StatementArchiver{
+		repo:       repo,
+		accounts:   accounts,
+		statements: statements,
+		store:      store,
+		config:     config,
+		now:        time.Now,
+		stop:       make(chan struct{}),
+	}
+}
+
+// Start archives statements in the background until Shutdown is called.
+func (a *StatementArchiver) Start() {
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		ticker := time.NewTicker(a.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			a.RunOnce(context.Background())
+			select {
+			case <-a.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Shutdown stops polling and waits for the statement in progress, or until ctx expires.
+func (a *StatementArchiver) Shutdown(ctx context.Context) error {
+	a.once.Do(func() { close(a.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunOnce archives the previous month's statements that are missing. It must not be
+// called concurrently.
+func (a *StatementArchiver) RunOnce(ctx context.Context) {
+	period := MonthPeriod(MonthPeriod(a.now()).Start.AddDate(0, -1, 0))
+	label := period.Label()
+	if a.archived == label {
+		return
+	}
+
+	complete := true
+	after := ""
+	for {
+		accounts, err := a.accounts.ListStatementAccounts(ctx, period.End, after, a.config.BatchSize)
+		if err != nil {
+			log.Printf("❌ Failed to list accounts for %s statements: %v", label, err)
+			return
+		}
+
+		for i := range accounts {
+			select {
+			case <-a.stop:
+				// Whatever is left is picked up after a restart.
+				return
+			default:
+			}
+			if err := a.archive(ctx, &accounts[i], period); err != nil {
+				log.Printf("❌ Failed to archive %s statement of account [%s]: %v", label, accounts[i].ID, err)
+				complete = false
+			}
+		}
+
+		if len(accounts) < a.config.BatchSize {
+			break
+		}
+		after = accounts[len(accounts)-1].ID
+	}
+
+	if complete {
+		a.archived = label
+	}
+}
+
+func (a *StatementArchiver) archive(ctx context.Context, account *AccountInfo, period StatementPeriod) error {
+	label := period.Label()
+	exists, err := a.repo.Exists(ctx, account.ID, label)
+	if err != nil || exists {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.config.Timeout)
+	defer cancel()
+
+	statement, err := a.statements.GetStatement(ctx, account.UserID, account.ID, period)
+	if err != nil {
+		return err
+	}
+	// Closed accounts stop getting statements once they no longer move money.
+	if account.Status == "closed" && len(statement.Lines) == 0 {
+		return nil
+	}
+
+	var pdf bytes.Buffer
+	if err := a.statements.GenerateStatementPDF(&pdf, statement); err != nil {
+		return err
+	}
+
+	key := StatementArchiveKey(account.ID, period)
+	if err := a.store.UploadFile(ctx, key, pdf.Bytes(), "application/pdf"); err != nil {
+		return err
+	}
+
+	err = a.repo.Create(ctx, &StatementArchive{
+		ID:        uuid.New().String(),
+		AccountID: account.ID,
+		Period:    label,
+		Key:       key,
+		Size:      int64(pdf.Len()),
+		CreatedAt: a.now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🗄️ Archived %s statement of account [%s] as %s", label, account.ID, key)
+	return nil
+}
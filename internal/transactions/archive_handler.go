@@ -0,0 +1,29 @@
+package transactions
+
+import (
+	"encoding/json"
+	"go-bank-app/pkg/middleware"
+	"net/http"
+)
+
+type StatementArchiveHandler struct {
+	service StatementArchiveService
+}
+
+func NewStatementArchiveHandler(service StatementArchiveService) *StatementArchiveHandler {
+	return &StatementArchiveHandler{service: service}
+}
+
+// List returns the archived monthly statements of the account in the account_id query
+// parameter, or of the caller's default account, with presigned download links.
+func (h *StatementArchiveHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	archives, err := h.service.List(r.Context(), userID, r.URL.Query().Get("account_id"))
+	if err != nil {
+		writeHistoryError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(archives)
+}
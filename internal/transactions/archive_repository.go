@@ -0,0 +1,64 @@
+package transactions
+
+import (
+	"context"
+	"database/sql"
+)
+
+type StatementArchiveRepository interface {
+	// Create records an uploaded statement. Recording a period the account already has
+	// is a no-op.
+	Create(ctx context.Context, archive *StatementArchive) error
+	Exists(ctx context.Context, accountID, period string) (bool, error)
+	// ListByAccount returns the archived statements of the account, newest period first.
+	ListByAccount(ctx context.Context, accountID string) ([]StatementArchive, error)
+}
+
+type statementArchiveRepository struct {
+	db *sql.DB
+}
+
+func NewStatementArchiveRepository(db *sql.DB) StatementArchiveRepository {
+	return &statementArchiveRepository{db: db}
+}
+
+func (r *statementArchiveRepository) Create(ctx context.Context, archive *StatementArchive) error {
+	query := `
+                INSERT INTO statement_archives (id, account_id, period, key, size, created_at)
+                VALUES ($1, $2, $3, $4, $5, $6)
+                ON CONFLICT (account_id, period) DO NOTHING
+        `
+	_, err := r.db.ExecContext(ctx, query, archive.ID, archive.AccountID, archive.Period, archive.Key, archive.Size, archive.CreatedAt)
+	return err
+}
+
+func (r *statementArchiveRepository) Exists(ctx context.Context, accountID, period string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM statement_archives WHERE account_id = $1 AND period = $2)`
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, accountID, period).Scan(&exists)
+	return exists, err
+}
+
+func (r *statementArchiveRepository) ListByAccount(ctx context.Context, accountID string) ([]StatementArchive, error) {
+	query := `
+                SELECT id, account_id, period, key, size, created_at
+                FROM statement_archives
+                WHERE account_id = $1
+                ORDER BY period DESC
+        `
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var archives []StatementArchive
+	for rows.Next() {
+		var a StatementArchive
+		if err := rows.Scan(&a.ID, &a.AccountID, &a.Period, &a.Key, &a.Size, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		archives = append(archives, a)
+	}
+	return archives, rows.Err()
+}
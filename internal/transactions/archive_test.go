@@ -0,0 +1,290 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"go-bank-app/pkg/aws/s3"
+	"go-bank-app/pkg/aws/s3/s3test"
+	"io"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+)
+
+const testBucket = "statements"
+
+func newTestStore(t *testing.T) (*s3.Client, *s3test.Server) {
+	t.Helper()
+	server := s3test.NewServer(t)
+	store, err := s3.New(context.Background(), s3.Config{
+		Bucket:          testBucket,
+		Region:          "us-east-1",
+		Endpoint:        server.URL,
+		UsePathStyle:    true,
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+	})
+	if err != nil {
+		t.Fatalf("s3.New() error = %v", err)
+	}
+	return store, server
+}
+
+type mockArchiveRepo struct {
+	archives map[string]StatementArchive // Keyed by account ID and period
+}
+
+func newMockArchiveRepo(archives ...StatementArchive) *mockArchiveRepo {
+	m := &mockArchiveRepo{archives: make(map[string]StatementArchive)}
+	for _, a := range archives {
+		m.archives[a.AccountID+"/"+a.Period] = a
+	}
+	return m
+}
+
+func (m *mockArchiveRepo) Create(ctx context.Context, archive *StatementArchive) error {
+	if _, ok := m.archives[archive.AccountID+"/"+archive.Period]; !ok {
+		m.archives[archive.AccountID+"/"+archive.Period] = *archive
+	}
+	return nil
+}
+
+func (m *mockArchiveRepo) Exists(ctx context.Context, accountID, period string) (bool, error) {
+	_, ok := m.archives[accountID+"/"+period]
+	return ok, nil
+}
+
+func (m *mockArchiveRepo) ListByAccount(ctx context.Context, accountID string) ([]StatementArchive, error) {
+	var list []StatementArchive
+	for _, a := range m.archives {
+		if a.AccountID == accountID {
+			list = append(list, a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Period > list[j].Period })
+	return list, nil
+}
+
+// accountList pages through its accounts like the accounts repository.
+type accountList struct {
+	accounts []AccountInfo // Sorted by ID
+	calls    int
+}
+
+func (l *accountList) ListStatementAccounts(ctx context.Context, openedBefore time.Time, afterID string, limit int) ([]AccountInfo, error) {
+	l.calls++
+	var page []AccountInfo
+	for _, acc := range l.accounts {
+		if acc.ID > afterID && acc.CreatedAt.Before(openedBefore) && len(page) < limit {
+			page = append(page, acc)
+		}
+	}
+	return page, nil
+}
+
+// stubStatements renders statements whose PDF is the account ID, failing for the
+// accounts in failing.
+type stubStatements struct {
+	TransactionService
+	lines   map[string]int
+	failing map[string]bool
+	got     []string
+}
+
+func (s *stubStatements) GetStatement(ctx context.Context, userID, accountID string, period StatementPeriod) (*Statement, error) {
+	s.got = append(s.got, userID+"/"+accountID+"/"+period.Label())
+	return &Statement{AccountID: accountID, Period: period, Lines: make([]StatementLine, s.lines[accountID])}, nil
+}
+
+func (s *stubStatements) GenerateStatementPDF(w io.Writer, st *Statement) error {
+	if s.failing[st.AccountID] {
+		return errors.New("render failed")
+	}
+	_, err := io.WriteString(w, "%PDF "+st.AccountID)
+	return err
+}
+
+func newTestArchiver(repo StatementArchiveRepository, accounts StatementAccountLister, statements TransactionService, store StatementStore, now time.Time) *StatementArchiver {
+	a := NewStatementArchiver(repo, accounts, statements, store, ArchiverConfig{BatchSize: 2, Timeout: time.Minute})
+	a.now = func() time.Time { return now }
+	return a
+}
+
+func TestStatementArchiveKey(t *testing.T) {
+	period := MonthPeriod(time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC))
+	if got := StatementArchiveKey("acc123", period); got != "statements/acc123/2024/2024-03.pdf" {
+		t.Errorf("StatementArchiveKey() = %q", got)
+	}
+}
+
+func TestStatementArchiver_ArchivesPreviousMonth(t *testing.T) {
+	now := time.Date(2024, time.April, 2, 3, 0, 0, 0, time.UTC)
+	opened := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	accounts := &accountList{accounts: []AccountInfo{
+		{ID: "acc1", UserID: "user1", Status: "active", CreatedAt: opened},
+		{ID: "acc2", UserID: "user2", Status: "active", CreatedAt: opened},
+		{ID: "acc3", UserID: "user3", Status: "frozen", CreatedAt: opened},
+		// Opened after March, so it has no March statement.
+		{ID: "acc4", UserID: "user4", Status: "active", CreatedAt: time.Date(2024, time.April, 1, 8, 0, 0, 0, time.UTC)},
+	}}
+	repo := newMockArchiveRepo()
+	statements := &stubStatements{}
+	store, server := newTestStore(t)
+
+	archiver := newTestArchiver(repo, accounts, statements, store, now)
+	archiver.RunOnce(context.Background())
+
+	want := []string{"user1/acc1/2024-03", "user2/acc2/2024-03", "user3/acc3/2024-03"}
+	if len(statements.got) != len(want) {
+		t.Fatalf("generated %v, want %v", statements.got, want)
+	}
+	for i := range want {
+		if statements.got[i] != want[i] {
+			t.Errorf("statement %d = %s, want %s", i, statements.got[i], want[i])
+		}
+	}
+
+	for _, id := range []string{"acc1", "acc2", "acc3"} {
+		key := "statements/" + id + "/2024/2024-03.pdf"
+		obj, ok := server.Object(testBucket, key)
+		if !ok {
+			t.Errorf("%s was not uploaded", key)
+			continue
+		}
+		if string(obj.Content) != "%PDF "+id || obj.ContentType != "application/pdf" {
+			t.Errorf("%s = %q (%s)", key, obj.Content, obj.ContentType)
+		}
+
+		archive := repo.archives[id+"/2024-03"]
+		if archive.Key != key || archive.Size != int64(len(obj.Content)) || !archive.CreatedAt.Equal(now) {
+			t.Errorf("recorded archive %+v", archive)
+		}
+	}
+
+	// Every account is archived, so the accounts are not listed again this month.
+	calls := accounts.calls
+	archiver.RunOnce(context.Background())
+	if accounts.calls != calls || len(statements.got) != len(want) {
+		t.Errorf("second run listed accounts %d times and generated %v", accounts.calls-calls, statements.got[len(want):])
+	}
+}
+
+func TestStatementArchiver_RetriesFailedStatements(t *testing.T) {
+	now := time.Date(2024, time.April, 2, 3, 0, 0, 0, time.UTC)
+	opened := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	accounts := &accountList{accounts: []AccountInfo{
+		{ID: "acc1", UserID: "user1", Status: "active", CreatedAt: opened},
+		{ID: "acc2", UserID: "user2", Status: "active", CreatedAt: opened},
+	}}
+	repo := newMockArchiveRepo(StatementArchive{AccountID: "acc1", Period: "2024-03"})
+	statements := &stubStatements{failing: map[string]bool{"acc2": true}}
+	store, server := newTestStore(t)
+
+	archiver := newTestArchiver(repo, accounts, statements, store, now)
+	archiver.RunOnce(context.Background())
+
+	if len(statements.got) != 1 || statements.got[0] != "user2/acc2/2024-03" {
+		t.Fatalf("generated %v, want only the statement not archived yet", statements.got)
+	}
+	if _, ok := repo.archives["acc2/2024-03"]; ok {
+		t.Fatal("a statement that failed to render was recorded")
+	}
+	if _, ok := server.Object(testBucket, "statements/acc2/2024/2024-03.pdf"); ok {
+		t.Fatal("a statement that failed to render was uploaded")
+	}
+
+	statements.failing = nil
+	archiver.RunOnce(context.Background())
+
+	if _, ok := repo.archives["acc2/2024-03"]; !ok {
+		t.Fatal("the failed statement was not archived on the next run")
+	}
+}
+
+func TestStatementArchiver_SkipsIdleClosedAccounts(t *testing.T) {
+	now := time.Date(2024, time.April, 2, 3, 0, 0, 0, time.UTC)
+	opened := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	accounts := &accountList{accounts: []AccountInfo{
+		{ID: "acc1", UserID: "user1", Status: "closed", CreatedAt: opened},
+		{ID: "acc2", UserID: "user2", Status: "closed", CreatedAt: opened},
+	}}
+	repo := newMockArchiveRepo()
+	statements := &stubStatements{lines: map[string]int{"acc2": 1}}
+	store, _ := newTestStore(t)
+
+	newTestArchiver(repo, accounts, statements, store, now).RunOnce(context.Background())
+
+	if _, ok := repo.archives["acc1/2024-03"]; ok {
+		t.Error("archived a closed account without transactions")
+	}
+	if _, ok := repo.archives["acc2/2024-03"]; !ok {
+		t.Error("did not archive the closing month of a closed account")
+	}
+}
+
+func TestStatementArchiveService_List(t *testing.T) {
+	reader := &userAccounts{
+		accounts: []AccountInfo{{ID: "acc1", UserID: "user1"}, {ID: "acc2", UserID: "user2"}},
+		defaults: map[string]string{"user1": "acc1"},
+	}
+	repo := newMockArchiveRepo(
+		StatementArchive{ID: "a1", AccountID: "acc1", Period: "2024-02", Key: "statements/acc1/2024/2024-02.pdf"},
+		StatementArchive{ID: "a2", AccountID: "acc1", Period: "2024-03", Key: "statements/acc1/2024/2024-03.pdf"},
+		StatementArchive{ID: "a3", AccountID: "acc2", Period: "2024-03", Key: "statements/acc2/2024/2024-03.pdf"},
+	)
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+	if err := store.UploadFile(ctx, "statements/acc1/2024/2024-03.pdf", []byte("%PDF march"), "application/pdf"); err != nil {
+		t.Fatalf("UploadFile() error = %v", err)
+	}
+
+	service := NewStatementArchiveService(repo, reader, store, 10*time.Minute)
+	list, err := service.List(ctx, "user1", "")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	if list.AccountID != "acc1" || len(list.Statements) != 2 {
+		t.Fatalf("List() = %+v, want the two statements of acc1", list)
+	}
+	if list.Statements[0].Period != "2024-03" || list.Statements[1].Period != "2024-02" {
+		t.Errorf("periods = %s, %s, want newest first", list.Statements[0].Period, list.Statements[1].Period)
+	}
+	if exp := list.Statements[0].URLExpiresAt; exp == nil || time.Until(*exp) <= 9*time.Minute {
+		t.Errorf("URLExpiresAt = %v, want about 10 minutes from now", exp)
+	}
+
+	resp, err := http.Get(list.Statements[0].URL)
+	if err != nil {
+		t.Fatalf("GET %s: %v", list.Statements[0].URL, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "%PDF march" {
+		t.Errorf("download = %d %q, want the archived PDF", resp.StatusCode, body)
+	}
+}
+
+func TestStatementArchiveService_List_OtherUsersAccount(t *testing.T) {
+	reader := &userAccounts{accounts: []AccountInfo{{ID: "acc2", UserID: "user2"}}}
+	store, _ := newTestStore(t)
+
+	_, err := NewStatementArchiveService(newMockArchiveRepo(), reader, store, time.Minute).List(context.Background(), "user1", "acc2")
+	if !errors.Is(err, ErrAccountNotFound) {
+		t.Fatalf("expected ErrAccountNotFound, got %v", err)
+	}
+}
+
+func TestStatementArchiveService_List_Empty(t *testing.T) {
+	reader := &userAccounts{accounts: []AccountInfo{{ID: "acc1", UserID: "user1"}}}
+	store, _ := newTestStore(t)
+
+	list, err := NewStatementArchiveService(newMockArchiveRepo(), reader, store, time.Minute).List(context.Background(), "user1", "acc1")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if list.Statements == nil || len(list.Statements) != 0 {
+		t.Errorf("Statements = %#v, want an empty list", list.Statements)
+	}
+}
@@ -1,22 +1,66 @@
 package transactions
 
-import "context"
+import (
+	"context"
+	"errors"
+	"go-bank-app/pkg/database"
+	"go-bank-app/pkg/money"
+	"time"
+)
+
+// ErrTransferQueueFull is returned by publishers that cannot take more balance updates
+// right now. Callers should retry later.
+var ErrTransferQueueFull = errors.New("too many transfers in progress, try again later")
+
+// ErrTransfersUnavailable is returned while the service is shutting down.
+var ErrTransfersUnavailable = errors.New("transfers are temporarily unavailable, try again later")
 
 type AccountTransferPublisher interface {
-	PublishTransfer(cmd UpdateAccountBalanceCommand) error
+	// PublishTransfer queues the balance update. The result is delivered on cmd.ErrChan
+	// unless ctx is cancelled first.
+	PublishTransfer(ctx context.Context, cmd UpdateAccountBalanceCommand) error
 }
 
 type UpdateAccountBalanceCommand struct {
 	FromAccountID string
 	ToAccountID   string
-	Amount        float64
-	ErrChan       chan error
+	// Amount is debited from the source account and CreditAmount credited to the
+	// destination account, each in the currency of its account.
+	Amount       money.Amount
+	CreditAmount money.Amount
+	// Reference is the ID of the transaction that causes the balance update.
+	Reference string
+	// Record is executed by the accounts package inside the database transaction that
+	// moves the balances, so the transaction row commits atomically with them.
+	Record  func(ctx context.Context, exec database.Executor) error
+	ErrChan chan error
 }
 
 type AccountReader interface {
-	GetAccountByUserID(ctx context.Context, userID string) (*AccountInfo, error)
+	// GetAccountForUser returns the user's account with the given ID, or the user's
+	// default account when accountID is empty. It returns nil when the user does not
+	// own such an account.
+	GetAccountForUser(ctx context.Context, userID, accountID string) (*AccountInfo, error)
+	// GetAccountByID returns any account, or nil when it does not exist.
+	GetAccountByID(ctx context.Context, accountID string) (*AccountInfo, error)
+	// GetClearingAccount returns the bank account deposits are paid from and withdrawals
+	// paid into, for the given currency.
+	GetClearingAccount(ctx context.Context, currency money.Currency) (*AccountInfo, error)
+	// GetBeneficiaryAccount returns the account the user saved as the given beneficiary,
+	// or nil when the user has no such beneficiary.
+	GetBeneficiaryAccount(ctx context.Context, userID, beneficiaryID string) (*AccountInfo, error)
+	// GetBalanceAt returns the balance the account had at the given time, from its ledger.
+	GetBalanceAt(ctx context.Context, accountID string, at time.Time) (money.Amount, error)
+	// GetHolderName returns the full name of the user, or an empty string when unknown.
+	GetHolderName(ctx context.Context, userID string) (string, error)
 }
 
 type AccountInfo struct {
-	ID string
+	ID       string
+	UserID   string // Empty for accounts owned by the bank
+	Status   string
+	Currency money.Currency
+	Tier     string // Picks the default transfer limits
+	// CreatedAt is when the account was opened, used by fraud screening.
+	CreatedAt time.Time
 }
@@ -1,10 +1,197 @@
 package transactions
 
-import "time"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-bank-app/pkg/money"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Direction tells incoming transactions apart from outgoing ones, from the point of view
+// of the account being queried.
+type Direction string
+
+const (
+	DirectionIn  Direction = "in"
+	DirectionOut Direction = "out"
+)
+
+// SortOrder orders a transaction listing. Ties are broken by ID so pages never overlap.
+type SortOrder string
+
+const (
+	SortNewest         SortOrder = "-date" // Default
+	SortOldest         SortOrder = "date"
+	SortLargestAmount  SortOrder = "-amount"
+	SortSmallestAmount SortOrder = "amount"
+)
+
+func (s SortOrder) valid() bool {
+	switch s {
+	case SortNewest, SortOldest, SortLargestAmount, SortSmallestAmount:
+		return true
+	}
+	return false
+}
 
 // TransactionFilter defines optional fields for querying transactions.
 type TransactionFilter struct {
 	Category  string
-	StartDate *time.Time
-	EndDate   *time.Time
+	Type      TransactionType
+	Status    TransactionStatus
+	StartDate *time.Time // Inclusive
+	EndDate   *time.Time // Exclusive
+	// MinAmount and MaxAmount bound the amount that moved in or out of the account, in
+	// its currency. Zero means unbounded.
+	MinAmount money.Amount
+	MaxAmount money.Amount
+	Direction Direction
+	// Counterparty is the other account of the transaction.
+	Counterparty string
+	// Search matches the description, case-insensitively.
+	Search string
+	Sort   SortOrder
+	// Limit caps the number of rows. Zero returns every match.
+	Limit int
+	// After continues a listing after the row the cursor points at.
+	After *Cursor
+}
+
+// Cursor points at the last row of a page, by the key the listing is sorted on.
+type Cursor struct {
+	Sort      SortOrder    `json:"s"`
+	CreatedAt time.Time    `json:"t,omitempty"`
+	Amount    money.Amount `json:"a,omitempty"`
+	ID        string       `json:"id"`
+}
+
+// Encode returns the cursor as an opaque URL-safe string.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor returned by Encode.
+func DecodeCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" || !c.Sort.valid() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// ParseTransactionFilter reads a filter from query parameters: category, type, status,
+// from and to (RFC 3339 or YYYY-MM-DD), min_amount, max_amount, direction,
+// counterparty, q, sort, limit and cursor.
+func ParseTransactionFilter(query url.Values) (TransactionFilter, error) {
+	filter := TransactionFilter{
+		Category:     query.Get("category"),
+		Counterparty: query.Get("counterparty"),
+		Search:       query.Get("q"),
+		Sort:         SortNewest,
+		Limit:        DefaultPageSize,
+	}
+
+	if txType := TransactionType(query.Get("type")); txType != "" {
+		if !txType.Valid() {
+			return filter, errors.New("invalid transaction type")
+		}
+		filter.Type = txType
+	}
+
+	if status := TransactionStatus(query.Get("status")); status != "" {
+		if !status.Valid() {
+			return filter, errors.New("invalid transaction status")
+		}
+		filter.Status = status
+	}
+
+	for param, bound := range map[string]**time.Time{"from": &filter.StartDate, "to": &filter.EndDate} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		t, err := parseFilterDate(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s date", param)
+		}
+		*bound = &t
+	}
+	// A bare end date includes the whole day.
+	if to := query.Get("to"); filter.EndDate != nil && len(to) == len(time.DateOnly) {
+		end := filter.EndDate.AddDate(0, 0, 1)
+		filter.EndDate = &end
+	}
+
+	for param, bound := range map[string]*money.Amount{"min_amount": &filter.MinAmount, "max_amount": &filter.MaxAmount} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		amount, err := money.Parse(value)
+		if err != nil || !amount.IsPositive() {
+			return filter, fmt.Errorf("invalid %s", param)
+		}
+		*bound = amount
+	}
+	if !filter.MaxAmount.IsZero() && filter.MinAmount > filter.MaxAmount {
+		return filter, errors.New("min_amount is greater than max_amount")
+	}
+
+	switch direction := Direction(query.Get("direction")); direction {
+	case "", DirectionIn, DirectionOut:
+		filter.Direction = direction
+	default:
+		return filter, errors.New("direction must be in or out")
+	}
+
+	if sort := SortOrder(query.Get("sort")); sort != "" {
+		if !sort.valid() {
+			return filter, errors.New("sort must be one of -date, date, -amount or amount")
+		}
+		filter.Sort = sort
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxPageSize {
+			return filter, fmt.Errorf("limit must be between 1 and %d", MaxPageSize)
+		}
+		filter.Limit = n
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		c, err := DecodeCursor(cursor)
+		if err != nil {
+			return filter, err
+		}
+		if c.Sort != filter.Sort {
+			return filter, fmt.Errorf("%w: it belongs to a listing sorted by %s", ErrInvalidCursor, c.Sort)
+		}
+		filter.After = c
+	}
+
+	return filter, nil
+}
+
+func parseFilterDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, value, time.Local)
 }
@@ -0,0 +1,96 @@
+package transactions
+
+import (
+	"errors"
+	"go-bank-app/pkg/money"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseTransactionFilter(t *testing.T) {
+	query := url.Values{
+		"category":     {"food"},
+		"from":         {"2024-03-01"},
+		"to":           {"2024-03-31"},
+		"min_amount":   {"10.50"},
+		"max_amount":   {"200"},
+		"direction":    {"out"},
+		"counterparty": {"acc456"},
+		"q":            {"rent"},
+		"sort":         {"-amount"},
+		"limit":        {"20"},
+	}
+
+	filter, err := ParseTransactionFilter(query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.Category != "food" || filter.Counterparty != "acc456" || filter.Search != "rent" {
+		t.Errorf("unexpected text fields: %+v", filter)
+	}
+	if filter.MinAmount != money.FromMinor(1050) || filter.MaxAmount != money.FromMinor(20000) {
+		t.Errorf("expected 10.50-200.00, got %s-%s", filter.MinAmount, filter.MaxAmount)
+	}
+	if filter.Direction != DirectionOut || filter.Sort != SortLargestAmount || filter.Limit != 20 {
+		t.Errorf("unexpected direction, sort or limit: %+v", filter)
+	}
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)
+	if filter.StartDate == nil || !filter.StartDate.Equal(start) {
+		t.Errorf("expected start %v, got %v", start, filter.StartDate)
+	}
+	// A bare end date includes the whole day.
+	if filter.EndDate == nil || !filter.EndDate.Equal(end) {
+		t.Errorf("expected end %v, got %v", end, filter.EndDate)
+	}
+}
+
+func TestParseTransactionFilter_Defaults(t *testing.T) {
+	filter, err := ParseTransactionFilter(url.Values{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.Sort != SortNewest || filter.Limit != DefaultPageSize || filter.After != nil {
+		t.Errorf("unexpected defaults: %+v", filter)
+	}
+}
+
+func TestParseTransactionFilter_Invalid(t *testing.T) {
+	tests := map[string]url.Values{
+		"type":         {"type": {"gift"}},
+		"status":       {"status": {"lost"}},
+		"date":         {"from": {"yesterday"}},
+		"amount":       {"min_amount": {"-5"}},
+		"amount range": {"min_amount": {"50"}, "max_amount": {"10"}},
+		"direction":    {"direction": {"sideways"}},
+		"sort":         {"sort": {"category"}},
+		"limit":        {"limit": {"500"}},
+		"cursor":       {"cursor": {"not-a-cursor"}},
+	}
+	for name, query := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseTransactionFilter(query); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseTransactionFilter_Cursor(t *testing.T) {
+	cursor := Cursor{Sort: SortOldest, CreatedAt: time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.UTC), ID: "tx1"}
+
+	filter, err := ParseTransactionFilter(url.Values{"sort": {"date"}, "cursor": {cursor.Encode()}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.After == nil || filter.After.ID != "tx1" || !filter.After.CreatedAt.Equal(cursor.CreatedAt) {
+		t.Errorf("expected cursor %+v, got %+v", cursor, filter.After)
+	}
+
+	// A cursor only makes sense for the ordering it was issued for.
+	_, err = ParseTransactionFilter(url.Values{"sort": {"-date"}, "cursor": {cursor.Encode()}})
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-bank-app/pkg/money"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	ErrFundingDeclined      = errors.New("the funding source declined the operation")
+	ErrFundingLimitExceeded = errors.New("amount exceeds the limit for this operation")
+	ErrFundingUnavailable   = errors.New("deposits and withdrawals are not available")
+)
+
+// FundingRequest asks an external funding source, e.g. a card processor or a bank
+// transfer network, to move money in or out of the bank.
+type FundingRequest struct {
+	// TransactionID identifies the operation, so sources can deduplicate retries.
+	TransactionID string
+	AccountID     string
+	Amount        money.Amount
+	Currency      money.Currency
+	// Source is the external instrument, such as a tokenized card or a bank account.
+	Source string
+}
+
+// FundingSource moves money between the bank and the outside world.
+type FundingSource interface {
+	// Collect pulls the funds of a deposit from the external source.
+	Collect(ctx context.Context, req FundingRequest) (externalReference string, err error)
+	// Payout sends the funds of a withdrawal to the external destination.
+	Payout(ctx context.Context, req FundingRequest) (externalReference string, err error)
+}
+
+// FundingLimits caps single deposits and withdrawals. Both must be set: a zero limit
+// rejects every operation of its kind.
+type FundingLimits struct {
+	MaxDeposit    money.Amount
+	MaxWithdrawal money.Amount
+}
+
+func (l FundingLimits) check(txType TransactionType, amount money.Amount) error {
+	max := l.MaxDeposit
+	if txType == TransactionTypeWithdrawal {
+		max = l.MaxWithdrawal
+	}
+	if amount > max {
+		return fmt.Errorf("%w: %s above %s", ErrFundingLimitExceeded, amount, max)
+	}
+	return nil
+}
+
+// FundingInput is a deposit or withdrawal requested by a user.
+type FundingInput struct {
+	UserID         string
+	AccountID      string // Optional, defaults to the user's default account
+	Amount         money.Amount
+	Currency       money.Currency // Optional, must match the account currency
+	Source         string
+	Description    string
+	IdempotencyKey string // Optional, makes retries of the same request safe
+}
+
+func (in FundingInput) fingerprint(txType TransactionType) string {
+	return requestFingerprint(string(txType), in.UserID, in.AccountID, in.Amount.String(), string(in.Currency), in.Source, in.Description)
+}
+
+// DepositReconciler credits, in the background, the deposits that were collected but
+// whose credit failed and that no retry of the request has credited since. Deposits are
+// only picked up once they have been processing for Grace, so requests still crediting
+// them are left alone; crediting one twice is harmless anyway, as only one credit commits.
+type DepositReconciler struct {
+	service  TransactionService
+	interval time.Duration
+	grace    time.Duration
+	now      func() time.Time
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+func NewDepositReconciler(service TransactionService, interval, grace time.Duration) *DepositReconciler {
+	return &DepositReconciler{
+		service:  service,
+		interval: interval,
+		grace:    grace,
+		now:      time.Now,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start credits collected deposits in the background until Shutdown is called.
+func (r *DepositReconciler) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			r.RunOnce(context.Background())
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Shutdown stops polling and waits for the credits in progress, or until ctx expires.
+func (r *DepositReconciler) Shutdown(ctx context.Context) error {
+	r.once.Do(func() { close(r.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunOnce credits the deposits collected more than Grace ago that are still processing.
+func (r *DepositReconciler) RunOnce(ctx context.Context) {
+	credited, err := r.service.CreditCollectedDeposits(ctx, r.now().Add(-r.grace))
+	if err != nil {
+		log.Printf("❌ Failed to credit collected deposits: %v", err)
+		return
+	}
+	if credited > 0 {
+		log.Printf("🏦 Credited %d collected deposits", credited)
+	}
+}
@@ -0,0 +1,335 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"go-bank-app/pkg/money"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testFundingLimits = FundingLimits{MaxDeposit: money.MustParse("1000.00"), MaxWithdrawal: money.MustParse("500.00")}
+
+// mockFunding approves every operation of a source not declined. Like a real processor,
+// it applies each transaction ID once.
+type mockFunding struct {
+	mu sync.Mutex
+	// Declined sources fail every operation with ErrFundingDeclined.
+	Declined   map[string]bool
+	Collected  []FundingRequest
+	PaidOut    []FundingRequest
+	references map[string]string
+}
+
+func newMockFunding() *mockFunding {
+	return &mockFunding{Declined: map[string]bool{}, references: map[string]string{}}
+}
+
+func (f *mockFunding) Collect(ctx context.Context, req FundingRequest) (string, error) {
+	return f.apply(req, &f.Collected)
+}
+
+func (f *mockFunding) Payout(ctx context.Context, req FundingRequest) (string, error) {
+	return f.apply(req, &f.PaidOut)
+}
+
+func (f *mockFunding) apply(req FundingRequest, applied *[]FundingRequest) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Declined[req.Source] {
+		return "", ErrFundingDeclined
+	}
+	if ref, ok := f.references[req.TransactionID]; ok {
+		return ref, nil
+	}
+
+	ref := "ref-" + req.TransactionID
+	f.references[req.TransactionID] = ref
+	*applied = append(*applied, req)
+	return ref, nil
+}
+
+func TestTransactionService_Deposit(t *testing.T) {
+	repo := &mockRepo{}
+	publisher := &mockPublisher{}
+	funding := newMockFunding()
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	svc := NewTransactionService(repo, publisher, reader, nil, nil, funding, testFundingLimits, nil, nil, nil)
+
+	tx, err := svc.Deposit(context.Background(), FundingInput{UserID: "user1", Amount: money.MustParse("250.00"), Source: "card:tok_1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tx.Type != TransactionTypeDeposit {
+		t.Errorf("expected a deposit, got %s", tx.Type)
+	}
+	if tx.FromAccountID != "clearing-MXN" || tx.ToAccountID != "acc123" {
+		t.Errorf("expected clearing-MXN -> acc123, got %s -> %s", tx.FromAccountID, tx.ToAccountID)
+	}
+	if tx.ExternalReference == "" {
+		t.Error("expected the funding source reference to be recorded")
+	}
+	if len(funding.Collected) != 1 || funding.Collected[0].TransactionID != tx.ID {
+		t.Errorf("expected the deposit to be collected once, got %v", funding.Collected)
+	}
+	if len(repo.created) != 1 {
+		t.Errorf("expected the deposit to be recorded, got %d transactions", len(repo.created))
+	}
+	// The deposit is processing once collected and completed by the balance update.
+	if got := repo.statuses[tx.ID]; len(got) != 2 || got[0] != TransactionStatusProcessing || got[1] != TransactionStatusCompleted {
+		t.Errorf("expected processing then completed, got %v", got)
+	}
+}
+
+func TestTransactionService_Deposit_RetryCreditsWithoutCollectingAgain(t *testing.T) {
+	repo := &mockRepo{}
+	publisher := &mockPublisher{rejectErr: ErrTransferQueueFull}
+	funding := newMockFunding()
+	idempotency := newMockIdempotency()
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	svc := NewTransactionService(repo, publisher, reader, idempotency, nil, funding, testFundingLimits, nil, nil, nil)
+	in := FundingInput{UserID: "user1", Amount: money.MustParse("250.00"), Source: "card:tok_1", IdempotencyKey: "key1"}
+
+	if _, err := svc.Deposit(context.Background(), in); !errors.Is(err, ErrTransferQueueFull) {
+		t.Fatalf("expected ErrTransferQueueFull, got %v", err)
+	}
+	deposit := repo.created[0]
+	if deposit.Status != TransactionStatusProcessing || deposit.ExternalReference == "" {
+		t.Fatalf("expected the collected deposit to stay processing with its reference, got %s %q", deposit.Status, deposit.ExternalReference)
+	}
+	if rec := idempotency.records["user1key1"]; rec == nil || rec.TransactionID != deposit.ID {
+		t.Fatalf("expected the idempotency key to stay linked to the deposit, got %+v", rec)
+	}
+
+	publisher.rejectErr = nil
+	tx, err := svc.Deposit(context.Background(), in)
+	if err != nil {
+		t.Fatalf("unexpected error on retry: %v", err)
+	}
+	if tx.ID != deposit.ID || tx.Status != TransactionStatusCompleted {
+		t.Errorf("expected the retry to complete deposit %s, got %s %s", deposit.ID, tx.ID, tx.Status)
+	}
+	if len(funding.Collected) != 1 {
+		t.Errorf("expected the deposit to be collected once, got %d", len(funding.Collected))
+	}
+	if len(repo.created) != 1 || len(publisher.published) != 1 {
+		t.Errorf("expected the retry to only credit the deposit, got %d transactions and %d balance updates", len(repo.created), len(publisher.published))
+	}
+}
+
+func TestTransactionService_CreditCollectedDeposits(t *testing.T) {
+	repo := &mockRepo{}
+	publisher := &mockPublisher{rejectErr: ErrTransferQueueFull}
+	funding := newMockFunding()
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	svc := NewTransactionService(repo, publisher, reader, nil, nil, funding, testFundingLimits, nil, nil, nil)
+
+	if _, err := svc.Deposit(context.Background(), FundingInput{UserID: "user1", Amount: money.MustParse("250.00"), Source: "card:tok_1"}); err == nil {
+		t.Fatal("expected the credit to fail")
+	}
+	deposit := repo.created[0]
+
+	publisher.rejectErr = nil
+	if credited, err := svc.CreditCollectedDeposits(context.Background(), deposit.UpdatedAt); err != nil || credited != 0 {
+		t.Fatalf("expected deposits updated since the cutoff to be left alone, got %d %v", credited, err)
+	}
+	credited, err := svc.CreditCollectedDeposits(context.Background(), time.Now().Add(time.Second))
+	if err != nil || credited != 1 {
+		t.Fatalf("expected one deposit to be credited, got %d %v", credited, err)
+	}
+	if got := repo.statuses[deposit.ID]; len(got) != 2 || got[0] != TransactionStatusProcessing || got[1] != TransactionStatusCompleted {
+		t.Errorf("expected processing then completed, got %v", got)
+	}
+	if len(funding.Collected) != 1 {
+		t.Errorf("expected the deposit to be collected once, got %d", len(funding.Collected))
+	}
+}
+
+// payoutRecorder captures the statuses stored for a withdrawal when its payout is requested.
+type payoutRecorder struct {
+	FundingSource
+	repo     *mockRepo
+	statuses []TransactionStatus
+}
+
+func (p *payoutRecorder) Payout(ctx context.Context, req FundingRequest) (string, error) {
+	p.statuses = append([]TransactionStatus(nil), p.repo.statuses[req.TransactionID]...)
+	return p.FundingSource.Payout(ctx, req)
+}
+
+func TestTransactionService_Withdraw(t *testing.T) {
+	repo := &mockRepo{}
+	publisher := &mockPublisher{}
+	funding := newMockFunding()
+	recorder := &payoutRecorder{FundingSource: funding, repo: repo}
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyUSD}}
+	svc := NewTransactionService(repo, publisher, reader, nil, nil, recorder, testFundingLimits, nil, nil, nil)
+
+	tx, err := svc.Withdraw(context.Background(), FundingInput{UserID: "user1", Amount: money.MustParse("40.00"), Source: "bank:clabe_1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tx.Type != TransactionTypeWithdrawal {
+		t.Errorf("expected a withdrawal, got %s", tx.Type)
+	}
+	cmd := publisher.published[0]
+	if cmd.FromAccountID != "acc123" || cmd.ToAccountID != "clearing-USD" {
+		t.Errorf("expected acc123 -> clearing-USD, got %s -> %s", cmd.FromAccountID, cmd.ToAccountID)
+	}
+	if len(funding.PaidOut) != 1 {
+		t.Errorf("expected one payout, got %d", len(funding.PaidOut))
+	}
+	// The payout is requested once the debit has committed, not inside it.
+	if len(recorder.statuses) != 1 || recorder.statuses[0] != TransactionStatusProcessing {
+		t.Errorf("expected the withdrawal to be processing at payout, got %v", recorder.statuses)
+	}
+	if tx.Status != TransactionStatusCompleted || tx.ExternalReference == "" {
+		t.Errorf("expected a completed withdrawal with a reference, got %s %q", tx.Status, tx.ExternalReference)
+	}
+}
+
+func TestTransactionService_Withdraw_DeclinedIsRefunded(t *testing.T) {
+	repo := &mockRepo{}
+	publisher := &mockPublisher{}
+	funding := newMockFunding()
+	funding.Declined["bank:declined"] = true
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyUSD}}
+	svc := NewTransactionService(repo, publisher, reader, nil, nil, funding, testFundingLimits, nil, nil, nil)
+
+	_, err := svc.Withdraw(context.Background(), FundingInput{UserID: "user1", Amount: money.MustParse("40.00"), Source: "bank:declined"})
+	if !errors.Is(err, ErrFundingDeclined) {
+		t.Fatalf("expected ErrFundingDeclined, got %v", err)
+	}
+
+	if len(publisher.published) != 2 {
+		t.Fatalf("expected a debit and a refund, got %d balance updates", len(publisher.published))
+	}
+	refund := publisher.published[1]
+	if refund.FromAccountID != "clearing-USD" || refund.ToAccountID != "acc123" || refund.Amount != money.MustParse("40.00") {
+		t.Errorf("expected 40.00 back from clearing-USD to acc123, got %s %s -> %s", refund.Amount, refund.FromAccountID, refund.ToAccountID)
+	}
+	tx := repo.created[0]
+	if got := repo.statuses[tx.ID]; len(got) != 2 || got[0] != TransactionStatusProcessing || got[1] != TransactionStatusFailed {
+		t.Errorf("expected processing then failed, got %v", got)
+	}
+}
+
+func TestTransactionService_FundingRejections(t *testing.T) {
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+
+	tests := []struct {
+		name        string
+		withdraw    bool
+		input       FundingInput
+		limits      *FundingLimits // Defaults to testFundingLimits
+		expectedErr error
+		published   int
+		recorded    int
+	}{
+		{
+			name:        "deposit above limit",
+			input:       FundingInput{UserID: "user1", Amount: money.MustParse("1000.01"), Source: "card:tok_1"},
+			expectedErr: ErrFundingLimitExceeded,
+		},
+		{
+			name:        "withdrawal above limit",
+			withdraw:    true,
+			input:       FundingInput{UserID: "user1", Amount: money.MustParse("600.00"), Source: "bank:clabe_1"},
+			expectedErr: ErrFundingLimitExceeded,
+		},
+		{
+			name:        "deposit without a configured limit",
+			input:       FundingInput{UserID: "user1", Amount: money.MustParse("10.00"), Source: "card:tok_1"},
+			limits:      &FundingLimits{MaxWithdrawal: money.MustParse("500.00")},
+			expectedErr: ErrFundingLimitExceeded,
+		},
+		{
+			name:        "declined source",
+			input:       FundingInput{UserID: "user1", Amount: money.MustParse("10.00"), Source: "card:declined"},
+			expectedErr: ErrFundingDeclined,
+			recorded:    1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &mockRepo{}
+			publisher := &mockPublisher{}
+			funding := newMockFunding()
+			funding.Declined["card:declined"] = true
+			limits := testFundingLimits
+			if test.limits != nil {
+				limits = *test.limits
+			}
+			svc := NewTransactionService(repo, publisher, reader, nil, nil, funding, limits, nil, nil, nil)
+
+			run := svc.Deposit
+			if test.withdraw {
+				run = svc.Withdraw
+			}
+
+			_, err := run(context.Background(), test.input)
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("expected %v, got %v", test.expectedErr, err)
+			}
+			if len(publisher.published) != test.published {
+				t.Errorf("expected %d balance updates, got %d", test.published, len(publisher.published))
+			}
+			// Rejections found before the funding source is called leave no trace; later
+			// ones are kept as failed transactions.
+			if len(repo.created) != test.recorded {
+				t.Fatalf("expected %d recorded transactions, got %d", test.recorded, len(repo.created))
+			}
+			for _, tx := range repo.created {
+				if tx.Status != TransactionStatusFailed || tx.FailureReason == "" {
+					t.Errorf("expected a failed transaction with a reason, got %s %q", tx.Status, tx.FailureReason)
+				}
+			}
+		})
+	}
+}
+
+func TestTransactionService_Deposit_CurrencyMustMatchAccount(t *testing.T) {
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	svc := NewTransactionService(&mockRepo{}, &mockPublisher{}, reader, nil, nil, newMockFunding(), testFundingLimits, nil, nil, nil)
+
+	_, err := svc.Deposit(context.Background(), FundingInput{UserID: "user1", Amount: money.MustParse("10.00"), Currency: money.CurrencyUSD, Source: "card:tok_1"})
+	if err == nil {
+		t.Fatal("expected a currency mismatch to be rejected")
+	}
+}
+
+func TestTransactionService_Deposit_NoFundingSource(t *testing.T) {
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	publisher := &mockPublisher{}
+	svc := NewTransactionService(&mockRepo{}, publisher, reader, nil, nil, nil, testFundingLimits, nil, nil, nil)
+
+	_, err := svc.Deposit(context.Background(), FundingInput{UserID: "user1", Amount: money.MustParse("10.00"), Source: "card:tok_1"})
+	if !errors.Is(err, ErrFundingUnavailable) {
+		t.Fatalf("expected ErrFundingUnavailable, got %v", err)
+	}
+	if len(publisher.published) != 0 {
+		t.Errorf("expected no balance update, got %d", len(publisher.published))
+	}
+}
+
+func TestTransactionService_Deposit_AccountLookup(t *testing.T) {
+	readErr := errors.New("database down")
+
+	for _, reader := range []*mockReader{{}, {err: readErr}} {
+		svc := NewTransactionService(&mockRepo{}, &mockPublisher{}, reader, nil, nil, newMockFunding(), testFundingLimits, nil, nil, nil)
+
+		_, err := svc.Deposit(context.Background(), FundingInput{UserID: "user1", Amount: money.MustParse("10.00"), Source: "card:tok_1"})
+		expected := ErrAccountNotFound
+		if reader.err != nil {
+			expected = readErr
+		}
+		if !errors.Is(err, expected) {
+			t.Errorf("expected %v, got %v", expected, err)
+		}
+	}
+}
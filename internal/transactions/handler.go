@@ -1,9 +1,19 @@
 package transactions
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"go-bank-app/pkg/csvwriter"
 	"go-bank-app/pkg/middleware"
+	"go-bank-app/pkg/money"
+	"io"
+	"log"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 type TransactionHandler struct {
@@ -14,12 +24,24 @@ func NewTransactionHandler(service TransactionService) *TransactionHandler {
 	return &TransactionHandler{service: service}
 }
 
+const maxIdempotencyKeyLength = 255
+
 type transferRequest struct {
-	ToAccountID string  `json:"to_account_id"`
-	Amount      float64 `json:"amount"`
-	Currency    string  `json:"currency"` // Optional, you can validate if needed
-	Description string  `json:"description"`
-	Category    string  `json:"category"`
+	FromAccountID string         `json:"from_account_id"` // Optional, defaults to the caller's default account
+	ToAccountID   string         `json:"to_account_id"`
+	BeneficiaryID string         `json:"beneficiary_id"` // Optional, a saved payee instead of to_account_id
+	Amount        money.Amount   `json:"amount"`
+	Currency      money.Currency `json:"currency"` // Optional, the source or destination account currency
+	Description   string         `json:"description"`
+	Category      string         `json:"category"`
+}
+
+type fundingRequest struct {
+	AccountID   string         `json:"account_id"` // Optional, defaults to the caller's default account
+	Amount      money.Amount   `json:"amount"`
+	Currency    money.Currency `json:"currency"` // Optional, must match the account currency
+	Source      string         `json:"source"`   // External instrument, e.g. a tokenized card or bank account
+	Description string         `json:"description"`
 }
 
 func (h *TransactionHandler) Transfer(w http.ResponseWriter, r *http.Request) {
@@ -27,18 +49,93 @@ func (h *TransactionHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 
 	var req transferRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if errors.Is(err, money.ErrSubCent) {
+			http.Error(w, "Amount cannot have fractions of a cent", http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if req.Amount <= 0 || req.ToAccountID == "" {
+	if !req.Amount.IsPositive() || (req.ToAccountID == "" && req.BeneficiaryID == "") {
 		http.Error(w, "Invalid transfer data", http.StatusBadRequest)
 		return
 	}
 
-	tx, err := h.service.Transfer(r.Context(), userID, req.ToAccountID, req.Amount, req.Currency, req.Description, req.Category)
+	idempotencyKey, ok := readIdempotencyKey(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := h.service.Transfer(r.Context(), TransferInput{
+		UserID:         userID,
+		FromAccountID:  req.FromAccountID,
+		ToAccountID:    req.ToAccountID,
+		BeneficiaryID:  req.BeneficiaryID,
+		Amount:         req.Amount,
+		Currency:       req.Currency,
+		Description:    req.Description,
+		Category:       req.Category,
+		IdempotencyKey: idempotencyKey,
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeMovementError(w, err)
+		return
+	}
+
+	// Held transfers are accepted but wait for a fraud review before moving money.
+	if tx.Status == TransactionStatusHeld {
+		w.WriteHeader(http.StatusAccepted)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(tx)
+}
+
+// Deposit credits the caller's account with money collected from an external source.
+func (h *TransactionHandler) Deposit(w http.ResponseWriter, r *http.Request) {
+	h.fund(w, r, h.service.Deposit)
+}
+
+// Withdraw pays money out of the caller's account to an external destination.
+func (h *TransactionHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
+	h.fund(w, r, h.service.Withdraw)
+}
+
+func (h *TransactionHandler) fund(w http.ResponseWriter, r *http.Request, run func(ctx context.Context, in FundingInput) (*Transaction, error)) {
+	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	var req fundingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if errors.Is(err, money.ErrSubCent) {
+			http.Error(w, "Amount cannot have fractions of a cent", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !req.Amount.IsPositive() || req.Source == "" {
+		http.Error(w, "Invalid funding data", http.StatusBadRequest)
+		return
+	}
+
+	idempotencyKey, ok := readIdempotencyKey(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := run(r.Context(), FundingInput{
+		UserID:         userID,
+		AccountID:      req.AccountID,
+		Amount:         req.Amount,
+		Currency:       req.Currency,
+		Source:         req.Source,
+		Description:    req.Description,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		writeMovementError(w, err)
 		return
 	}
 
@@ -46,37 +143,326 @@ func (h *TransactionHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(tx)
 }
 
+type reverseRequest struct {
+	Amount money.Amount `json:"amount"` // Optional, defaults to everything left to reverse
+	Reason string       `json:"reason"`
+}
+
+// Reverse refunds the transfer in the path to its sender, fully or in part. Admin only.
+func (h *TransactionHandler) Reverse(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	var req reverseRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			if errors.Is(err, money.ErrSubCent) {
+				http.Error(w, "Amount cannot have fractions of a cent", http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	idempotencyKey, ok := readIdempotencyKey(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := h.service.Reverse(r.Context(), ReversalInput{
+		TransactionID:  r.PathValue("id"),
+		Amount:         req.Amount,
+		Reason:         req.Reason,
+		RequestedBy:    adminID,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTransactionNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrNotReversible), errors.Is(err, ErrAlreadyReversed), errors.Is(err, ErrRefundExceedsRemaining):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			writeMovementError(w, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tx)
+}
+
+// ListReviews returns the fraud review queue, pending reviews unless ?status= says
+// otherwise. Admin only.
+func (h *TransactionHandler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	status := ReviewStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "":
+		status = ReviewStatusPending
+	case ReviewStatusPending, ReviewStatusApproved, ReviewStatusRejected:
+	default:
+		http.Error(w, "Invalid review status", http.StatusBadRequest)
+		return
+	}
+
+	reviews, err := h.service.ListReviews(r.Context(), status)
+	if err != nil {
+		http.Error(w, "Error retrieving reviews", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(reviews)
+}
+
+type reviewRequest struct {
+	Note string `json:"note"`
+}
+
+// ApproveReview releases the held transfer in the path. Admin only.
+func (h *TransactionHandler) ApproveReview(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.ApproveHeld)
+}
+
+// RejectReview fails the held transfer in the path. Admin only.
+func (h *TransactionHandler) RejectReview(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.RejectHeld)
+}
+
+func (h *TransactionHandler) review(w http.ResponseWriter, r *http.Request, resolve func(ctx context.Context, d ReviewDecision) (*Transaction, error)) {
+	adminID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	var req reviewRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx, err := resolve(r.Context(), ReviewDecision{
+		TransactionID: r.PathValue("id"),
+		ReviewerID:    adminID,
+		Note:          req.Note,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTransactionNotFound), errors.Is(err, ErrReviewNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrReviewResolved):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			writeMovementError(w, err)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(tx)
+}
+
+// readIdempotencyKey returns the optional Idempotency-Key header. It writes the error
+// response and returns false when the key is invalid.
+func readIdempotencyKey(w http.ResponseWriter, r *http.Request) (string, bool) {
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+		return "", false
+	}
+	return idempotencyKey, true
+}
+
+type limitErrorResponse struct {
+	Error     string         `json:"error"`
+	Limit     LimitKind      `json:"limit"`
+	Max       money.Amount   `json:"max"`
+	Remaining money.Amount   `json:"remaining"`
+	Currency  money.Currency `json:"currency"`
+}
+
+// writeMovementError maps the error of a transfer, deposit or withdrawal to a response.
+func writeMovementError(w http.ResponseWriter, err error) {
+	var limitErr *LimitError
+	switch {
+	case errors.As(err, &limitErr):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(limitErrorResponse{
+			Error:     limitErr.Error(),
+			Limit:     limitErr.Limit,
+			Max:       limitErr.Max,
+			Remaining: limitErr.Remaining,
+			Currency:  limitErr.Currency,
+		})
+	case errors.Is(err, ErrIdempotencyKeyReused), errors.Is(err, ErrIdempotencyKeyInProgress):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrTransferQueueFull), errors.Is(err, ErrTransfersUnavailable):
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, ErrFundingUnavailable), errors.Is(err, ErrTransferLimitsNotFound):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, ErrAccountNotFound), errors.Is(err, ErrDestinationNotFound), errors.Is(err, ErrBeneficiaryNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrFundingLimitExceeded), errors.Is(err, ErrFundingDeclined), errors.Is(err, ErrTransferBlocked),
+		errors.Is(err, ErrDestinationUnavailable):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
 func (h *TransactionHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
 
-	filter := TransactionFilter{}
-	category := r.URL.Query().Get("category")
-	if category != "" {
-		filter.Category = category
+	filter, err := ParseTransactionFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	transactions, err := h.service.GetByAccount(r.Context(), userID, filter)
+	page, err := h.service.GetHistory(r.Context(), userID, r.URL.Query().Get("account_id"), filter)
 	if err != nil {
-		http.Error(w, "Error retrieving history", http.StatusInternalServerError)
+		writeHistoryError(w, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(transactions)
+	json.NewEncoder(w).Encode(page)
+}
+
+func writeHistoryError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrAccountNotFound) {
+		http.Error(w, "Account not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, "Error retrieving history", http.StatusInternalServerError)
+}
+
+// GetStatement streams the statement in the format query parameter: pdf (default), csv,
+// ofx, qfx or camt053. The period is chosen like for GetStatementPDF.
+func (h *TransactionHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "pdf":
+		h.GetStatementPDF(w, r)
+	case "csv":
+		h.GetStatementCSV(w, r)
+	case "ofx", "qfx", "camt053":
+		statement, ok := h.statement(w, r)
+		if !ok {
+			return
+		}
+		export := statementExports[format]
+		streamStatement(w, export.contentType, statement.Filename(export.extension), func(out io.Writer) error {
+			return export.render(h.service, out, statement)
+		})
+	default:
+		http.Error(w, "format must be pdf, csv, ofx, qfx or camt053", http.StatusBadRequest)
+	}
+}
+
+type statementExport struct {
+	contentType string
+	extension   string
+	render      func(s TransactionService, w io.Writer, statement *Statement) error
+}
+
+var statementExports = map[string]statementExport{
+	"ofx":     {contentType: "application/x-ofx", extension: "ofx", render: TransactionService.GenerateStatementOFX},
+	"qfx":     {contentType: "application/vnd.intu.qfx", extension: "qfx", render: TransactionService.GenerateStatementQFX},
+	"camt053": {contentType: "application/xml", extension: "camt053.xml", render: TransactionService.GenerateStatementCAMT053},
 }
 
+// GetStatementPDF streams the statement of the caller's account for the month given in
+// the month query parameter, the days between from and to, or the current month.
 func (h *TransactionHandler) GetStatementPDF(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
-	transactions, err := h.service.GetByAccount(r.Context(), userID, TransactionFilter{})
+	statement, ok := h.statement(w, r)
+	if !ok {
+		return
+	}
+	streamStatement(w, "application/pdf", statement.Filename("pdf"), func(out io.Writer) error {
+		return h.service.GenerateStatementPDF(out, statement)
+	})
+}
+
+// GetStatementCSV streams the statement like GetStatementPDF. The delimiter query parameter
+// picks comma (default), semicolon or tab, and bom=true prefixes a byte order mark so Excel
+// reads the file as UTF-8.
+func (h *TransactionHandler) GetStatementCSV(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseCSVOptions(r.URL.Query())
 	if err != nil {
-		http.Error(w, "Error retrieving history", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	filePath := "statement.pdf"
-	if err := h.service.GenerateStatementPDF(transactions, filePath); err != nil {
-		http.Error(w, "Failed to generate PDF", http.StatusInternalServerError)
+	statement, ok := h.statement(w, r)
+	if !ok {
 		return
 	}
+	streamStatement(w, "text/csv; charset=utf-8", statement.Filename("csv"), func(out io.Writer) error {
+		return h.service.GenerateStatementCSV(out, statement, opts)
+	})
+}
+
+func parseCSVOptions(query url.Values) (csvwriter.Options, error) {
+	var opts csvwriter.Options
+	switch query.Get("delimiter") {
+	case "", "comma":
+		opts.Delimiter = ','
+	case "semicolon":
+		opts.Delimiter = ';'
+	case "tab":
+		opts.Delimiter = '\t'
+	default:
+		return opts, errors.New("delimiter must be comma, semicolon or tab")
+	}
+
+	if bom := query.Get("bom"); bom != "" {
+		var err error
+		if opts.BOM, err = strconv.ParseBool(bom); err != nil {
+			return opts, errors.New("bom must be true or false")
+		}
+	}
+	return opts, nil
+}
+
+// statement loads the statement a request asks for, or writes the error response.
+func (h *TransactionHandler) statement(w http.ResponseWriter, r *http.Request) (*Statement, bool) {
+	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	period, err := ParseStatementPeriod(r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	statement, err := h.service.GetStatement(r.Context(), userID, r.URL.Query().Get("account_id"), period)
+	if err != nil {
+		writeHistoryError(w, err)
+		return nil, false
+	}
+	return statement, true
+}
+
+// streamStatement renders a statement straight into the response as a download. Errors
+// can only be reported while nothing has been written yet.
+func streamStatement(w http.ResponseWriter, contentType, filename string, render func(io.Writer) error) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+
+	out := &trackingWriter{w: w}
+	if err := render(out); err != nil {
+		log.Printf("❌ Failed to render %s: %v", filename, err)
+		if !out.wrote {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, "Failed to generate statement", http.StatusInternalServerError)
+		}
+	}
+}
+
+// trackingWriter records whether anything reached the response.
+type trackingWriter struct {
+	w     io.Writer
+	wrote bool
+}
 
-	http.ServeFile(w, r, filePath)
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	t.wrote = true
+	return t.w.Write(p)
 }
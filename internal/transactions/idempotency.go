@@ -0,0 +1,119 @@
+package transactions
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-bank-app/pkg/database"
+	"time"
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+// IdempotencyRecord remembers which request a client-supplied key was first used for
+// and, once it succeeded, the transaction it produced.
+type IdempotencyRecord struct {
+	UserID        string
+	Key           string
+	Fingerprint   string
+	TransactionID string
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+}
+
+// requestFingerprint hashes the fields of a request. Each field is prefixed with its
+// length, so free text containing a separator cannot make two requests collide.
+func requestFingerprint(fields ...string) string {
+	h := sha256.New()
+	for _, field := range fields {
+		fmt.Fprintf(h, "%d:%s", len(field), field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type IdempotencyRepository interface {
+	// WithTx returns a repository that runs its queries on exec.
+	WithTx(exec database.Executor) IdempotencyRepository
+	// Reserve claims the key for the given fingerprint. When the key is already taken and
+	// not expired, the existing record is returned and reserved is false.
+	Reserve(ctx context.Context, userID, key, fingerprint string) (record *IdempotencyRecord, reserved bool, err error)
+	// Complete links the key to the transaction produced by the request.
+	Complete(ctx context.Context, userID, key, transactionID string) error
+	// Release frees the key so a failed request can be retried with it.
+	Release(ctx context.Context, userID, key string) error
+}
+
+type idempotencyRepository struct {
+	db  database.Executor
+	ttl time.Duration
+}
+
+// NewIdempotencyRepository returns a Postgres backed store whose keys expire after ttl.
+func NewIdempotencyRepository(db *sql.DB, ttl time.Duration) IdempotencyRepository {
+	return &idempotencyRepository{db: db, ttl: ttl}
+}
+
+func (r *idempotencyRepository) WithTx(exec database.Executor) IdempotencyRepository {
+	return &idempotencyRepository{db: exec, ttl: r.ttl}
+}
+
+func (r *idempotencyRepository) Reserve(ctx context.Context, userID, key, fingerprint string) (*IdempotencyRecord, bool, error) {
+	now := time.Now()
+
+	// Expired keys are taken over as if they never existed.
+	query := `
+                INSERT INTO idempotency_keys (user_id, key, fingerprint, created_at, expires_at)
+                VALUES ($1, $2, $3, $4, $5)
+                ON CONFLICT (user_id, key) DO UPDATE
+                SET fingerprint = EXCLUDED.fingerprint, transaction_id = NULL,
+                    created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+                WHERE idempotency_keys.expires_at < $4
+                RETURNING user_id, key, fingerprint, created_at, expires_at
+        `
+	var rec IdempotencyRecord
+	err := r.db.QueryRowContext(ctx, query, userID, key, fingerprint, now, now.Add(r.ttl)).
+		Scan(&rec.UserID, &rec.Key, &rec.Fingerprint, &rec.CreatedAt, &rec.ExpiresAt)
+	if err == nil {
+		return &rec, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	existing, err := r.get(ctx, userID, key)
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+func (r *idempotencyRepository) get(ctx context.Context, userID, key string) (*IdempotencyRecord, error) {
+	query := `
+                SELECT user_id, key, fingerprint, COALESCE(transaction_id::text, ''), created_at, expires_at
+                FROM idempotency_keys
+                WHERE user_id = $1 AND key = $2
+        `
+	var rec IdempotencyRecord
+	err := r.db.QueryRowContext(ctx, query, userID, key).
+		Scan(&rec.UserID, &rec.Key, &rec.Fingerprint, &rec.TransactionID, &rec.CreatedAt, &rec.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, userID, key, transactionID string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE idempotency_keys SET transaction_id = $3 WHERE user_id = $1 AND key = $2`, userID, key, transactionID)
+	return err
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, userID, key string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND transaction_id IS NULL`, userID, key)
+	return err
+}
@@ -0,0 +1,226 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-bank-app/pkg/database"
+	"go-bank-app/pkg/money"
+	"os"
+	"time"
+)
+
+var (
+	ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+	// ErrTransferLimitsNotFound is returned for an account whose tier or currency has no
+	// default limits, so its transfers are refused rather than left unlimited.
+	ErrTransferLimitsNotFound = errors.New("no transfer limits for this account")
+)
+
+// LimitKind names one of the limits on transfers out of an account.
+type LimitKind string
+
+const (
+	LimitPerTransaction LimitKind = "per_transaction"
+	LimitDaily          LimitKind = "daily"   // Since midnight, server time
+	LimitMonthly        LimitKind = "monthly" // Since the first of the month, server time
+)
+
+// TransferLimits caps the transfers out of an account, in the account currency. A zero
+// limit means no limit; the defaults of every tier are positive, so a TransferLimitPolicy
+// never returns one.
+type TransferLimits struct {
+	PerTransaction money.Amount `json:"per_transaction"`
+	Daily          money.Amount `json:"daily"`
+	Monthly        money.Amount `json:"monthly"`
+}
+
+// override returns l with the non-zero limits of o taking precedence.
+func (l TransferLimits) override(o TransferLimits) TransferLimits {
+	if !o.PerTransaction.IsZero() {
+		l.PerTransaction = o.PerTransaction
+	}
+	if !o.Daily.IsZero() {
+		l.Daily = o.Daily
+	}
+	if !o.Monthly.IsZero() {
+		l.Monthly = o.Monthly
+	}
+	return l
+}
+
+// LimitError reports which limit a transfer would exceed and how much of it is left.
+type LimitError struct {
+	Limit     LimitKind
+	Max       money.Amount
+	Remaining money.Amount
+	Currency  money.Currency
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s transfer limit of %s %s exceeded: %s %s remaining",
+		e.Limit, e.Max, e.Currency, e.Remaining, e.Currency)
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrTransferLimitExceeded
+}
+
+// LimitPolicy decides which limits apply to transfers out of an account.
+type LimitPolicy interface {
+	LimitsFor(ctx context.Context, account AccountInfo) (TransferLimits, error)
+}
+
+// TierLimits holds the default limits of every account tier, per currency.
+type TierLimits map[string]map[money.Currency]TransferLimits
+
+// LoadTierLimits reads tier defaults from a JSON file shaped like TierLimits, e.g.
+// {"standard": {"MXN": {"per_transaction": "20000.00", "daily": "50000.00", "monthly": "200000.00"}}}.
+// Every one of the given tiers must have positive limits in every supported currency, so
+// a missing or mistyped entry fails loading instead of leaving accounts unlimited.
+func LoadTierLimits(path string, tiers ...string) (TierLimits, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var limits TierLimits
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, fmt.Errorf("invalid transfer limits file %s: %w", path, err)
+	}
+	if err := limits.validate(tiers); err != nil {
+		return nil, fmt.Errorf("invalid transfer limits file %s: %w", path, err)
+	}
+	return limits, nil
+}
+
+func (t TierLimits) validate(tiers []string) error {
+	for _, tier := range tiers {
+		for _, currency := range money.SupportedCurrencies {
+			l, ok := t[tier][currency]
+			if !ok {
+				return fmt.Errorf("tier %q has no %s limits", tier, currency)
+			}
+			if !l.PerTransaction.IsPositive() || !l.Daily.IsPositive() || !l.Monthly.IsPositive() {
+				return fmt.Errorf("tier %q %s limits must all be greater than zero", tier, currency)
+			}
+		}
+	}
+	return nil
+}
+
+// TransferLimitPolicy applies the defaults of the account tier, overridden by whatever
+// limits were set on the account itself.
+type TransferLimitPolicy struct {
+	repo  LimitRepository
+	tiers TierLimits
+}
+
+func NewTransferLimitPolicy(repo LimitRepository, tiers TierLimits) *TransferLimitPolicy {
+	return &TransferLimitPolicy{repo: repo, tiers: tiers}
+}
+
+// LimitsFor implements LimitPolicy.
+func (p *TransferLimitPolicy) LimitsFor(ctx context.Context, account AccountInfo) (TransferLimits, error) {
+	limits, ok := p.tiers[account.Tier][account.Currency]
+	if !ok {
+		return TransferLimits{}, fmt.Errorf("%w: tier %q, currency %s", ErrTransferLimitsNotFound, account.Tier, account.Currency)
+	}
+
+	override, err := p.repo.GetAccountLimits(ctx, account.ID)
+	if err != nil {
+		return TransferLimits{}, err
+	}
+	if override != nil {
+		limits = limits.override(*override)
+	}
+	return limits, nil
+}
+
+// AccountLimits returns the limits set on the account, nil when it only has the tier
+// defaults.
+func (p *TransferLimitPolicy) AccountLimits(ctx context.Context, accountID string) (*TransferLimits, error) {
+	return p.repo.GetAccountLimits(ctx, accountID)
+}
+
+// SetAccountLimits overrides the tier defaults of the account. Zero limits fall back to
+// the defaults.
+func (p *TransferLimitPolicy) SetAccountLimits(ctx context.Context, accountID string, limits TransferLimits, updatedBy string) error {
+	if limits.PerTransaction.IsNegative() || limits.Daily.IsNegative() || limits.Monthly.IsNegative() {
+		return errors.New("limits must not be negative")
+	}
+	return p.repo.SetAccountLimits(ctx, accountID, limits, updatedBy)
+}
+
+// checkTransferLimits rejects a transfer of amount out of account when it would go over
+// one of the account's limits, and otherwise returns the limits. Usage counts the
+// transfers out of the account that have not failed, so transfers submitted at the same
+// time may each pass on their own; limitTransfer checks them again under the lock.
+func (s *transactionService) checkTransferLimits(ctx context.Context, account *AccountInfo, amount money.Amount) (TransferLimits, error) {
+	limits, err := s.limitsFor(ctx, account)
+	if err != nil {
+		return TransferLimits{}, err
+	}
+	return limits, limits.check(ctx, s.repo.SumOutgoingTransfers, account, amount)
+}
+
+// limitsFor returns the limits of the account. Without a policy no transfer goes out.
+func (s *transactionService) limitsFor(ctx context.Context, account *AccountInfo) (TransferLimits, error) {
+	if s.transferLimits == nil {
+		return TransferLimits{}, ErrTransferLimitsNotFound
+	}
+	return s.transferLimits.LimitsFor(ctx, *account)
+}
+
+// limitTransfer wraps the unit of work of a transfer so its limits are checked again
+// inside the balance update. The sender's row is locked by then, so the completed
+// transfers it counts cannot change until this one commits or rolls back.
+func (s *transactionService) limitTransfer(tx *Transaction, account *AccountInfo, limits TransferLimits, record func(ctx context.Context, exec database.Executor) error) func(ctx context.Context, exec database.Executor) error {
+	return func(ctx context.Context, exec database.Executor) error {
+		if err := limits.check(ctx, s.repo.WithTx(exec).SumSettledTransfers, account, tx.Amount); err != nil {
+			return err
+		}
+		return record(ctx, exec)
+	}
+}
+
+// check rejects a transfer of amount out of account when it would go over one of the
+// limits. used sums the transfers out of the account since the start of a window.
+func (l TransferLimits) check(ctx context.Context, used func(ctx context.Context, accountID string, since time.Time) (money.Amount, error), account *AccountInfo, amount money.Amount) error {
+	exceeded := func(kind LimitKind, max, used money.Amount) error {
+		remaining := max.Sub(used)
+		if remaining.IsNegative() {
+			remaining = 0
+		}
+		return &LimitError{Limit: kind, Max: max, Remaining: remaining, Currency: account.Currency}
+	}
+
+	if !l.PerTransaction.IsZero() && amount > l.PerTransaction {
+		return exceeded(LimitPerTransaction, l.PerTransaction, 0)
+	}
+
+	now := time.Now()
+	windows := []struct {
+		kind  LimitKind
+		max   money.Amount
+		since time.Time
+	}{
+		{LimitDaily, l.Daily, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())},
+		{LimitMonthly, l.Monthly, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())},
+	}
+	for _, w := range windows {
+		if w.max.IsZero() {
+			continue
+		}
+		total, err := used(ctx, account.ID, w.since)
+		if err != nil {
+			return err
+		}
+		if total.Add(amount) > w.max {
+			return exceeded(w.kind, w.max, total)
+		}
+	}
+
+	return nil
+}
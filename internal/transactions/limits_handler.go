@@ -0,0 +1,97 @@
+package transactions
+
+import (
+	"encoding/json"
+	"errors"
+	"go-bank-app/pkg/middleware"
+	"go-bank-app/pkg/money"
+	"net/http"
+)
+
+// LimitHandler lets operators inspect and override the transfer limits of an account.
+type LimitHandler struct {
+	policy *TransferLimitPolicy
+	reader AccountReader
+}
+
+func NewLimitHandler(policy *TransferLimitPolicy, reader AccountReader) *LimitHandler {
+	return &LimitHandler{policy: policy, reader: reader}
+}
+
+type accountLimitsResponse struct {
+	AccountID string         `json:"account_id"`
+	Tier      string         `json:"tier"`
+	Currency  money.Currency `json:"currency"`
+	// Override holds the limits set on the account, nil when it uses its tier defaults.
+	Override  *TransferLimits `json:"override"`
+	Effective TransferLimits  `json:"effective"`
+}
+
+// GetLimits returns the limits that apply to the account in the path. Admin only.
+func (h *LimitHandler) GetLimits(w http.ResponseWriter, r *http.Request) {
+	h.writeLimits(w, r, r.PathValue("id"))
+}
+
+// SetLimits overrides the tier defaults of the account in the path. Admin only.
+func (h *LimitHandler) SetLimits(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(middleware.ContextUserIDKey).(string)
+	accountID := r.PathValue("id")
+
+	var limits TransferLimits
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		if errors.Is(err, money.ErrSubCent) {
+			http.Error(w, "Limits cannot have fractions of a cent", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	account, err := h.reader.GetAccountByID(r.Context(), accountID)
+	if err != nil {
+		http.Error(w, "Error retrieving account", http.StatusInternalServerError)
+		return
+	}
+	if account == nil {
+		http.Error(w, "Account not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.policy.SetAccountLimits(r.Context(), accountID, limits, adminID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.writeLimits(w, r, accountID)
+}
+
+func (h *LimitHandler) writeLimits(w http.ResponseWriter, r *http.Request, accountID string) {
+	account, err := h.reader.GetAccountByID(r.Context(), accountID)
+	if err != nil {
+		http.Error(w, "Error retrieving account", http.StatusInternalServerError)
+		return
+	}
+	if account == nil {
+		http.Error(w, "Account not found", http.StatusNotFound)
+		return
+	}
+
+	override, err := h.policy.AccountLimits(r.Context(), accountID)
+	if err != nil {
+		http.Error(w, "Error retrieving limits", http.StatusInternalServerError)
+		return
+	}
+	effective, err := h.policy.LimitsFor(r.Context(), *account)
+	if err != nil {
+		http.Error(w, "Error retrieving limits", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(accountLimitsResponse{
+		AccountID: account.ID,
+		Tier:      account.Tier,
+		Currency:  account.Currency,
+		Override:  override,
+		Effective: effective,
+	})
+}
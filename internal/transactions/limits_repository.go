@@ -0,0 +1,51 @@
+package transactions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type LimitRepository interface {
+	// GetAccountLimits returns the limits set on the account, or nil when there are none.
+	GetAccountLimits(ctx context.Context, accountID string) (*TransferLimits, error)
+	SetAccountLimits(ctx context.Context, accountID string, limits TransferLimits, updatedBy string) error
+}
+
+type limitRepository struct {
+	db *sql.DB
+}
+
+func NewLimitRepository(db *sql.DB) LimitRepository {
+	return &limitRepository{db: db}
+}
+
+func (r *limitRepository) GetAccountLimits(ctx context.Context, accountID string) (*TransferLimits, error) {
+	query := `
+                SELECT per_transaction, daily, monthly
+                FROM account_limits
+                WHERE account_id = $1
+        `
+	var l TransferLimits
+	err := r.db.QueryRowContext(ctx, query, accountID).Scan(&l.PerTransaction, &l.Daily, &l.Monthly)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *limitRepository) SetAccountLimits(ctx context.Context, accountID string, limits TransferLimits, updatedBy string) error {
+	query := `
+                INSERT INTO account_limits (account_id, per_transaction, daily, monthly, updated_by, updated_at)
+                VALUES ($1, $2, $3, $4, $5, $6)
+                ON CONFLICT (account_id) DO UPDATE
+                SET per_transaction = EXCLUDED.per_transaction, daily = EXCLUDED.daily, monthly = EXCLUDED.monthly,
+                        updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+        `
+	_, err := r.db.ExecContext(ctx, query, accountID, limits.PerTransaction, limits.Daily, limits.Monthly, updatedBy, time.Now())
+	return err
+}
@@ -0,0 +1,285 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-bank-app/pkg/money"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fixedLimits TransferLimits
+
+func (f fixedLimits) LimitsFor(ctx context.Context, account AccountInfo) (TransferLimits, error) {
+	return TransferLimits(f), nil
+}
+
+// noLimits lets every transfer through, for tests that are not about limits.
+var noLimits = fixedLimits{}
+
+type mockLimitRepo struct {
+	accounts map[string]TransferLimits
+}
+
+func (m *mockLimitRepo) GetAccountLimits(ctx context.Context, accountID string) (*TransferLimits, error) {
+	if l, ok := m.accounts[accountID]; ok {
+		return &l, nil
+	}
+	return nil, nil
+}
+
+func (m *mockLimitRepo) SetAccountLimits(ctx context.Context, accountID string, limits TransferLimits, updatedBy string) error {
+	m.accounts[accountID] = limits
+	return nil
+}
+
+func TestLoadTierLimits(t *testing.T) {
+	tiers := []string{"standard", "premium", "business"}
+	limits, err := LoadTierLimits("../../configs/transfer_limits.json", tiers...)
+	if err != nil {
+		t.Fatalf("unexpected error loading the shipped limits: %v", err)
+	}
+	if limits["premium"][money.CurrencyGBP].Daily != money.MustParse("10000.00") {
+		t.Errorf("unexpected premium GBP limits: %+v", limits["premium"][money.CurrencyGBP])
+	}
+
+	complete := func() TierLimits {
+		limits := TierLimits{}
+		for _, tier := range tiers {
+			limits[tier] = map[money.Currency]TransferLimits{}
+			for _, currency := range money.SupportedCurrencies {
+				limits[tier][currency] = TransferLimits{PerTransaction: money.MustParse("100.00"), Daily: money.MustParse("500.00"), Monthly: money.MustParse("2000.00")}
+			}
+		}
+		return limits
+	}
+
+	tests := []struct {
+		name string
+		edit func(TierLimits)
+	}{
+		{name: "missing tier", edit: func(l TierLimits) { delete(l, "business") }},
+		{name: "mistyped tier", edit: func(l TierLimits) { l["premuim"] = l["premium"]; delete(l, "premium") }},
+		{name: "missing currency", edit: func(l TierLimits) { delete(l["standard"], money.CurrencyCAD) }},
+		{name: "zero limit", edit: func(l TierLimits) {
+			usd := l["standard"][money.CurrencyUSD]
+			usd.Monthly = 0
+			l["standard"][money.CurrencyUSD] = usd
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits := complete()
+			tt.edit(limits)
+			data, err := json.Marshal(limits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), "limits.json")
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := LoadTierLimits(path, tiers...); err == nil {
+				t.Error("expected the limits file to be rejected")
+			}
+		})
+	}
+}
+
+func TestTransferLimitPolicy_LimitsFor(t *testing.T) {
+	tiers := TierLimits{
+		"standard": {money.CurrencyMXN: {PerTransaction: money.MustParse("1000.00"), Daily: money.MustParse("5000.00")}},
+	}
+	repo := &mockLimitRepo{accounts: map[string]TransferLimits{
+		"acc-override": {Daily: money.MustParse("8000.00")},
+	}}
+	policy := NewTransferLimitPolicy(repo, tiers)
+
+	tests := []struct {
+		name    string
+		account AccountInfo
+		want    TransferLimits
+		wantErr error
+	}{
+		{
+			name:    "tier default",
+			account: AccountInfo{ID: "acc1", Tier: "standard", Currency: money.CurrencyMXN},
+			want:    TransferLimits{PerTransaction: money.MustParse("1000.00"), Daily: money.MustParse("5000.00")},
+		},
+		{
+			name:    "account override",
+			account: AccountInfo{ID: "acc-override", Tier: "standard", Currency: money.CurrencyMXN},
+			want:    TransferLimits{PerTransaction: money.MustParse("1000.00"), Daily: money.MustParse("8000.00")},
+		},
+		{
+			name:    "currency without defaults",
+			account: AccountInfo{ID: "acc2", Tier: "standard", Currency: money.CurrencyUSD},
+			wantErr: ErrTransferLimitsNotFound,
+		},
+		{
+			name:    "unknown tier",
+			account: AccountInfo{ID: "acc3", Tier: "gold", Currency: money.CurrencyMXN},
+			wantErr: ErrTransferLimitsNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.LimitsFor(context.Background(), tt.account)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Transfer_Limits(t *testing.T) {
+	previous := func(amount string, status TransactionStatus) *Transaction {
+		return &Transaction{
+			ID:            "previous",
+			Type:          TransactionTypeTransfer,
+			Status:        status,
+			FromAccountID: "acc123",
+			Amount:        money.MustParse(amount),
+			CreatedAt:     time.Now(),
+		}
+	}
+
+	tests := []struct {
+		name          string
+		limits        TransferLimits
+		history       []*Transaction
+		amount        string
+		wantLimit     LimitKind
+		wantRemaining string
+	}{
+		{
+			name:   "within limits",
+			limits: TransferLimits{PerTransaction: money.MustParse("500.00"), Daily: money.MustParse("1000.00")},
+			amount: "500.00",
+		},
+		{
+			name:          "per transaction",
+			limits:        TransferLimits{PerTransaction: money.MustParse("500.00")},
+			amount:        "500.01",
+			wantLimit:     LimitPerTransaction,
+			wantRemaining: "500.00",
+		},
+		{
+			name:          "daily",
+			limits:        TransferLimits{Daily: money.MustParse("1000.00")},
+			history:       []*Transaction{previous("800.00", TransactionStatusCompleted)},
+			amount:        "300.00",
+			wantLimit:     LimitDaily,
+			wantRemaining: "200.00",
+		},
+		{
+			name:          "monthly",
+			limits:        TransferLimits{Daily: money.MustParse("1000.00"), Monthly: money.MustParse("900.00")},
+			history:       []*Transaction{previous("850.00", TransactionStatusPending)},
+			amount:        "100.00",
+			wantLimit:     LimitMonthly,
+			wantRemaining: "50.00",
+		},
+		{
+			name:    "failed transfers do not count",
+			limits:  TransferLimits{Daily: money.MustParse("1000.00")},
+			history: []*Transaction{previous("800.00", TransactionStatusFailed)},
+			amount:  "300.00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepo{created: tt.history}
+			publisher := &mockPublisher{}
+			reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+			svc := NewTransactionService(repo, publisher, reader, nil, nil, nil, FundingLimits{}, fixedLimits(tt.limits), nil, nil)
+
+			_, err := svc.Transfer(context.Background(), TransferInput{
+				UserID:      "user1",
+				ToAccountID: "acc456",
+				Amount:      money.MustParse(tt.amount),
+			})
+
+			if tt.wantLimit == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || !errors.Is(err, ErrTransferLimitExceeded) {
+				t.Fatalf("expected a limit error, got %v", err)
+			}
+			if limitErr.Limit != tt.wantLimit || limitErr.Remaining != money.MustParse(tt.wantRemaining) {
+				t.Errorf("expected %s limit with %s remaining, got %+v", tt.wantLimit, tt.wantRemaining, limitErr)
+			}
+			if len(publisher.published) != 0 {
+				t.Error("expected the transfer to be rejected before reaching the balance workers")
+			}
+		})
+	}
+}
+
+// racingPublisher lets another transfer complete between the limit check of a transfer
+// and its balance update.
+type racingPublisher struct {
+	*mockPublisher
+	race func()
+}
+
+func (p *racingPublisher) PublishTransfer(ctx context.Context, cmd UpdateAccountBalanceCommand) error {
+	p.race()
+	return p.mockPublisher.PublishTransfer(ctx, cmd)
+}
+
+func TestTransactionService_Transfer_NoLimitPolicy(t *testing.T) {
+	publisher := &mockPublisher{}
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	svc := NewTransactionService(&mockRepo{}, publisher, reader, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+
+	_, err := svc.Transfer(context.Background(), TransferInput{UserID: "user1", ToAccountID: "acc456", Amount: money.MustParse("10.00")})
+	if !errors.Is(err, ErrTransferLimitsNotFound) {
+		t.Fatalf("expected ErrTransferLimitsNotFound, got %v", err)
+	}
+	if len(publisher.published) != 0 {
+		t.Error("expected no balance update without limits")
+	}
+}
+
+func TestTransactionService_Transfer_LimitsCheckedUnderLock(t *testing.T) {
+	repo := &mockRepo{}
+	publisher := &racingPublisher{mockPublisher: &mockPublisher{}, race: func() {
+		repo.created = append(repo.created, &Transaction{
+			ID:            "concurrent",
+			Type:          TransactionTypeTransfer,
+			Status:        TransactionStatusCompleted,
+			FromAccountID: "acc123",
+			Amount:        money.MustParse("800.00"),
+			CreatedAt:     time.Now(),
+		})
+	}}
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	limits := fixedLimits(TransferLimits{Daily: money.MustParse("1000.00")})
+	svc := NewTransactionService(repo, publisher, reader, nil, nil, nil, FundingLimits{}, limits, nil, nil)
+
+	_, err := svc.Transfer(context.Background(), TransferInput{UserID: "user1", ToAccountID: "acc456", Amount: money.MustParse("300.00")})
+
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != LimitDaily || limitErr.Remaining != money.MustParse("200.00") {
+		t.Fatalf("expected the daily limit to be enforced in the balance update, got %v", err)
+	}
+	tx := repo.created[0]
+	if got := repo.statuses[tx.ID]; len(got) != 1 || got[0] != TransactionStatusFailed {
+		t.Errorf("expected the transfer to be recorded as failed, got %v", got)
+	}
+}
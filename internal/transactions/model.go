@@ -1,15 +1,70 @@
 package transactions
 
-import "time"
+import (
+	"go-bank-app/pkg/money"
+	"time"
+)
 
+type TransactionType string
+
+const (
+	TransactionTypeTransfer   TransactionType = "transfer"
+	TransactionTypeDeposit    TransactionType = "deposit"
+	TransactionTypeWithdrawal TransactionType = "withdrawal"
+	TransactionTypeFee        TransactionType = "fee"
+	TransactionTypeReversal   TransactionType = "reversal"
+)
+
+// Valid reports whether t is a known type.
+func (t TransactionType) Valid() bool {
+	switch t {
+	case TransactionTypeTransfer, TransactionTypeDeposit, TransactionTypeWithdrawal, TransactionTypeFee, TransactionTypeReversal:
+		return true
+	}
+	return false
+}
+
+// Transaction is a money movement between two accounts. Amount is what left the source
+// account, in its currency; DestinationAmount is what reached the destination account.
+// They differ only for cross-currency transfers. Deposits come from, and withdrawals go
+// to, the bank's clearing account.
 type Transaction struct {
-	ID            string    `json:"id"`
-	FromAccountID string    `json:"from_account_id"`
-	ToAccountID   string    `json:"to_account_id"`
-	Amount        float64   `json:"amount"`
-	Currency      string    `json:"currency"`
-	Description   string    `json:"description"`
-	Category      string    `json:"category"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            string            `json:"id"`
+	Type          TransactionType   `json:"type"`
+	Status        TransactionStatus `json:"status"`
+	FailureReason string            `json:"failure_reason,omitempty"`
+	// OriginalTransactionID links a reversal to the transaction it refunds.
+	OriginalTransactionID string         `json:"original_transaction_id,omitempty"`
+	FromAccountID         string         `json:"from_account_id"`
+	ToAccountID           string         `json:"to_account_id"`
+	Amount                money.Amount   `json:"amount"`
+	Currency              money.Currency `json:"currency"`
+	DestinationAmount     money.Amount   `json:"destination_amount"`
+	DestinationCurrency   money.Currency `json:"destination_currency"`
+	ExchangeRate          string         `json:"exchange_rate"` // Units of DestinationCurrency per unit of Currency
+	Description           string         `json:"description"`
+	Category              string         `json:"category"`
+	ExternalReference     string         `json:"external_reference,omitempty"` // Set by the funding source on deposits and withdrawals
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+}
+
+// TransferInput is a transfer requested by a user.
+type TransferInput struct {
+	UserID         string
+	FromAccountID  string // Optional, defaults to the user's default account
+	ToAccountID    string
+	BeneficiaryID  string // Optional, a saved payee used instead of ToAccountID
+	Amount         money.Amount
+	Currency       money.Currency // Optional, the source or destination account currency; defaults to the source
+	Description    string
+	Category       string
+	IdempotencyKey string // Optional, makes retries of the same request safe
+}
+
+// fingerprint identifies the request body so a reused idempotency key can be told apart
+// from a genuine retry.
+func (in TransferInput) fingerprint() string {
+	return requestFingerprint(in.UserID, in.FromAccountID, in.ToAccountID, in.BeneficiaryID,
+		in.Amount.String(), string(in.Currency), in.Description, in.Category)
 }
@@ -0,0 +1,62 @@
+package transactions
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// Notification is a message for a user about something the server did on their behalf.
+type Notification struct {
+	UserID  string
+	Subject string
+	Message string
+}
+
+// Notifier delivers notifications to users, e.g. by email or push.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// LogNotifier writes notifications to the log. It does not reach the user, so it is only
+// meant for deployments without an email server.
+type LogNotifier struct{}
+
+// Notify implements Notifier.
+func (LogNotifier) Notify(ctx context.Context, n Notification) error {
+	log.Printf("📣 Notification for user [%s]: %s — %s", n.UserID, n.Subject, n.Message)
+	return nil
+}
+
+// EmailSender sends a plain text email.
+type EmailSender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// UserDirectory finds how to reach a user.
+type UserDirectory interface {
+	// GetUserEmail returns the email address of the user, or "" when there is no such user.
+	GetUserEmail(ctx context.Context, userID string) (string, error)
+}
+
+// emailNotifier emails notifications to the address the user registered with.
+type emailNotifier struct {
+	users  UserDirectory
+	sender EmailSender
+}
+
+func NewEmailNotifier(users UserDirectory, sender EmailSender) Notifier {
+	return &emailNotifier{users: users, sender: sender}
+}
+
+// Notify implements Notifier.
+func (e *emailNotifier) Notify(ctx context.Context, n Notification) error {
+	to, err := e.users.GetUserEmail(ctx, n.UserID)
+	if err != nil {
+		return err
+	}
+	if to == "" {
+		return fmt.Errorf("no email address for user %s", n.UserID)
+	}
+	return e.sender.Send(ctx, to, n.Subject, n.Message)
+}
@@ -0,0 +1,43 @@
+package transactions
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type mockDirectory map[string]string
+
+func (d mockDirectory) GetUserEmail(ctx context.Context, userID string) (string, error) {
+	return d[userID], nil
+}
+
+type recordingSender struct {
+	sent [][3]string
+}
+
+func (s *recordingSender) Send(ctx context.Context, to, subject, body string) error {
+	s.sent = append(s.sent, [3]string{to, subject, body})
+	return nil
+}
+
+func TestEmailNotifier_Notify(t *testing.T) {
+	sender := &recordingSender{}
+	notifier := NewEmailNotifier(mockDirectory{"user1": "ana@example.com"}, sender)
+
+	err := notifier.Notify(context.Background(), Notification{UserID: "user1", Subject: "Scheduled transfer failed", Message: "Rent could not be sent."})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := [][3]string{{"ana@example.com", "Scheduled transfer failed", "Rent could not be sent."}}
+	if !reflect.DeepEqual(sender.sent, expected) {
+		t.Errorf("expected %v, got %v", expected, sender.sent)
+	}
+
+	if err := notifier.Notify(context.Background(), Notification{UserID: "ghost"}); err == nil {
+		t.Error("expected an error for a user without email address")
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("expected nothing sent to an unknown user, got %v", sender.sent)
+	}
+}
@@ -3,51 +3,326 @@ package transactions
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"go-bank-app/pkg/database"
+	"go-bank-app/pkg/money"
 	"log"
+	"strings"
+	"time"
 )
 
 type TransactionRepository interface {
+	// WithTx returns a repository that runs its queries on exec, typically a *sql.Tx
+	// owned by another package.
+	WithTx(exec database.Executor) TransactionRepository
 	Create(ctx context.Context, tx *Transaction) error
+	// UpdateStatus stores the status, failure reason and external reference of tx, as
+	// long as the row is still in status from. Otherwise it returns ErrInvalidStatusTransition.
+	UpdateStatus(ctx context.Context, tx *Transaction, from TransactionStatus) error
+	GetByID(ctx context.Context, id string) (*Transaction, error)
+	// GetByIDForUpdate is GetByID that also locks the row until the surrounding database
+	// transaction ends. It only makes sense on a repository returned by WithTx.
+	GetByIDForUpdate(ctx context.Context, id string) (*Transaction, error)
+	// GetReversedAmounts sums the completed reversals of a transaction: what they refunded
+	// to its sender and what they debited from its recipient.
+	GetReversedAmounts(ctx context.Context, originalID string) (refunded, debited money.Amount, err error)
 	GetByAccount(ctx context.Context, accountID string, filter TransactionFilter) ([]Transaction, error)
+	// GetCollectedDeposits returns the deposits still processing, collected but not yet
+	// credited, that were last updated before the given time, oldest first.
+	GetCollectedDeposits(ctx context.Context, before time.Time) ([]Transaction, error)
+	// GetStatementLines returns the postings made to the account's ledger during the
+	// period, oldest first, each with the transaction whose balance update made it.
+	// Postings no transaction made, such as the sweep of a closed account, come with one
+	// built from their journal entry. Balances are left to the caller.
+	GetStatementLines(ctx context.Context, accountID string, period StatementPeriod) ([]StatementLine, error)
+	// SumOutgoingTransfers sums the transfers out of the account created since the given
+	// time that have not failed, in the account currency.
+	SumOutgoingTransfers(ctx context.Context, accountID string, since time.Time) (money.Amount, error)
+	// SumSettledTransfers is SumOutgoingTransfers counting only completed transfers, the
+	// ones that have already moved money.
+	SumSettledTransfers(ctx context.Context, accountID string, since time.Time) (money.Amount, error)
+	TransferHistory
 }
 
 type transactionRepository struct {
-	db *sql.DB
+	db database.Executor
 }
 
 func NewTransactionRepository(db *sql.DB) TransactionRepository {
 	return &transactionRepository{db: db}
 }
 
+func (r *transactionRepository) WithTx(exec database.Executor) TransactionRepository {
+	return &transactionRepository{db: exec}
+}
+
 func (r *transactionRepository) Create(ctx context.Context, t *Transaction) error {
-	log.Printf("💾 Saving transaction: FROM %s TO %s AMOUNT %.2f CURRENCY %s",
+	log.Printf("💾 Saving transaction: FROM %s TO %s AMOUNT %s CURRENCY %s",
 		t.FromAccountID, t.ToAccountID, t.Amount, t.Currency)
 
 	query := `
-                INSERT INTO transactions (id, from_account_id, to_account_id, amount, currency, description, category, created_at, updated_at)
-                VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+                INSERT INTO transactions (id, type, status, failure_reason, original_transaction_id, from_account_id, to_account_id, amount, currency, destination_amount, destination_currency, exchange_rate, description, category, external_reference, created_at, updated_at)
+                VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
         `
-	_, err := r.db.ExecContext(ctx, query, t.ID, t.FromAccountID, t.ToAccountID, t.Amount, t.Currency, t.Description, t.Category, t.CreatedAt, t.UpdatedAt)
+	_, err := r.db.ExecContext(ctx, query, t.ID, t.Type, t.Status, t.FailureReason, t.OriginalTransactionID, t.FromAccountID, t.ToAccountID, t.Amount, t.Currency, t.DestinationAmount, t.DestinationCurrency, t.ExchangeRate, t.Description, t.Category, t.ExternalReference, t.CreatedAt, t.UpdatedAt)
 	if err != nil {
 		log.Printf("❌ Failed to save transaction: %v", err)
 	}
 	return err
 }
 
+const transactionColumns = `id, type, status, failure_reason, COALESCE(original_transaction_id::text, ''), from_account_id, to_account_id, amount, currency, destination_amount, destination_currency, exchange_rate, description, category, external_reference, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTransaction(row rowScanner) (*Transaction, error) {
+	var t Transaction
+	err := row.Scan(&t.ID, &t.Type, &t.Status, &t.FailureReason, &t.OriginalTransactionID, &t.FromAccountID, &t.ToAccountID, &t.Amount, &t.Currency, &t.DestinationAmount, &t.DestinationCurrency, &t.ExchangeRate, &t.Description, &t.Category, &t.ExternalReference, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *transactionRepository) UpdateStatus(ctx context.Context, t *Transaction, from TransactionStatus) error {
+	query := `
+                UPDATE transactions
+                SET status = $1, failure_reason = $2, external_reference = $3, updated_at = $4
+                WHERE id = $5 AND status = $6
+        `
+	res, err := r.db.ExecContext(ctx, query, t.Status, t.FailureReason, t.ExternalReference, t.UpdatedAt, t.ID, from)
+	if err != nil {
+		log.Printf("❌ Failed to update transaction %s to %s: %v", t.ID, t.Status, err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: transaction %s is no longer %s", ErrInvalidStatusTransition, t.ID, from)
+	}
+	return nil
+}
+
+func (r *transactionRepository) GetByID(ctx context.Context, id string) (*Transaction, error) {
+	query := `
+                SELECT ` + transactionColumns + `
+                FROM transactions
+                WHERE id = $1
+        `
+	return scanTransaction(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *transactionRepository) GetByIDForUpdate(ctx context.Context, id string) (*Transaction, error) {
+	query := `
+                SELECT ` + transactionColumns + `
+                FROM transactions
+                WHERE id = $1
+                FOR UPDATE
+        `
+	return scanTransaction(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *transactionRepository) GetReversedAmounts(ctx context.Context, originalID string) (refunded, debited money.Amount, err error) {
+	query := `
+                SELECT COALESCE(SUM(destination_amount), 0), COALESCE(SUM(amount), 0)
+                FROM transactions
+                WHERE original_transaction_id = $1 AND type = 'reversal' AND status = 'completed'
+        `
+	err = r.db.QueryRowContext(ctx, query, originalID).Scan(&refunded, &debited)
+	return refunded, debited, err
+}
+
+func (r *transactionRepository) GetStatementLines(ctx context.Context, accountID string, period StatementPeriod) ([]StatementLine, error) {
+	query := `
+                SELECT COALESCE(t.id::text, j.reference), COALESCE(t.type, ''), COALESCE(t.status, 'completed'), COALESCE(t.failure_reason, ''),
+                        COALESCE(t.original_transaction_id::text, ''),
+                        COALESCE(t.from_account_id::text, CASE WHEN p.direction = 'debit' THEN p.account_id::text ELSE '' END),
+                        COALESCE(t.to_account_id::text, CASE WHEN p.direction = 'credit' THEN p.account_id::text ELSE '' END),
+                        COALESCE(t.amount, p.amount), COALESCE(t.currency, p.currency), COALESCE(t.destination_amount, p.amount),
+                        COALESCE(t.destination_currency, p.currency), COALESCE(t.exchange_rate, 1), COALESCE(t.description, j.description, ''),
+                        COALESCE(t.category, ''), COALESCE(t.external_reference, ''), COALESCE(t.created_at, p.created_at), COALESCE(t.updated_at, p.created_at),
+                        CASE WHEN p.direction = 'credit' THEN p.amount ELSE -p.amount END, p.created_at
+                FROM postings p
+                JOIN journal_entries j ON j.id = p.journal_entry_id
+                LEFT JOIN transactions t ON t.id::text = j.reference
+                WHERE p.account_id = $1 AND p.created_at >= $2 AND p.created_at < $3
+                ORDER BY p.created_at, p.id
+        `
+	rows, err := r.db.QueryContext(ctx, query, accountID, period.Start, period.End)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []StatementLine
+	for rows.Next() {
+		var line StatementLine
+		t := &line.Transaction
+		err := rows.Scan(&t.ID, &t.Type, &t.Status, &t.FailureReason, &t.OriginalTransactionID, &t.FromAccountID, &t.ToAccountID, &t.Amount, &t.Currency,
+			&t.DestinationAmount, &t.DestinationCurrency, &t.ExchangeRate, &t.Description, &t.Category, &t.ExternalReference, &t.CreatedAt, &t.UpdatedAt,
+			&line.Amount, &line.PostedAt)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+func (r *transactionRepository) SumOutgoingTransfers(ctx context.Context, accountID string, since time.Time) (money.Amount, error) {
+	query := `
+                SELECT COALESCE(SUM(amount), 0)
+                FROM transactions
+                WHERE from_account_id = $1 AND type = 'transfer' AND status IN ('pending', 'completed', 'held') AND created_at >= $2
+        `
+	var total money.Amount
+	err := r.db.QueryRowContext(ctx, query, accountID, since).Scan(&total)
+	return total, err
+}
+
+func (r *transactionRepository) SumSettledTransfers(ctx context.Context, accountID string, since time.Time) (money.Amount, error) {
+	query := `
+                SELECT COALESCE(SUM(amount), 0)
+                FROM transactions
+                WHERE from_account_id = $1 AND type = 'transfer' AND status = 'completed' AND created_at >= $2
+        `
+	var total money.Amount
+	err := r.db.QueryRowContext(ctx, query, accountID, since).Scan(&total)
+	return total, err
+}
+
+func (r *transactionRepository) CountOutgoingTransfers(ctx context.Context, accountID string, since time.Time) (int, error) {
+	query := `
+                SELECT COUNT(*)
+                FROM transactions
+                WHERE from_account_id = $1 AND type = 'transfer' AND status <> 'failed' AND created_at >= $2
+        `
+	var count int
+	err := r.db.QueryRowContext(ctx, query, accountID, since).Scan(&count)
+	return count, err
+}
+
+func (r *transactionRepository) HasTransferredTo(ctx context.Context, fromAccountID, toAccountID string) (bool, error) {
+	query := `
+                SELECT EXISTS (
+                        SELECT 1 FROM transactions
+                        WHERE from_account_id = $1 AND to_account_id = $2 AND type = 'transfer' AND status IN ('completed', 'reversed')
+                )
+        `
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, fromAccountID, toAccountID).Scan(&exists)
+	return exists, err
+}
+
+// Expressions over a transaction as seen from the account in $1: the amount that moved in
+// or out of it, in its currency, and the account on the other side.
+const (
+	accountAmountExpr = "CASE WHEN from_account_id = $1 THEN amount ELSE destination_amount END"
+	counterpartyExpr  = "CASE WHEN from_account_id = $1 THEN to_account_id ELSE from_account_id END"
+)
+
+func (r *transactionRepository) GetCollectedDeposits(ctx context.Context, before time.Time) ([]Transaction, error) {
+	query := `
+                SELECT ` + transactionColumns + `
+                FROM transactions
+                WHERE type = 'deposit' AND status = 'processing' AND updated_at < $1
+                ORDER BY updated_at
+        `
+	rows, err := r.db.QueryContext(ctx, query, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deposits []Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		deposits = append(deposits, *t)
+	}
+	return deposits, rows.Err()
+}
+
 func (r *transactionRepository) GetByAccount(ctx context.Context, accountID string, filter TransactionFilter) ([]Transaction, error) {
 	baseQuery := `
-                SELECT id, from_account_id, to_account_id, amount, currency, description, category, created_at, updated_at
+                SELECT ` + transactionColumns + `
                 FROM transactions
                 WHERE (from_account_id = $1 OR to_account_id = $1)`
 
-	var args []interface{}
-	args = append(args, accountID)
+	args := []interface{}{accountID}
+	where := func(condition string, value interface{}) {
+		args = append(args, value)
+		baseQuery += fmt.Sprintf(" AND %s $%d", condition, len(args))
+	}
+
 	if filter.Category != "" {
-		baseQuery += " AND category = $2"
-		args = append(args, filter.Category)
+		where("category =", filter.Category)
+	}
+	if filter.Type != "" {
+		where("type =", filter.Type)
+	}
+	if filter.Status != "" {
+		where("status =", filter.Status)
+	}
+	if filter.StartDate != nil {
+		where("created_at >=", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		where("created_at <", *filter.EndDate)
+	}
+	if !filter.MinAmount.IsZero() {
+		where(accountAmountExpr+" >=", filter.MinAmount)
+	}
+	if !filter.MaxAmount.IsZero() {
+		where(accountAmountExpr+" <=", filter.MaxAmount)
+	}
+	switch filter.Direction {
+	case DirectionOut:
+		baseQuery += " AND from_account_id = $1"
+	case DirectionIn:
+		baseQuery += " AND to_account_id = $1"
+	}
+	if filter.Counterparty != "" {
+		where(counterpartyExpr+"::text =", filter.Counterparty)
+	}
+	if filter.Search != "" {
+		where("description ILIKE", "%"+likeEscaper.Replace(filter.Search)+"%")
+	}
+
+	sortKey, direction := "created_at", "DESC"
+	switch filter.Sort {
+	case SortOldest:
+		direction = "ASC"
+	case SortLargestAmount:
+		sortKey = accountAmountExpr
+	case SortSmallestAmount:
+		sortKey, direction = accountAmountExpr, "ASC"
 	}
 
-	baseQuery += " ORDER BY created_at DESC"
+	// Keyset pagination: continue strictly after the (key, id) of the cursor row.
+	if c := filter.After; c != nil {
+		var key interface{} = c.CreatedAt
+		if sortKey != "created_at" {
+			key = c.Amount
+		}
+		op := "<"
+		if direction == "ASC" {
+			op = ">"
+		}
+		args = append(args, key, c.ID)
+		baseQuery += fmt.Sprintf(" AND (%s, id) %s ($%d, $%d::uuid)", sortKey, op, len(args)-1, len(args))
+	}
+
+	baseQuery += fmt.Sprintf(" ORDER BY %s %s, id %s", sortKey, direction, direction)
+	if filter.Limit > 0 {
+		baseQuery += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
 
 	rows, err := r.db.QueryContext(ctx, baseQuery, args...)
 	if err != nil {
@@ -58,13 +333,15 @@ func (r *transactionRepository) GetByAccount(ctx context.Context, accountID stri
 	var transactions []Transaction
 
 	for rows.Next() {
-		var t Transaction
-		err := rows.Scan(&t.ID, &t.FromAccountID, &t.ToAccountID, &t.Amount, &t.Currency, &t.Description, &t.Category, &t.CreatedAt, &t.UpdatedAt)
+		t, err := scanTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
-		transactions = append(transactions, t)
+		transactions = append(transactions, *t)
 	}
 
-	return transactions, nil
+	return transactions, rows.Err()
 }
+
+// likeEscaper escapes the LIKE wildcards so searches match them literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
@@ -0,0 +1,187 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-bank-app/pkg/database"
+	"go-bank-app/pkg/fx"
+	"go-bank-app/pkg/money"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTransactionNotFound    = errors.New("transaction not found")
+	ErrNotReversible          = errors.New("only completed transfers can be reversed")
+	ErrAlreadyReversed        = errors.New("transaction has already been fully reversed")
+	ErrRefundExceedsRemaining = errors.New("refund exceeds the amount left to reverse")
+)
+
+// ReversalInput asks to send the money of a completed transfer back to its sender,
+// fully or in part.
+type ReversalInput struct {
+	TransactionID string
+	// Amount is in the currency debited from the original sender. Zero refunds whatever
+	// is left to reverse.
+	Amount money.Amount
+	Reason string
+	// RequestedBy is the ID of the operator asking for the reversal.
+	RequestedBy    string
+	IdempotencyKey string // Optional, makes retries of the same request safe
+}
+
+func (in ReversalInput) fingerprint() string {
+	return requestFingerprint(in.TransactionID, in.Amount.String(), in.Reason, in.RequestedBy)
+}
+
+// Reverse implements TransactionService. The reversal is a new transaction going from
+// the original recipient back to the original sender, applied by the balance worker like
+// any other transfer. The original moves to reversed once nothing is left to refund.
+func (s *transactionService) Reverse(ctx context.Context, in ReversalInput) (*Transaction, error) {
+	return s.idempotent(ctx, in.RequestedBy, in.IdempotencyKey, in.fingerprint(), func() (*Transaction, error) {
+		return s.reverse(ctx, in)
+	})
+}
+
+func (s *transactionService) reverse(ctx context.Context, in ReversalInput) (*Transaction, error) {
+	if in.Amount.IsNegative() {
+		return nil, errors.New("amount must not be negative")
+	}
+
+	original, err := s.repo.GetByID(ctx, in.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil {
+		return nil, ErrTransactionNotFound
+	}
+
+	refunded, debited, err := s.repo.GetReversedAmounts(ctx, original.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	debit, credit, err := reversalAmounts(original, in.Amount, refunded, debited)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tx := &Transaction{
+		ID:                    uuid.New().String(),
+		Type:                  TransactionTypeReversal,
+		Status:                TransactionStatusPending,
+		OriginalTransactionID: original.ID,
+		FromAccountID:         original.ToAccountID,
+		ToAccountID:           original.FromAccountID,
+		Amount:                debit,
+		Currency:              original.DestinationCurrency,
+		DestinationAmount:     credit,
+		DestinationCurrency:   original.Currency,
+		ExchangeRate:          new(big.Rat).Quo(credit.Rat(), debit.Rat()).FloatString(fx.RateDecimals),
+		Description:           in.Reason,
+		Category:              original.Category,
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+
+	complete := s.completeTransaction(tx, in.RequestedBy, in.IdempotencyKey)
+	err = s.execute(ctx, tx, UpdateAccountBalanceCommand{
+		FromAccountID: tx.FromAccountID,
+		ToAccountID:   tx.ToAccountID,
+		Amount:        debit,
+		CreditAmount:  credit,
+		Reference:     tx.ID,
+		Record: func(ctx context.Context, exec database.Executor) error {
+			if err := s.settleOriginal(ctx, exec, tx); err != nil {
+				return err
+			}
+			return complete(ctx, exec)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// settleOriginal runs inside the balance update of a reversal. It locks the original
+// transaction, so concurrent reversals of it are applied one at a time, checks again
+// that the reversal still fits and marks the original reversed when fully refunded.
+func (s *transactionService) settleOriginal(ctx context.Context, exec database.Executor, reversal *Transaction) error {
+	repo := s.repo.WithTx(exec)
+
+	original, err := repo.GetByIDForUpdate(ctx, reversal.OriginalTransactionID)
+	if err != nil {
+		return err
+	}
+	if original == nil {
+		return ErrTransactionNotFound
+	}
+
+	refunded, debited, err := repo.GetReversedAmounts(ctx, original.ID)
+	if err != nil {
+		return err
+	}
+
+	debit, _, err := reversalAmounts(original, reversal.DestinationAmount, refunded, debited)
+	if err != nil {
+		return err
+	}
+	if debit != reversal.Amount {
+		return errors.New("transaction was reversed concurrently, try again")
+	}
+
+	if refunded.Add(reversal.DestinationAmount) == original.Amount {
+		return setStatus(ctx, repo, original, TransactionStatusReversed, "")
+	}
+	return nil
+}
+
+// reversalAmounts returns what a refund of amount takes back from the original recipient
+// (debit, in the destination currency) and returns to the original sender (credit, in
+// the source currency), given what earlier reversals already refunded and debited.
+// Partial refunds of cross-currency transfers use the ratio of the original amounts; the
+// last one takes whatever is left so the totals match the original exactly.
+func reversalAmounts(original *Transaction, amount, refunded, debited money.Amount) (debit, credit money.Amount, err error) {
+	if original.Type != TransactionTypeTransfer {
+		return 0, 0, ErrNotReversible
+	}
+	switch original.Status {
+	case TransactionStatusCompleted:
+	case TransactionStatusReversed:
+		return 0, 0, ErrAlreadyReversed
+	default:
+		return 0, 0, ErrNotReversible
+	}
+
+	remaining := original.Amount.Sub(refunded)
+	if !remaining.IsPositive() {
+		return 0, 0, ErrAlreadyReversed
+	}
+	if amount.IsZero() {
+		amount = remaining
+	}
+	if amount > remaining {
+		return 0, 0, fmt.Errorf("%w: %s left", ErrRefundExceedsRemaining, remaining)
+	}
+
+	if amount == remaining {
+		debit = original.DestinationAmount.Sub(debited)
+	} else {
+		ratio := new(big.Rat).Quo(original.DestinationAmount.Rat(), original.Amount.Rat())
+		debit, err = money.Round(new(big.Rat).Mul(amount.Rat(), ratio), money.RoundHalfEven)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	if !debit.IsPositive() {
+		return 0, 0, errors.New("amount is too small to reverse")
+	}
+
+	return debit, amount, nil
+}
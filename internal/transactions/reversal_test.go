@@ -0,0 +1,121 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"go-bank-app/pkg/money"
+	"testing"
+)
+
+func TestReversalAmounts(t *testing.T) {
+	sameCurrency := &Transaction{Type: TransactionTypeTransfer, Status: TransactionStatusCompleted, Amount: money.MustParse("100.00"), DestinationAmount: money.MustParse("100.00")}
+	crossCurrency := &Transaction{Type: TransactionTypeTransfer, Status: TransactionStatusCompleted, Amount: money.MustParse("10.00"), DestinationAmount: money.MustParse("182.55")}
+
+	tests := []struct {
+		name              string
+		original          *Transaction
+		amount            string
+		refunded, debited string
+		debit, credit     string
+		expectedErr       error
+	}{
+		{name: "full refund", original: sameCurrency, amount: "0", refunded: "0", debited: "0", debit: "100.00", credit: "100.00"},
+		{name: "partial refund", original: sameCurrency, amount: "30.00", refunded: "0", debited: "0", debit: "30.00", credit: "30.00"},
+		{name: "rest after partial refund", original: sameCurrency, amount: "0", refunded: "30.00", debited: "30.00", debit: "70.00", credit: "70.00"},
+		{name: "partial cross-currency refund", original: crossCurrency, amount: "3.33", refunded: "0", debited: "0", debit: "60.79", credit: "3.33"},
+		{name: "last cross-currency refund takes the rest", original: crossCurrency, amount: "6.67", refunded: "3.33", debited: "60.79", debit: "121.76", credit: "6.67"},
+		{name: "more than remaining", original: sameCurrency, amount: "80.00", refunded: "30.00", debited: "30.00", expectedErr: ErrRefundExceedsRemaining},
+		{name: "nothing left", original: sameCurrency, amount: "0", refunded: "100.00", debited: "100.00", expectedErr: ErrAlreadyReversed},
+		{name: "already reversed", original: &Transaction{Type: TransactionTypeTransfer, Status: TransactionStatusReversed}, amount: "0", refunded: "0", debited: "0", expectedErr: ErrAlreadyReversed},
+		{name: "failed transfer", original: &Transaction{Type: TransactionTypeTransfer, Status: TransactionStatusFailed}, amount: "0", refunded: "0", debited: "0", expectedErr: ErrNotReversible},
+		{name: "deposit", original: &Transaction{Type: TransactionTypeDeposit, Status: TransactionStatusCompleted}, amount: "0", refunded: "0", debited: "0", expectedErr: ErrNotReversible},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			debit, credit, err := reversalAmounts(test.original, money.MustParse(test.amount), money.MustParse(test.refunded), money.MustParse(test.debited))
+			if test.expectedErr != nil {
+				if !errors.Is(err, test.expectedErr) {
+					t.Fatalf("expected %v, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if debit != money.MustParse(test.debit) || credit != money.MustParse(test.credit) {
+				t.Errorf("expected %s -> %s, got %s -> %s", test.debit, test.credit, debit, credit)
+			}
+		})
+	}
+}
+
+func TestReversalInput_Fingerprint_FreeText(t *testing.T) {
+	a := ReversalInput{TransactionID: "tx1", Reason: "duplicate|op1", RequestedBy: "op2"}
+	b := ReversalInput{TransactionID: "tx1", Reason: "duplicate", RequestedBy: "op1|op2"}
+
+	if a.fingerprint() == b.fingerprint() {
+		t.Error("requests differing only in how free text splits across fields share a fingerprint")
+	}
+}
+
+func TestTransactionService_Reverse(t *testing.T) {
+	original := &Transaction{
+		ID:                  "tx-original",
+		Type:                TransactionTypeTransfer,
+		Status:              TransactionStatusCompleted,
+		FromAccountID:       "acc-sender",
+		ToAccountID:         "acc-recipient",
+		Amount:              money.MustParse("100.00"),
+		Currency:            money.CurrencyMXN,
+		DestinationAmount:   money.MustParse("100.00"),
+		DestinationCurrency: money.CurrencyMXN,
+	}
+	repo := &mockRepo{created: []*Transaction{original}}
+	publisher := &mockPublisher{}
+	svc := NewTransactionService(repo, publisher, &mockReader{}, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+	ctx := context.Background()
+
+	partial, err := svc.Reverse(ctx, ReversalInput{TransactionID: original.ID, Amount: money.MustParse("40.00"), Reason: "duplicate payment", RequestedBy: "admin1"})
+	if err != nil {
+		t.Fatalf("unexpected error on partial reversal: %v", err)
+	}
+	if partial.Type != TransactionTypeReversal || partial.OriginalTransactionID != original.ID {
+		t.Errorf("expected a reversal linked to %s, got %s linked to %q", original.ID, partial.Type, partial.OriginalTransactionID)
+	}
+	cmd := publisher.published[0]
+	if cmd.FromAccountID != "acc-recipient" || cmd.ToAccountID != "acc-sender" {
+		t.Errorf("expected money to go back from acc-recipient to acc-sender, got %s -> %s", cmd.FromAccountID, cmd.ToAccountID)
+	}
+	if original.Status != TransactionStatusCompleted {
+		t.Errorf("expected a partially refunded transfer to stay completed, got %s", original.Status)
+	}
+
+	rest, err := svc.Reverse(ctx, ReversalInput{TransactionID: original.ID, RequestedBy: "admin1"})
+	if err != nil {
+		t.Fatalf("unexpected error on final reversal: %v", err)
+	}
+	if rest.Amount != money.MustParse("60.00") {
+		t.Errorf("expected the rest of 60.00 to be reversed, got %s", rest.Amount)
+	}
+	if original.Status != TransactionStatusReversed {
+		t.Errorf("expected the original to be reversed, got %s", original.Status)
+	}
+
+	_, err = svc.Reverse(ctx, ReversalInput{TransactionID: original.ID, RequestedBy: "admin1"})
+	if !errors.Is(err, ErrAlreadyReversed) {
+		t.Errorf("expected ErrAlreadyReversed, got %v", err)
+	}
+	if len(publisher.published) != 2 {
+		t.Errorf("expected 2 balance updates, got %d", len(publisher.published))
+	}
+}
+
+func TestTransactionService_Reverse_NotFound(t *testing.T) {
+	svc := NewTransactionService(&mockRepo{}, &mockPublisher{}, &mockReader{}, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+
+	_, err := svc.Reverse(context.Background(), ReversalInput{TransactionID: "missing", RequestedBy: "admin1"})
+	if !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("expected ErrTransactionNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-bank-app/pkg/database"
+	"strings"
+	"time"
+)
+
+var (
+	ErrReviewNotFound = errors.New("no review found for this transaction")
+	ErrReviewResolved = errors.New("review has already been resolved")
+)
+
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "pending"
+	ReviewStatusApproved ReviewStatus = "approved"
+	ReviewStatusRejected ReviewStatus = "rejected"
+)
+
+// FraudReview is the review queue entry of a transfer held by fraud screening.
+type FraudReview struct {
+	TransactionID string       `json:"transaction_id"`
+	Rules         []string     `json:"rules"`
+	Status        ReviewStatus `json:"status"`
+	ReviewedBy    string       `json:"reviewed_by,omitempty"`
+	Note          string       `json:"note,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	ReviewedAt    *time.Time   `json:"reviewed_at,omitempty"`
+	Transaction   *Transaction `json:"transaction,omitempty"`
+}
+
+// ReviewDecision approves or rejects a held transfer.
+type ReviewDecision struct {
+	TransactionID string
+	ReviewerID    string
+	Note          string
+}
+
+// hold records tx as held together with its review queue entry, and links the
+// idempotency key to it so retries of the request return the held transfer. All three
+// are written in one database transaction, so a transfer is never held without a review
+// that can release it.
+func (s *transactionService) hold(ctx context.Context, tx *Transaction, rules []string, userID, idempotencyKey string) error {
+	tx.Status = TransactionStatusHeld
+	review := &FraudReview{
+		TransactionID: tx.ID,
+		Rules:         rules,
+		Status:        ReviewStatusPending,
+		CreatedAt:     time.Now(),
+	}
+	return s.reviews.CreateHeld(ctx, tx, review, func(ctx context.Context, exec database.Executor) error {
+		if idempotencyKey != "" {
+			return s.idempotency.WithTx(exec).Complete(ctx, userID, idempotencyKey, tx.ID)
+		}
+		return nil
+	})
+}
+
+// ListReviews implements TransactionService.
+func (s *transactionService) ListReviews(ctx context.Context, status ReviewStatus) ([]FraudReview, error) {
+	reviews, err := s.reviews.List(ctx, status)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range reviews {
+		tx, err := s.repo.GetByID(ctx, reviews[i].TransactionID)
+		if err != nil {
+			return nil, err
+		}
+		reviews[i].Transaction = tx
+	}
+	return reviews, nil
+}
+
+// ApproveHeld implements TransactionService. The transfer goes through the balance
+// workers like any other, and its review is closed in the same database transaction that
+// completes it. When the balance update fails, e.g. for lack of funds by now, nothing is
+// recorded: the transfer stays held with its review pending.
+func (s *transactionService) ApproveHeld(ctx context.Context, d ReviewDecision) (*Transaction, error) {
+	tx, err := s.heldTransaction(ctx, d.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.reader.GetAccountByID(ctx, tx.FromAccountID)
+	if err == nil && account == nil {
+		err = fmt.Errorf("origin account %s not found", tx.FromAccountID)
+	}
+	var limits TransferLimits
+	if err == nil {
+		limits, err = s.limitsFor(ctx, account)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	approve := func(ctx context.Context, exec database.Executor) error {
+		if err := s.reviews.WithTx(exec).Resolve(ctx, tx.ID, ReviewStatusApproved, d.ReviewerID, d.Note, nil); err != nil {
+			return err
+		}
+		// The idempotency key was linked when the transfer was held.
+		return s.completeTransaction(tx, "", "")(ctx, exec)
+	}
+	err = s.await(ctx, UpdateAccountBalanceCommand{
+		FromAccountID: tx.FromAccountID,
+		ToAccountID:   tx.ToAccountID,
+		Amount:        tx.Amount,
+		CreditAmount:  tx.DestinationAmount,
+		Reference:     tx.ID,
+		Record:        s.limitTransfer(tx, account, limits, approve),
+	}, nil)
+	if err != nil {
+		return nil, resolveError(err)
+	}
+	return tx, nil
+}
+
+// RejectHeld implements TransactionService. The transfer fails in the same database
+// transaction that closes its review.
+func (s *transactionService) RejectHeld(ctx context.Context, d ReviewDecision) (*Transaction, error) {
+	tx, err := s.heldTransaction(ctx, d.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := "rejected by fraud review"
+	if d.Note != "" {
+		reason += ": " + d.Note
+	}
+	err = s.reviews.Resolve(ctx, tx.ID, ReviewStatusRejected, d.ReviewerID, d.Note, func(ctx context.Context, exec database.Executor) error {
+		return setStatus(ctx, s.repo.WithTx(exec), tx, TransactionStatusFailed, reason)
+	})
+	if err != nil {
+		return nil, resolveError(err)
+	}
+	return tx, nil
+}
+
+// heldTransaction returns the transaction awaiting a review decision.
+func (s *transactionService) heldTransaction(ctx context.Context, id string) (*Transaction, error) {
+	tx, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, ErrTransactionNotFound
+	}
+	if tx.Status != TransactionStatusHeld {
+		return nil, fmt.Errorf("%w: transaction is %s", ErrReviewResolved, tx.Status)
+	}
+	return tx, nil
+}
+
+// resolveError reports a decision that lost the race with a concurrent one as
+// ErrReviewResolved. Both the review and the status update only succeed while the
+// transfer is still held, so concurrent decisions cannot both go through.
+func resolveError(err error) error {
+	if errors.Is(err, ErrReviewNotFound) || errors.Is(err, ErrInvalidStatusTransition) {
+		return ErrReviewResolved
+	}
+	return err
+}
+
+func joinRules(rules []string) string {
+	return strings.Join(rules, ",")
+}
+
+func splitRules(rules string) []string {
+	if rules == "" {
+		return nil
+	}
+	return strings.Split(rules, ",")
+}
@@ -0,0 +1,132 @@
+package transactions
+
+import (
+	"context"
+	"database/sql"
+	"go-bank-app/pkg/database"
+	"log"
+	"time"
+)
+
+type ReviewRepository interface {
+	// WithTx returns a repository whose writes run on exec, inside a database transaction
+	// owned by another package, instead of starting their own.
+	WithTx(exec database.Executor) ReviewRepository
+	// CreateHeld stores a held transaction and its review in one database transaction.
+	// record runs in that transaction too, so whatever it writes commits with them.
+	CreateHeld(ctx context.Context, tx *Transaction, review *FraudReview, record func(ctx context.Context, exec database.Executor) error) error
+	// List returns the reviews in the given status, oldest first.
+	List(ctx context.Context, status ReviewStatus) ([]FraudReview, error)
+	// Resolve closes a pending review in one database transaction with record, which
+	// stores the decision on the transaction and may be nil. It returns ErrReviewNotFound
+	// when the transaction has no pending review.
+	Resolve(ctx context.Context, transactionID string, status ReviewStatus, reviewerID, note string, record func(ctx context.Context, exec database.Executor) error) error
+}
+
+type reviewRepository struct {
+	db *sql.DB
+	// tx is the database transaction set by WithTx, if any.
+	tx database.Executor
+}
+
+func NewReviewRepository(db *sql.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+func (r *reviewRepository) WithTx(exec database.Executor) ReviewRepository {
+	return &reviewRepository{db: r.db, tx: exec}
+}
+
+// inTx runs fn in the database transaction set by WithTx, or else in a new one.
+func (r *reviewRepository) inTx(ctx context.Context, fn func(exec database.Executor) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(dbTx); err != nil {
+		dbTx.Rollback()
+		return err
+	}
+	return dbTx.Commit()
+}
+
+func (r *reviewRepository) CreateHeld(ctx context.Context, tx *Transaction, review *FraudReview, record func(ctx context.Context, exec database.Executor) error) error {
+	err := r.inTx(ctx, func(exec database.Executor) error {
+		return r.createHeld(ctx, exec, tx, review, record)
+	})
+	if err != nil {
+		log.Printf("❌ Failed to hold transaction %s for review: %v", tx.ID, err)
+	}
+	return err
+}
+
+func (r *reviewRepository) createHeld(ctx context.Context, exec database.Executor, tx *Transaction, review *FraudReview, record func(ctx context.Context, exec database.Executor) error) error {
+	if err := (&transactionRepository{db: exec}).Create(ctx, tx); err != nil {
+		return err
+	}
+
+	query := `
+                INSERT INTO fraud_reviews (transaction_id, rules, status, created_at)
+                VALUES ($1, $2, $3, $4)
+        `
+	if _, err := exec.ExecContext(ctx, query, review.TransactionID, joinRules(review.Rules), review.Status, review.CreatedAt); err != nil {
+		return err
+	}
+
+	if record != nil {
+		return record(ctx, exec)
+	}
+	return nil
+}
+
+func (r *reviewRepository) List(ctx context.Context, status ReviewStatus) ([]FraudReview, error) {
+	query := `
+                SELECT transaction_id, rules, status, COALESCE(reviewed_by::text, ''), note, created_at, reviewed_at
+                FROM fraud_reviews
+                WHERE status = $1
+                ORDER BY created_at
+        `
+	rows, err := r.db.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []FraudReview
+	for rows.Next() {
+		var review FraudReview
+		var rules string
+		if err := rows.Scan(&review.TransactionID, &rules, &review.Status, &review.ReviewedBy, &review.Note, &review.CreatedAt, &review.ReviewedAt); err != nil {
+			return nil, err
+		}
+		review.Rules = splitRules(rules)
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+func (r *reviewRepository) Resolve(ctx context.Context, transactionID string, status ReviewStatus, reviewerID, note string, record func(ctx context.Context, exec database.Executor) error) error {
+	return r.inTx(ctx, func(exec database.Executor) error {
+		query := `
+                UPDATE fraud_reviews
+                SET status = $1, reviewed_by = $2, note = $3, reviewed_at = $4
+                WHERE transaction_id = $5 AND status = 'pending'
+        `
+		res, err := exec.ExecContext(ctx, query, status, reviewerID, note, time.Now(), transactionID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrReviewNotFound
+		}
+
+		if record != nil {
+			return record(ctx, exec)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,255 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-bank-app/pkg/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Recurrence is how often a scheduled transfer runs.
+type Recurrence string
+
+const (
+	RecurrenceOnce    Recurrence = "once"
+	RecurrenceWeekly  Recurrence = "weekly"
+	RecurrenceMonthly Recurrence = "monthly" // On DayOfMonth, or the month's last day if shorter
+)
+
+type ScheduleStatus string
+
+const (
+	ScheduleStatusActive    ScheduleStatus = "active"
+	ScheduleStatusCompleted ScheduleStatus = "completed" // A one-off transfer that ran
+	ScheduleStatusFailed    ScheduleStatus = "failed"    // A one-off transfer that ran out of attempts
+	ScheduleStatusCancelled ScheduleStatus = "cancelled"
+)
+
+var (
+	ErrScheduleNotFound    = errors.New("scheduled transfer not found")
+	ErrScheduleNotEditable = errors.New("only active scheduled transfers can be changed")
+	ErrScheduleChanged     = errors.New("scheduled transfer is running or was changed meanwhile, try again")
+)
+
+// ScheduledTransfer is a transfer the server executes on behalf of a user at NextRunAt,
+// once or on a recurrence. ScheduledAt is when the current occurrence is due; retries
+// push NextRunAt back but leave ScheduledAt alone, so the recurrence never drifts.
+type ScheduledTransfer struct {
+	ID            string         `json:"id"`
+	UserID        string         `json:"user_id"`
+	FromAccountID string         `json:"from_account_id"`
+	ToAccountID   string         `json:"to_account_id"`
+	Amount        money.Amount   `json:"amount"`
+	Currency      money.Currency `json:"currency"`
+	Description   string         `json:"description"`
+	Category      string         `json:"category"`
+	Recurrence    Recurrence     `json:"recurrence"`
+	DayOfMonth    int            `json:"day_of_month,omitempty"`
+	ScheduledAt   time.Time      `json:"scheduled_at"`
+	NextRunAt     time.Time      `json:"next_run_at"`
+	Status        ScheduleStatus `json:"status"`
+	// RunCount numbers the occurrences; together with the ID it forms the idempotency key
+	// of each run, so a run retried after a crash cannot move money twice.
+	RunCount          int       `json:"run_count"`
+	Attempts          int       `json:"attempts"`
+	LastError         string    `json:"last_error,omitempty"`
+	LastTransactionID string    `json:"last_transaction_id,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	// Version counts the writes to the schedule. Every write requires the version it was
+	// read at, so it cannot overwrite a write it has not seen.
+	Version int `json:"-"`
+}
+
+// idempotencyKey identifies the current occurrence.
+func (s *ScheduledTransfer) idempotencyKey() string {
+	return fmt.Sprintf("schedule:%s:%d", s.ID, s.RunCount)
+}
+
+// advance moves the schedule past its current occurrence.
+func (s *ScheduledTransfer) advance(status ScheduleStatus) {
+	s.RunCount++
+	s.Attempts = 0
+	switch s.Recurrence {
+	case RecurrenceWeekly:
+		s.ScheduledAt = s.ScheduledAt.AddDate(0, 0, 7)
+	case RecurrenceMonthly:
+		s.ScheduledAt = nextMonthlyRun(s.ScheduledAt, s.DayOfMonth)
+	default:
+		s.Status = status
+		return
+	}
+	s.NextRunAt = s.ScheduledAt
+}
+
+// nextMonthlyRun returns the run in the month after from, on day or on the last day of
+// that month when it is shorter, at the same time of day.
+func nextMonthlyRun(from time.Time, day int) time.Time {
+	year, month := from.Year(), from.Month()+1
+	if last := daysIn(year, month, from.Location()); day > last {
+		day = last
+	}
+	return time.Date(year, month, day, from.Hour(), from.Minute(), from.Second(), 0, from.Location())
+}
+
+func daysIn(year int, month time.Month, loc *time.Location) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+}
+
+// ScheduleInput creates or replaces the editable fields of a scheduled transfer.
+type ScheduleInput struct {
+	UserID        string
+	FromAccountID string // Optional, defaults to the user's default account
+	ToAccountID   string
+	Amount        money.Amount
+	Currency      money.Currency
+	Description   string
+	Category      string
+	Recurrence    Recurrence
+	DayOfMonth    int // Monthly only, defaults to the day of RunAt
+	RunAt         time.Time
+}
+
+type ScheduledTransferService interface {
+	Create(ctx context.Context, in ScheduleInput) (*ScheduledTransfer, error)
+	List(ctx context.Context, userID string) ([]ScheduledTransfer, error)
+	Get(ctx context.Context, userID, id string) (*ScheduledTransfer, error)
+	Update(ctx context.Context, id string, in ScheduleInput) (*ScheduledTransfer, error)
+	Cancel(ctx context.Context, userID, id string) error
+}
+
+type scheduledTransferService struct {
+	repo   ScheduledTransferRepository
+	reader AccountReader
+}
+
+func NewScheduledTransferService(repo ScheduledTransferRepository, reader AccountReader) ScheduledTransferService {
+	return &scheduledTransferService{repo: repo, reader: reader}
+}
+
+// Create implements ScheduledTransferService.
+func (s *scheduledTransferService) Create(ctx context.Context, in ScheduleInput) (*ScheduledTransfer, error) {
+	now := time.Now()
+	schedule := &ScheduledTransfer{
+		ID:        uuid.New().String(),
+		UserID:    in.UserID,
+		Status:    ScheduleStatusActive,
+		CreatedAt: now,
+	}
+	if err := s.apply(ctx, schedule, in); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// List implements ScheduledTransferService.
+func (s *scheduledTransferService) List(ctx context.Context, userID string) ([]ScheduledTransfer, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// Get implements ScheduledTransferService. Schedules of other users are reported as missing.
+func (s *scheduledTransferService) Get(ctx context.Context, userID, id string) (*ScheduledTransfer, error) {
+	schedule, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil || schedule.UserID != userID {
+		return nil, ErrScheduleNotFound
+	}
+	return schedule, nil
+}
+
+// Update implements ScheduledTransferService. The next occurrence is recomputed from
+// the new RunAt and the attempt counter starts over.
+func (s *scheduledTransferService) Update(ctx context.Context, id string, in ScheduleInput) (*ScheduledTransfer, error) {
+	schedule, err := s.Get(ctx, in.UserID, id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Status != ScheduleStatusActive {
+		return nil, ErrScheduleNotEditable
+	}
+
+	if err := s.apply(ctx, schedule, in); err != nil {
+		return nil, err
+	}
+	schedule.Attempts, schedule.LastError = 0, ""
+
+	if err := s.repo.Update(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Cancel implements ScheduledTransferService.
+func (s *scheduledTransferService) Cancel(ctx context.Context, userID, id string) error {
+	schedule, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if schedule.Status != ScheduleStatusActive {
+		return ErrScheduleNotEditable
+	}
+
+	schedule.Status = ScheduleStatusCancelled
+	return s.repo.Update(ctx, schedule)
+}
+
+// apply validates the input and copies it onto the schedule.
+func (s *scheduledTransferService) apply(ctx context.Context, schedule *ScheduledTransfer, in ScheduleInput) error {
+	if !in.Amount.IsPositive() {
+		return errors.New("amount must be greater than zero")
+	}
+	if in.ToAccountID == "" {
+		return errors.New("destination account is required")
+	}
+	if !in.RunAt.After(time.Now()) {
+		return errors.New("run_at must be in the future")
+	}
+
+	dayOfMonth := 0
+	switch in.Recurrence {
+	case RecurrenceOnce, RecurrenceWeekly:
+	case RecurrenceMonthly:
+		dayOfMonth = in.DayOfMonth
+		if dayOfMonth == 0 {
+			dayOfMonth = in.RunAt.Day()
+		}
+		if dayOfMonth < 1 || dayOfMonth > 31 {
+			return errors.New("day_of_month must be between 1 and 31")
+		}
+	default:
+		return fmt.Errorf("recurrence must be %s, %s or %s", RecurrenceOnce, RecurrenceWeekly, RecurrenceMonthly)
+	}
+
+	account, err := s.reader.GetAccountForUser(ctx, in.UserID, in.FromAccountID)
+	if err != nil || account == nil {
+		return errors.New("origin account not found")
+	}
+	if account.ID == in.ToAccountID {
+		return errors.New("cannot transfer to the same account")
+	}
+	if _, err := lookupDestination(ctx, s.reader, in.ToAccountID); err != nil {
+		return err
+	}
+
+	schedule.FromAccountID = account.ID
+	schedule.ToAccountID = in.ToAccountID
+	schedule.Amount = in.Amount
+	schedule.Currency = in.Currency
+	schedule.Description = in.Description
+	schedule.Category = in.Category
+	schedule.Recurrence = in.Recurrence
+	schedule.DayOfMonth = dayOfMonth
+	schedule.ScheduledAt = in.RunAt
+	schedule.NextRunAt = in.RunAt
+	schedule.UpdatedAt = time.Now()
+	return nil
+}
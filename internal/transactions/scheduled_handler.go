@@ -0,0 +1,142 @@
+package transactions
+
+import (
+	"encoding/json"
+	"errors"
+	"go-bank-app/pkg/middleware"
+	"go-bank-app/pkg/money"
+	"net/http"
+	"time"
+)
+
+type ScheduledTransferHandler struct {
+	service ScheduledTransferService
+}
+
+func NewScheduledTransferHandler(service ScheduledTransferService) *ScheduledTransferHandler {
+	return &ScheduledTransferHandler{service: service}
+}
+
+type scheduleRequest struct {
+	FromAccountID string         `json:"from_account_id"` // Optional, defaults to the caller's default account
+	ToAccountID   string         `json:"to_account_id"`
+	Amount        money.Amount   `json:"amount"`
+	Currency      money.Currency `json:"currency"` // Optional, the source or destination account currency
+	Description   string         `json:"description"`
+	Category      string         `json:"category"`
+	Recurrence    Recurrence     `json:"recurrence"`   // once, weekly or monthly
+	DayOfMonth    int            `json:"day_of_month"` // Monthly only, defaults to the day of run_at
+	RunAt         time.Time      `json:"run_at"`       // First execution, RFC 3339
+}
+
+// Create schedules a transfer for the caller.
+func (h *ScheduledTransferHandler) Create(w http.ResponseWriter, r *http.Request) {
+	in, ok := readScheduleInput(w, r)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.service.Create(r.Context(), in)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// List returns the caller's scheduled transfers, including finished and cancelled ones.
+func (h *ScheduledTransferHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	schedules, err := h.service.List(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Error retrieving scheduled transfers", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(schedules)
+}
+
+func (h *ScheduledTransferHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	schedule, err := h.service.Get(r.Context(), userID, r.PathValue("id"))
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// Update replaces the transfer and timing of an active schedule.
+func (h *ScheduledTransferHandler) Update(w http.ResponseWriter, r *http.Request) {
+	in, ok := readScheduleInput(w, r)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.service.Update(r.Context(), r.PathValue("id"), in)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// Cancel stops an active schedule. Occurrences that already ran are not undone.
+func (h *ScheduledTransferHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	if err := h.service.Cancel(r.Context(), userID, r.PathValue("id")); err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readScheduleInput decodes the request body. It writes the error response and returns
+// false when the body is invalid.
+func readScheduleInput(w http.ResponseWriter, r *http.Request) (ScheduleInput, bool) {
+	userID := r.Context().Value(middleware.ContextUserIDKey).(string)
+
+	var req scheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if errors.Is(err, money.ErrSubCent) {
+			http.Error(w, "Amount cannot have fractions of a cent", http.StatusBadRequest)
+			return ScheduleInput{}, false
+		}
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return ScheduleInput{}, false
+	}
+
+	return ScheduleInput{
+		UserID:        userID,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Description:   req.Description,
+		Category:      req.Category,
+		Recurrence:    req.Recurrence,
+		DayOfMonth:    req.DayOfMonth,
+		RunAt:         req.RunAt,
+	}, true
+}
+
+func writeScheduleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrScheduleNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrScheduleNotEditable), errors.Is(err, ErrScheduleChanged):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrDestinationNotFound), errors.Is(err, ErrDestinationUnavailable):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
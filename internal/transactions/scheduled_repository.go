@@ -0,0 +1,159 @@
+package transactions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type ScheduledTransferRepository interface {
+	Create(ctx context.Context, s *ScheduledTransfer) error
+	GetByID(ctx context.Context, id string) (*ScheduledTransfer, error)
+	ListByUser(ctx context.Context, userID string) ([]ScheduledTransfer, error)
+	// Update stores the editable fields and the status of the schedule. It returns
+	// ErrScheduleChanged when the schedule is running or was written since it was read.
+	Update(ctx context.Context, s *ScheduledTransfer) error
+	// ClaimDue leases up to limit active schedules whose next run is due. Leased schedules
+	// are skipped by other scheduler instances until SaveRun releases them or the lease
+	// expires, e.g. because the instance holding it crashed.
+	ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]ScheduledTransfer, error)
+	// SaveRun stores the outcome of a run and releases the lease. It returns
+	// ErrScheduleChanged when the schedule was written since it was claimed, e.g. by an
+	// instance that claimed it again after the lease expired.
+	SaveRun(ctx context.Context, s *ScheduledTransfer) error
+}
+
+type scheduledTransferRepository struct {
+	db *sql.DB
+}
+
+func NewScheduledTransferRepository(db *sql.DB) ScheduledTransferRepository {
+	return &scheduledTransferRepository{db: db}
+}
+
+const scheduledTransferColumns = `id, user_id, from_account_id, to_account_id, amount, currency, description, category,
+	recurrence, day_of_month, scheduled_at, next_run_at, status, run_count, attempts, last_error,
+	COALESCE(last_transaction_id::text, ''), created_at, updated_at, version`
+
+func scanScheduledTransfer(row rowScanner) (*ScheduledTransfer, error) {
+	var s ScheduledTransfer
+	err := row.Scan(&s.ID, &s.UserID, &s.FromAccountID, &s.ToAccountID, &s.Amount, &s.Currency, &s.Description, &s.Category,
+		&s.Recurrence, &s.DayOfMonth, &s.ScheduledAt, &s.NextRunAt, &s.Status, &s.RunCount, &s.Attempts, &s.LastError,
+		&s.LastTransactionID, &s.CreatedAt, &s.UpdatedAt, &s.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *scheduledTransferRepository) Create(ctx context.Context, s *ScheduledTransfer) error {
+	query := `
+                INSERT INTO scheduled_transfers (id, user_id, from_account_id, to_account_id, amount, currency, description, category,
+                        recurrence, day_of_month, scheduled_at, next_run_at, status, run_count, attempts, last_error, created_at, updated_at)
+                VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+        `
+	_, err := r.db.ExecContext(ctx, query, s.ID, s.UserID, s.FromAccountID, s.ToAccountID, s.Amount, s.Currency, s.Description, s.Category,
+		s.Recurrence, s.DayOfMonth, s.ScheduledAt, s.NextRunAt, s.Status, s.RunCount, s.Attempts, s.LastError, s.CreatedAt, s.UpdatedAt)
+	return err
+}
+
+func (r *scheduledTransferRepository) GetByID(ctx context.Context, id string) (*ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE id = $1`
+	return scanScheduledTransfer(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *scheduledTransferRepository) ListByUser(ctx context.Context, userID string) ([]ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE user_id = $1 ORDER BY next_run_at`
+	return r.list(ctx, query, userID)
+}
+
+func (r *scheduledTransferRepository) Update(ctx context.Context, s *ScheduledTransfer) error {
+	// A schedule is not changed while it runs, so the run is saved with the fields it ran with.
+	query := `
+                UPDATE scheduled_transfers
+                SET from_account_id = $1, to_account_id = $2, amount = $3, currency = $4, description = $5, category = $6,
+                        recurrence = $7, day_of_month = $8, scheduled_at = $9, next_run_at = $10, status = $11, attempts = $12,
+                        last_error = $13, updated_at = $14, version = version + 1
+                WHERE id = $15 AND version = $16 AND status = 'active' AND (locked_until IS NULL OR locked_until < $14)
+        `
+	res, err := r.db.ExecContext(ctx, query, s.FromAccountID, s.ToAccountID, s.Amount, s.Currency, s.Description, s.Category,
+		s.Recurrence, s.DayOfMonth, s.ScheduledAt, s.NextRunAt, s.Status, s.Attempts, s.LastError, time.Now(), s.ID, s.Version)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return r.conflict(ctx, s.ID)
+	}
+	s.Version++
+	return nil
+}
+
+// conflict tells why a write of the schedule with the given ID matched no row.
+func (r *scheduledTransferRepository) conflict(ctx context.Context, id string) error {
+	stored, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		return ErrScheduleNotFound
+	}
+	if stored.Status != ScheduleStatusActive {
+		return ErrScheduleNotEditable
+	}
+	return ErrScheduleChanged
+}
+
+func (r *scheduledTransferRepository) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]ScheduledTransfer, error) {
+	query := `
+                UPDATE scheduled_transfers
+                SET locked_until = $2, version = version + 1
+                WHERE id IN (
+                        SELECT id FROM scheduled_transfers
+                        WHERE status = 'active' AND next_run_at <= $1 AND (locked_until IS NULL OR locked_until < $1)
+                        ORDER BY next_run_at
+                        LIMIT $3
+                        FOR UPDATE SKIP LOCKED
+                )
+                RETURNING ` + scheduledTransferColumns
+	return r.list(ctx, query, now, now.Add(lease), limit)
+}
+
+func (r *scheduledTransferRepository) SaveRun(ctx context.Context, s *ScheduledTransfer) error {
+	query := `
+                UPDATE scheduled_transfers
+                SET scheduled_at = $1, next_run_at = $2, status = $3, run_count = $4, attempts = $5, last_error = $6,
+                        last_transaction_id = NULLIF($7, '')::uuid, locked_until = NULL, updated_at = $8, version = version + 1
+                WHERE id = $9 AND version = $10
+        `
+	res, err := r.db.ExecContext(ctx, query, s.ScheduledAt, s.NextRunAt, s.Status, s.RunCount, s.Attempts, s.LastError, s.LastTransactionID, time.Now(), s.ID, s.Version)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrScheduleChanged
+	}
+	s.Version++
+	return nil
+}
+
+func (r *scheduledTransferRepository) list(ctx context.Context, query string, args ...interface{}) ([]ScheduledTransfer, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []ScheduledTransfer
+	for rows.Next() {
+		s, err := scanScheduledTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *s)
+	}
+	return schedules, rows.Err()
+}
@@ -0,0 +1,200 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"go-bank-app/pkg/money"
+	"testing"
+	"time"
+)
+
+func TestNextMonthlyRun(t *testing.T) {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		from time.Time
+		day  int
+		want time.Time
+	}{
+		{"same day next month", at(2026, time.March, 15), 15, at(2026, time.April, 15)},
+		{"clamped to short month", at(2026, time.January, 31), 31, at(2026, time.February, 28)},
+		{"clamped in leap year", at(2028, time.January, 31), 31, at(2028, time.February, 29)},
+		{"back to day after short month", at(2026, time.February, 28), 31, at(2026, time.March, 31)},
+		{"across year end", at(2026, time.December, 10), 10, at(2027, time.January, 10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextMonthlyRun(tt.from, tt.day); !got.Equal(tt.want) {
+				t.Errorf("nextMonthlyRun(%s, %d) = %s, want %s", tt.from, tt.day, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduledTransfer_Advance(t *testing.T) {
+	start := time.Date(2026, time.May, 4, 8, 0, 0, 0, time.UTC)
+
+	// The occurrence was retried, so it ran later than scheduled.
+	weekly := ScheduledTransfer{Recurrence: RecurrenceWeekly, ScheduledAt: start, NextRunAt: start.Add(3 * time.Minute), Status: ScheduleStatusActive, Attempts: 2}
+	weekly.advance(ScheduleStatusCompleted)
+	if !weekly.NextRunAt.Equal(start.AddDate(0, 0, 7)) || !weekly.ScheduledAt.Equal(weekly.NextRunAt) || weekly.Status != ScheduleStatusActive {
+		t.Errorf("weekly schedule advanced to %s (%s)", weekly.NextRunAt, weekly.Status)
+	}
+	if weekly.RunCount != 1 || weekly.Attempts != 0 {
+		t.Errorf("expected run 1 with no attempts, got run %d with %d attempts", weekly.RunCount, weekly.Attempts)
+	}
+
+	once := ScheduledTransfer{Recurrence: RecurrenceOnce, ScheduledAt: start, NextRunAt: start, Status: ScheduleStatusActive}
+	once.advance(ScheduleStatusFailed)
+	if once.Status != ScheduleStatusFailed {
+		t.Errorf("expected one-off schedule to end as failed, got %s", once.Status)
+	}
+}
+
+type mockScheduleRepo struct {
+	schedules map[string]*ScheduledTransfer
+	saved     []ScheduledTransfer
+	// claims records the limit of every ClaimDue call.
+	claims []int
+}
+
+func newMockScheduleRepo(schedules ...ScheduledTransfer) *mockScheduleRepo {
+	m := &mockScheduleRepo{schedules: map[string]*ScheduledTransfer{}}
+	for i := range schedules {
+		m.schedules[schedules[i].ID] = &schedules[i]
+	}
+	return m
+}
+
+func (m *mockScheduleRepo) Create(ctx context.Context, s *ScheduledTransfer) error {
+	m.schedules[s.ID] = s
+	return nil
+}
+
+func (m *mockScheduleRepo) GetByID(ctx context.Context, id string) (*ScheduledTransfer, error) {
+	if s, ok := m.schedules[id]; ok {
+		clone := *s
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (m *mockScheduleRepo) ListByUser(ctx context.Context, userID string) ([]ScheduledTransfer, error) {
+	var list []ScheduledTransfer
+	for _, s := range m.schedules {
+		if s.UserID == userID {
+			list = append(list, *s)
+		}
+	}
+	return list, nil
+}
+
+func (m *mockScheduleRepo) Update(ctx context.Context, s *ScheduledTransfer) error {
+	if stored, ok := m.schedules[s.ID]; ok && stored.Version != s.Version {
+		return ErrScheduleChanged
+	}
+	s.Version++
+	m.schedules[s.ID] = s
+	return nil
+}
+
+func (m *mockScheduleRepo) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]ScheduledTransfer, error) {
+	m.claims = append(m.claims, limit)
+	var due []ScheduledTransfer
+	for _, s := range m.schedules {
+		if len(due) < limit && s.Status == ScheduleStatusActive && !s.NextRunAt.After(now) {
+			s.Version++
+			due = append(due, *s)
+		}
+	}
+	return due, nil
+}
+
+func (m *mockScheduleRepo) SaveRun(ctx context.Context, s *ScheduledTransfer) error {
+	if stored, ok := m.schedules[s.ID]; ok && stored.Version != s.Version {
+		return ErrScheduleChanged
+	}
+	s.Version++
+	m.saved = append(m.saved, *s)
+	m.schedules[s.ID] = s
+	return nil
+}
+
+func TestScheduledTransferService_Create(t *testing.T) {
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	service := NewScheduledTransferService(newMockScheduleRepo(), reader)
+	runAt := time.Now().Add(24 * time.Hour)
+
+	schedule, err := service.Create(context.Background(), ScheduleInput{
+		UserID:      "user1",
+		ToAccountID: "acc456",
+		Amount:      money.MustParse("250.00"),
+		Recurrence:  RecurrenceMonthly,
+		RunAt:       runAt,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schedule.FromAccountID != "acc123" {
+		t.Errorf("expected the default account to be resolved, got %q", schedule.FromAccountID)
+	}
+	if schedule.DayOfMonth != runAt.Day() || schedule.Status != ScheduleStatusActive {
+		t.Errorf("unexpected schedule: %+v", schedule)
+	}
+}
+
+func TestScheduledTransferService_CreateRejectsInvalidInput(t *testing.T) {
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	service := NewScheduledTransferService(newMockScheduleRepo(), reader)
+	valid := ScheduleInput{
+		UserID:      "user1",
+		ToAccountID: "acc456",
+		Amount:      money.MustParse("10.00"),
+		Recurrence:  RecurrenceWeekly,
+		RunAt:       time.Now().Add(time.Hour),
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(in *ScheduleInput)
+	}{
+		{"past run", func(in *ScheduleInput) { in.RunAt = time.Now().Add(-time.Minute) }},
+		{"zero amount", func(in *ScheduleInput) { in.Amount = 0 }},
+		{"unknown recurrence", func(in *ScheduleInput) { in.Recurrence = "daily" }},
+		{"day out of range", func(in *ScheduleInput) { in.Recurrence, in.DayOfMonth = RecurrenceMonthly, 32 }},
+		{"same account", func(in *ScheduleInput) { in.ToAccountID = "acc123" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := valid
+			tt.mutate(&in)
+			if _, err := service.Create(context.Background(), in); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestScheduledTransferService_OtherUsersSchedulesAreHidden(t *testing.T) {
+	repo := newMockScheduleRepo(ScheduledTransfer{ID: "s1", UserID: "owner", Status: ScheduleStatusActive})
+	service := NewScheduledTransferService(repo, &mockReader{})
+
+	if _, err := service.Get(context.Background(), "intruder", "s1"); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("expected ErrScheduleNotFound, got %v", err)
+	}
+	if err := service.Cancel(context.Background(), "intruder", "s1"); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("expected ErrScheduleNotFound, got %v", err)
+	}
+
+	if err := service.Cancel(context.Background(), "owner", "s1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.Cancel(context.Background(), "owner", "s1"); !errors.Is(err, ErrScheduleNotEditable) {
+		t.Errorf("expected ErrScheduleNotEditable on a cancelled schedule, got %v", err)
+	}
+}
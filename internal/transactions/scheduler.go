@@ -0,0 +1,182 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// SchedulerConfig tunes how the Scheduler polls and retries.
+type SchedulerConfig struct {
+	Interval  time.Duration // How often due schedules are polled
+	BatchSize int           // Schedules run per poll, claimed one at a time
+	// Lease is how long a claimed schedule stays hidden from other instances. It must
+	// outlast a run, otherwise a slow run can be picked up twice.
+	Lease        time.Duration
+	MaxAttempts  int           // Attempts per occurrence before giving up on it
+	RetryBackoff time.Duration // Delay before the first retry, doubled on every further one
+}
+
+var DefaultSchedulerConfig = SchedulerConfig{
+	Interval:     30 * time.Second,
+	BatchSize:    20,
+	Lease:        5 * time.Minute,
+	MaxAttempts:  3,
+	RetryBackoff: time.Minute,
+}
+
+// Scheduler executes due scheduled transfers through the TransactionService. Each
+// occurrence runs with the idempotency key of its schedule and run number, so an
+// occurrence retried after a restart is replayed instead of being executed again.
+type Scheduler struct {
+	repo     ScheduledTransferRepository
+	service  TransactionService
+	notifier Notifier
+	config   SchedulerConfig
+	now      func() time.Time
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+func NewScheduler(repo ScheduledTransferRepository, service TransactionService, notifier Notifier, config SchedulerConfig) *Scheduler {
+	return &Scheduler{
+		repo:     repo,
+		service:  service,
+		notifier: notifier,
+		config:   config,
+		now:      time.Now,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start polls for due schedules in the background until Shutdown is called.
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			s.RunDue(context.Background())
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Shutdown stops polling and waits for the runs in progress, or until ctx expires.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.once.Do(func() { close(s.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunDue runs up to BatchSize due schedules. Each is claimed right before it runs, so
+// its lease covers only its own run and not the runs queued ahead of it.
+func (s *Scheduler) RunDue(ctx context.Context) {
+	for i := 0; i < s.config.BatchSize; i++ {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		due, err := s.repo.ClaimDue(ctx, s.now(), 1, s.config.Lease)
+		if err != nil {
+			log.Printf("❌ Failed to claim scheduled transfers: %v", err)
+			return
+		}
+		if len(due) == 0 {
+			return
+		}
+		s.run(ctx, &due[0])
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, schedule *ScheduledTransfer) {
+	// Give up on the run before the lease expires and another instance claims it.
+	runCtx, cancel := context.WithTimeout(ctx, s.config.Lease)
+	defer cancel()
+
+	tx, err := s.service.Transfer(runCtx, TransferInput{
+		UserID:         schedule.UserID,
+		FromAccountID:  schedule.FromAccountID,
+		ToAccountID:    schedule.ToAccountID,
+		Amount:         schedule.Amount,
+		Currency:       schedule.Currency,
+		Description:    schedule.Description,
+		Category:       schedule.Category,
+		IdempotencyKey: schedule.idempotencyKey(),
+	})
+
+	if err == nil {
+		log.Printf("⏰ Scheduled transfer [%s] run %d completed as transaction [%s]", schedule.ID, schedule.RunCount, tx.ID)
+		schedule.LastTransactionID = tx.ID
+		schedule.LastError = ""
+		schedule.advance(ScheduleStatusCompleted)
+	} else {
+		s.fail(ctx, schedule, err)
+	}
+
+	// A schedule claimed again after the lease expired is saved by the instance that
+	// claimed it last, which replays this run under the same idempotency key.
+	if err := s.repo.SaveRun(ctx, schedule); errors.Is(err, ErrScheduleChanged) {
+		log.Printf("⚠️ Scheduled transfer [%s] was claimed again while it ran, leaving the run to the new claim", schedule.ID)
+	} else if err != nil {
+		log.Printf("❌ Failed to save run of scheduled transfer [%s]: %v", schedule.ID, err)
+	}
+}
+
+// fail schedules a retry of the current occurrence, or gives up on it once it ran out of
+// attempts. Recurring schedules then carry on with their next occurrence.
+func (s *Scheduler) fail(ctx context.Context, schedule *ScheduledTransfer, err error) {
+	schedule.Attempts++
+	schedule.LastError = err.Error()
+
+	if schedule.Attempts < s.config.MaxAttempts {
+		backoff := s.config.RetryBackoff << (schedule.Attempts - 1)
+		schedule.NextRunAt = s.now().Add(backoff)
+		log.Printf("⚠️ Scheduled transfer [%s] run %d failed (attempt %d/%d), retrying in %s: %v",
+			schedule.ID, schedule.RunCount, schedule.Attempts, s.config.MaxAttempts, backoff, err)
+		s.notify(ctx, schedule, "Scheduled transfer delayed",
+			fmt.Sprintf("Your scheduled transfer of %s %s could not be completed (%v). We will try again at %s.",
+				schedule.Amount, schedule.Currency, err, schedule.NextRunAt.Format(time.RFC3339)))
+		return
+	}
+
+	log.Printf("❌ Scheduled transfer [%s] run %d failed after %d attempts: %v", schedule.ID, schedule.RunCount, schedule.Attempts, err)
+	schedule.advance(ScheduleStatusFailed)
+
+	message := fmt.Sprintf("Your scheduled transfer of %s %s failed: %v.", schedule.Amount, schedule.Currency, err)
+	if schedule.Status == ScheduleStatusActive {
+		message += fmt.Sprintf(" The next one is scheduled for %s.", schedule.NextRunAt.Format(time.RFC3339))
+	}
+	s.notify(ctx, schedule, "Scheduled transfer failed", message)
+}
+
+func (s *Scheduler) notify(ctx context.Context, schedule *ScheduledTransfer, subject, message string) {
+	if err := s.notifier.Notify(ctx, Notification{UserID: schedule.UserID, Subject: subject, Message: message}); err != nil {
+		log.Printf("❌ Failed to notify user [%s] about scheduled transfer [%s]: %v", schedule.UserID, schedule.ID, err)
+	}
+}
@@ -0,0 +1,210 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"go-bank-app/pkg/money"
+	"testing"
+	"time"
+)
+
+// stubTransfers is a TransactionService whose transfers fail with the queued errors and
+// succeed once they run out.
+type stubTransfers struct {
+	TransactionService
+	errs []error
+	got  []TransferInput
+	// during, when set, runs while the transfer is in progress.
+	during func()
+}
+
+func (s *stubTransfers) Transfer(ctx context.Context, in TransferInput) (*Transaction, error) {
+	s.got = append(s.got, in)
+	if s.during != nil {
+		s.during()
+	}
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return nil, err
+	}
+	return &Transaction{ID: "tx-" + in.IdempotencyKey}, nil
+}
+
+type recordingNotifier struct {
+	sent []Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification Notification) error {
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func newTestScheduler(repo ScheduledTransferRepository, transfers TransactionService, notifier Notifier, now time.Time) *Scheduler {
+	s := NewScheduler(repo, transfers, notifier, SchedulerConfig{
+		BatchSize:    10,
+		Lease:        time.Minute,
+		MaxAttempts:  2,
+		RetryBackoff: time.Minute,
+	})
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestScheduler_RunsDueScheduleAndAdvances(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 9, 0, 0, 0, time.UTC)
+	repo := newMockScheduleRepo(ScheduledTransfer{
+		ID: "s1", UserID: "user1", FromAccountID: "acc123", ToAccountID: "acc456",
+		Amount: money.MustParse("100.00"), Recurrence: RecurrenceWeekly,
+		ScheduledAt: now.Add(-time.Minute), NextRunAt: now.Add(-time.Minute), Status: ScheduleStatusActive, RunCount: 3,
+	})
+	transfers := &stubTransfers{}
+	notifier := &recordingNotifier{}
+
+	newTestScheduler(repo, transfers, notifier, now).RunDue(context.Background())
+
+	if len(transfers.got) != 1 || transfers.got[0].IdempotencyKey != "schedule:s1:3" {
+		t.Fatalf("expected one transfer keyed by schedule and run, got %+v", transfers.got)
+	}
+	saved := repo.schedules["s1"]
+	if saved.RunCount != 4 || !saved.NextRunAt.Equal(now.Add(-time.Minute).AddDate(0, 0, 7)) {
+		t.Errorf("expected the next weekly run, got run %d at %s", saved.RunCount, saved.NextRunAt)
+	}
+	if saved.LastTransactionID != "tx-schedule:s1:3" {
+		t.Errorf("expected the transaction to be recorded, got %q", saved.LastTransactionID)
+	}
+	if len(notifier.sent) != 0 {
+		t.Errorf("expected no notifications, got %+v", notifier.sent)
+	}
+}
+
+func TestScheduler_RetriesThenGivesUp(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 9, 0, 0, 0, time.UTC)
+	repo := newMockScheduleRepo(ScheduledTransfer{
+		ID: "s1", UserID: "user1", FromAccountID: "acc123", ToAccountID: "acc456",
+		Amount: money.MustParse("100.00"), Recurrence: RecurrenceOnce,
+		ScheduledAt: now, NextRunAt: now, Status: ScheduleStatusActive,
+	})
+	insufficient := errors.New("insufficient funds")
+	transfers := &stubTransfers{errs: []error{insufficient, insufficient}}
+	notifier := &recordingNotifier{}
+
+	scheduler := newTestScheduler(repo, transfers, notifier, now)
+	scheduler.RunDue(context.Background())
+
+	saved := repo.schedules["s1"]
+	if saved.Status != ScheduleStatusActive || saved.Attempts != 1 || !saved.NextRunAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected a retry in a minute, got %+v", saved)
+	}
+
+	// Nothing is due until the backoff elapses.
+	scheduler.RunDue(context.Background())
+	if len(transfers.got) != 1 {
+		t.Fatalf("expected the retry to wait for its backoff, got %d transfers", len(transfers.got))
+	}
+
+	scheduler.now = func() time.Time { return now.Add(time.Minute) }
+	scheduler.RunDue(context.Background())
+
+	if transfers.got[1].IdempotencyKey != transfers.got[0].IdempotencyKey {
+		t.Errorf("expected the retry to reuse the run's idempotency key, got %q and %q",
+			transfers.got[0].IdempotencyKey, transfers.got[1].IdempotencyKey)
+	}
+	saved = repo.schedules["s1"]
+	if saved.Status != ScheduleStatusFailed || saved.LastError != insufficient.Error() {
+		t.Errorf("expected the schedule to fail after two attempts, got %+v", saved)
+	}
+	if len(notifier.sent) != 2 || notifier.sent[1].Subject != "Scheduled transfer failed" {
+		t.Errorf("expected a retry and a failure notification, got %+v", notifier.sent)
+	}
+}
+
+func TestScheduler_RetryKeepsMonthlyTimeOfDay(t *testing.T) {
+	scheduledAt := time.Date(2026, time.January, 31, 8, 0, 0, 0, time.UTC)
+	repo := newMockScheduleRepo(ScheduledTransfer{
+		ID: "s1", UserID: "user1", FromAccountID: "acc123", ToAccountID: "acc456",
+		Amount: money.MustParse("100.00"), Recurrence: RecurrenceMonthly, DayOfMonth: 31,
+		ScheduledAt: scheduledAt, NextRunAt: scheduledAt, Status: ScheduleStatusActive,
+	})
+	transfers := &stubTransfers{errs: []error{errors.New("insufficient funds")}}
+
+	scheduler := newTestScheduler(repo, transfers, &recordingNotifier{}, scheduledAt)
+	scheduler.RunDue(context.Background())
+	scheduler.now = func() time.Time { return scheduledAt.Add(time.Minute) }
+	scheduler.RunDue(context.Background())
+
+	saved := repo.schedules["s1"]
+	expected := time.Date(2026, time.February, 28, 8, 0, 0, 0, time.UTC)
+	if saved.RunCount != 1 || !saved.ScheduledAt.Equal(expected) || !saved.NextRunAt.Equal(expected) {
+		t.Errorf("expected run 1 at %s, got run %d scheduled at %s, next run at %s", expected, saved.RunCount, saved.ScheduledAt, saved.NextRunAt)
+	}
+}
+
+func TestScheduler_RunDoesNotOverwriteConcurrentWrite(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 9, 0, 0, 0, time.UTC)
+	repo := newMockScheduleRepo(ScheduledTransfer{
+		ID: "s1", UserID: "user1", FromAccountID: "acc123", ToAccountID: "acc456",
+		Amount: money.MustParse("100.00"), Recurrence: RecurrenceWeekly,
+		ScheduledAt: now, NextRunAt: now, Status: ScheduleStatusActive,
+	})
+	// The schedule is cancelled after the lease of the run expired.
+	transfers := &stubTransfers{during: func() {
+		cancelled, _ := repo.GetByID(context.Background(), "s1")
+		cancelled.Status = ScheduleStatusCancelled
+		if err := repo.Update(context.Background(), cancelled); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}}
+
+	newTestScheduler(repo, transfers, &recordingNotifier{}, now).RunDue(context.Background())
+
+	if len(transfers.got) != 1 || len(repo.saved) != 0 {
+		t.Fatalf("expected one run whose outcome is not saved, got %d runs and %d saves", len(transfers.got), len(repo.saved))
+	}
+	if stored := repo.schedules["s1"]; stored.Status != ScheduleStatusCancelled || stored.RunCount != 0 {
+		t.Errorf("expected the cancellation to stand, got %s after %d runs", stored.Status, stored.RunCount)
+	}
+}
+
+func TestScheduler_ClaimsOneScheduleAtATime(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 9, 0, 0, 0, time.UTC)
+	var schedules []ScheduledTransfer
+	for _, id := range []string{"s1", "s2", "s3"} {
+		schedules = append(schedules, ScheduledTransfer{
+			ID: id, UserID: "user1", FromAccountID: "acc123", ToAccountID: "acc456",
+			Amount: money.MustParse("100.00"), Recurrence: RecurrenceOnce,
+			ScheduledAt: now, NextRunAt: now, Status: ScheduleStatusActive,
+		})
+	}
+	repo := newMockScheduleRepo(schedules...)
+	transfers := &stubTransfers{}
+
+	newTestScheduler(repo, transfers, &recordingNotifier{}, now).RunDue(context.Background())
+
+	if len(transfers.got) != 3 {
+		t.Fatalf("expected every due schedule to run, got %d transfers", len(transfers.got))
+	}
+	// One claim per run and a last one that finds nothing left.
+	if len(repo.claims) != 4 {
+		t.Fatalf("expected 4 claims, got %v", repo.claims)
+	}
+	for _, limit := range repo.claims {
+		if limit != 1 {
+			t.Errorf("expected schedules to be claimed one at a time, got %v", repo.claims)
+			break
+		}
+	}
+}
+
+func TestScheduler_ShutdownStopsLoop(t *testing.T) {
+	scheduler := NewScheduler(newMockScheduleRepo(), &stubTransfers{}, &recordingNotifier{}, SchedulerConfig{
+		Interval: time.Hour, BatchSize: 1, Lease: time.Minute, MaxAttempts: 1,
+	})
+	scheduler.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := scheduler.Shutdown(ctx); err != nil {
+		t.Fatalf("expected the scheduler to stop, got %v", err)
+	}
+}
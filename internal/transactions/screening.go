@@ -0,0 +1,233 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-bank-app/pkg/money"
+	"os"
+	"time"
+)
+
+var ErrTransferBlocked = errors.New("transfer was blocked by fraud screening")
+
+// ScreeningAction is what fraud screening decides to do with a transfer.
+type ScreeningAction string
+
+const (
+	ScreeningAllow ScreeningAction = "allow"
+	ScreeningHold  ScreeningAction = "hold" // Wait for a reviewer to approve or reject it
+	ScreeningBlock ScreeningAction = "block"
+)
+
+// severity orders actions so the strictest matching rule wins.
+func (a ScreeningAction) severity() int {
+	switch a {
+	case ScreeningBlock:
+		return 2
+	case ScreeningHold:
+		return 1
+	}
+	return 0
+}
+
+// FraudRuleType selects what a rule looks at.
+type FraudRuleType string
+
+const (
+	// RuleNewPayeeLargeAmount matches transfers of at least Amounts[currency] to an
+	// account the sender never transferred to before.
+	RuleNewPayeeLargeAmount FraudRuleType = "new_payee_large_amount"
+	// RuleRapidFire matches when the sender already made MaxTransfers transfers within
+	// Window.
+	RuleRapidFire FraudRuleType = "rapid_fire"
+	// RuleNearLimit matches transfers of at least ThresholdPercent of the per-transaction
+	// limit, a common way of probing it.
+	RuleNearLimit FraudRuleType = "near_limit"
+	// RuleNewAccountFirstTransfer matches the first transfer out of an account opened
+	// less than AccountAge ago.
+	RuleNewAccountFirstTransfer FraudRuleType = "new_account_first_transfer"
+)
+
+// ruleDuration reads durations such as "10m" or "72h" from the rules file.
+type ruleDuration time.Duration
+
+func (d *ruleDuration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = ruleDuration(parsed)
+	return nil
+}
+
+// FraudRule is one entry of the rules file. Only the fields of its type are used.
+type FraudRule struct {
+	Name             string                          `json:"name"`
+	Type             FraudRuleType                   `json:"type"`
+	Action           ScreeningAction                 `json:"action"`
+	Amounts          map[money.Currency]money.Amount `json:"amounts,omitempty"`
+	MaxTransfers     int                             `json:"max_transfers,omitempty"`
+	Window           ruleDuration                    `json:"window,omitempty"`
+	ThresholdPercent int64                           `json:"threshold_percent,omitempty"`
+	AccountAge       ruleDuration                    `json:"account_age,omitempty"`
+}
+
+func (r FraudRule) validate() error {
+	switch r.Action {
+	case ScreeningAllow, ScreeningHold, ScreeningBlock:
+	default:
+		return fmt.Errorf("rule %q: unknown action %q", r.Name, r.Action)
+	}
+
+	switch r.Type {
+	case RuleNewPayeeLargeAmount:
+		if len(r.Amounts) == 0 {
+			return fmt.Errorf("rule %q: amounts are required", r.Name)
+		}
+	case RuleRapidFire:
+		if r.MaxTransfers < 1 || r.Window <= 0 {
+			return fmt.Errorf("rule %q: max_transfers and window are required", r.Name)
+		}
+	case RuleNearLimit:
+		if r.ThresholdPercent < 1 || r.ThresholdPercent > 100 {
+			return fmt.Errorf("rule %q: threshold_percent must be between 1 and 100", r.Name)
+		}
+	case RuleNewAccountFirstTransfer:
+		if r.AccountAge <= 0 {
+			return fmt.Errorf("rule %q: account_age is required", r.Name)
+		}
+	default:
+		return fmt.Errorf("rule %q: unknown type %q", r.Name, r.Type)
+	}
+	return nil
+}
+
+// LoadFraudRules reads the rules from a JSON file holding {"rules": [...]}.
+func LoadFraudRules(path string) ([]FraudRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Rules []FraudRule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid fraud rules file %s: %w", path, err)
+	}
+	for _, rule := range file.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("invalid fraud rules file %s: %w", path, err)
+		}
+	}
+	return file.Rules, nil
+}
+
+// ScreeningRequest describes a transfer about to be submitted.
+type ScreeningRequest struct {
+	Account     AccountInfo
+	ToAccountID string
+	Amount      money.Amount // Debited from Account, in its currency
+	Limits      TransferLimits
+}
+
+// ScreeningDecision is the outcome of screening and the rules that led to it.
+type ScreeningDecision struct {
+	Action ScreeningAction
+	Rules  []string
+}
+
+// Screener screens transfers for fraud before they move any money.
+type Screener interface {
+	Screen(ctx context.Context, req ScreeningRequest) (ScreeningDecision, error)
+}
+
+// TransferHistory answers the questions rules ask about past transfers.
+type TransferHistory interface {
+	// CountOutgoingTransfers counts the transfers out of the account created since the
+	// given time that have not failed.
+	CountOutgoingTransfers(ctx context.Context, accountID string, since time.Time) (int, error)
+	// HasTransferredTo reports whether the account completed a transfer to toAccountID.
+	HasTransferredTo(ctx context.Context, fromAccountID, toAccountID string) (bool, error)
+}
+
+// RuleScreener evaluates every rule and applies the strictest action among the ones
+// that match. Transfers no rule matches are allowed.
+type RuleScreener struct {
+	rules   []FraudRule
+	history TransferHistory
+	now     func() time.Time
+}
+
+func NewRuleScreener(rules []FraudRule, history TransferHistory) *RuleScreener {
+	return &RuleScreener{rules: rules, history: history, now: time.Now}
+}
+
+// Screen implements Screener.
+func (s *RuleScreener) Screen(ctx context.Context, req ScreeningRequest) (ScreeningDecision, error) {
+	decision := ScreeningDecision{Action: ScreeningAllow}
+
+	for _, rule := range s.rules {
+		matched, err := s.matches(ctx, rule, req)
+		if err != nil {
+			return ScreeningDecision{}, fmt.Errorf("fraud rule %q: %w", rule.Name, err)
+		}
+		if !matched {
+			continue
+		}
+
+		decision.Rules = append(decision.Rules, rule.Name)
+		if rule.Action.severity() > decision.Action.severity() {
+			decision.Action = rule.Action
+		}
+	}
+
+	return decision, nil
+}
+
+func (s *RuleScreener) matches(ctx context.Context, rule FraudRule, req ScreeningRequest) (bool, error) {
+	switch rule.Type {
+	case RuleNewPayeeLargeAmount:
+		threshold, ok := rule.Amounts[req.Account.Currency]
+		if !ok || req.Amount < threshold {
+			return false, nil
+		}
+		known, err := s.history.HasTransferredTo(ctx, req.Account.ID, req.ToAccountID)
+		return !known, err
+
+	case RuleRapidFire:
+		since := s.now().Add(-time.Duration(rule.Window))
+		count, err := s.history.CountOutgoingTransfers(ctx, req.Account.ID, since)
+		return count >= rule.MaxTransfers, err
+
+	case RuleNearLimit:
+		limit := req.Limits.PerTransaction
+		if limit.IsZero() {
+			return false, nil
+		}
+		return int64(req.Amount)*100 >= int64(limit)*rule.ThresholdPercent, nil
+
+	case RuleNewAccountFirstTransfer:
+		if req.Account.CreatedAt.IsZero() || s.now().Sub(req.Account.CreatedAt) >= time.Duration(rule.AccountAge) {
+			return false, nil
+		}
+		count, err := s.history.CountOutgoingTransfers(ctx, req.Account.ID, time.Time{})
+		return count == 0, err
+	}
+
+	return false, nil
+}
+
+// screen runs fraud screening on a transfer. Without a screener every transfer is allowed.
+func (s *transactionService) screen(ctx context.Context, req ScreeningRequest) (ScreeningDecision, error) {
+	if s.screener == nil {
+		return ScreeningDecision{Action: ScreeningAllow}, nil
+	}
+	return s.screener.Screen(ctx, req)
+}
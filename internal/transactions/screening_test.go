@@ -0,0 +1,310 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"go-bank-app/pkg/database"
+	"go-bank-app/pkg/money"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type mockReviews struct {
+	// repo, when set, stores the held transactions.
+	repo      *mockRepo
+	createErr error
+	created   []*FraudReview
+	resolved  map[string]ReviewStatus
+	// resolveErr fails Resolve before anything is recorded.
+	resolveErr error
+}
+
+func (m *mockReviews) WithTx(exec database.Executor) ReviewRepository {
+	return m
+}
+
+func (m *mockReviews) CreateHeld(ctx context.Context, tx *Transaction, review *FraudReview, record func(ctx context.Context, exec database.Executor) error) error {
+	if m.createErr != nil {
+		return m.createErr
+	}
+	if m.repo != nil {
+		if err := m.repo.Create(ctx, tx); err != nil {
+			return err
+		}
+	}
+	m.created = append(m.created, review)
+	return record(ctx, nil)
+}
+
+func (m *mockReviews) List(ctx context.Context, status ReviewStatus) ([]FraudReview, error) {
+	var reviews []FraudReview
+	for _, review := range m.created {
+		if review.Status == status {
+			reviews = append(reviews, *review)
+		}
+	}
+	return reviews, nil
+}
+
+func (m *mockReviews) Resolve(ctx context.Context, transactionID string, status ReviewStatus, reviewerID, note string, record func(ctx context.Context, exec database.Executor) error) error {
+	if m.resolveErr != nil {
+		return m.resolveErr
+	}
+	if record != nil {
+		if err := record(ctx, nil); err != nil {
+			return err
+		}
+	}
+	if m.resolved == nil {
+		m.resolved = map[string]ReviewStatus{}
+	}
+	m.resolved[transactionID] = status
+	return nil
+}
+
+type fixedScreener ScreeningDecision
+
+func (f fixedScreener) Screen(ctx context.Context, req ScreeningRequest) (ScreeningDecision, error) {
+	return ScreeningDecision(f), nil
+}
+
+func TestLoadFraudRules(t *testing.T) {
+	rules, err := LoadFraudRules("testdata/fraud_rules.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 2 || rules[1].Window != ruleDuration(10*time.Minute) || rules[0].Amounts[money.CurrencyMXN] != money.MustParse("1000.00") {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+
+	if err := (FraudRule{Name: "bad", Type: RuleRapidFire, Action: ScreeningHold}).validate(); err == nil {
+		t.Error("expected a rapid_fire rule without window to be invalid")
+	}
+	if err := (FraudRule{Name: "bad", Type: RuleNearLimit, Action: "freeze", ThresholdPercent: 90}).validate(); err == nil {
+		t.Error("expected an unknown action to be invalid")
+	}
+}
+
+func TestRuleScreener_Screen(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC)
+	rules := []FraudRule{
+		{Name: "new_payee", Type: RuleNewPayeeLargeAmount, Action: ScreeningHold, Amounts: map[money.Currency]money.Amount{money.CurrencyMXN: money.MustParse("1000.00")}},
+		{Name: "rapid_fire", Type: RuleRapidFire, Action: ScreeningBlock, MaxTransfers: 2, Window: ruleDuration(10 * time.Minute)},
+		{Name: "near_limit", Type: RuleNearLimit, Action: ScreeningHold, ThresholdPercent: 90},
+		{Name: "new_account", Type: RuleNewAccountFirstTransfer, Action: ScreeningHold, AccountAge: ruleDuration(72 * time.Hour)},
+	}
+	oldAccount := AccountInfo{ID: "acc123", Currency: money.CurrencyMXN, CreatedAt: now.AddDate(-1, 0, 0)}
+	transfer := func(to string, minutesAgo int, status TransactionStatus) *Transaction {
+		return &Transaction{
+			Type: TransactionTypeTransfer, Status: status, FromAccountID: "acc123", ToAccountID: to,
+			Amount: money.MustParse("10.00"), CreatedAt: now.Add(-time.Duration(minutesAgo) * time.Minute),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		history    []*Transaction
+		account    AccountInfo
+		amount     string
+		limits     TransferLimits
+		wantAction ScreeningAction
+		wantRules  []string
+	}{
+		{
+			name:       "known payee",
+			history:    []*Transaction{transfer("acc456", 600, TransactionStatusCompleted)},
+			account:    oldAccount,
+			amount:     "5000.00",
+			wantAction: ScreeningAllow,
+		},
+		{
+			name:       "new payee large amount",
+			history:    []*Transaction{transfer("acc789", 600, TransactionStatusCompleted)},
+			account:    oldAccount,
+			amount:     "5000.00",
+			wantAction: ScreeningHold,
+			wantRules:  []string{"new_payee"},
+		},
+		{
+			name:       "rapid fire wins over hold",
+			history:    []*Transaction{transfer("acc456", 1, TransactionStatusCompleted), transfer("acc456", 5, TransactionStatusPending)},
+			account:    oldAccount,
+			amount:     "950.00",
+			limits:     TransferLimits{PerTransaction: money.MustParse("1000.00")},
+			wantAction: ScreeningBlock,
+			wantRules:  []string{"rapid_fire", "near_limit"},
+		},
+		{
+			name:       "failed transfers are not rapid fire",
+			history:    []*Transaction{transfer("acc456", 1, TransactionStatusFailed), transfer("acc456", 2, TransactionStatusFailed)},
+			account:    oldAccount,
+			amount:     "10.00",
+			wantAction: ScreeningAllow,
+		},
+		{
+			name:       "first transfer from new account",
+			account:    AccountInfo{ID: "acc123", Currency: money.CurrencyMXN, CreatedAt: now.Add(-time.Hour)},
+			amount:     "10.00",
+			wantAction: ScreeningHold,
+			wantRules:  []string{"new_account"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			screener := NewRuleScreener(rules, &mockRepo{created: tt.history})
+			screener.now = func() time.Time { return now }
+
+			decision, err := screener.Screen(context.Background(), ScreeningRequest{
+				Account:     tt.account,
+				ToAccountID: "acc456",
+				Amount:      money.MustParse(tt.amount),
+				Limits:      tt.limits,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decision.Action != tt.wantAction || !reflect.DeepEqual(decision.Rules, tt.wantRules) {
+				t.Errorf("got %s %v, want %s %v", decision.Action, decision.Rules, tt.wantAction, tt.wantRules)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Transfer_Blocked(t *testing.T) {
+	repo := &mockRepo{}
+	publisher := &mockPublisher{}
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	screener := fixedScreener{Action: ScreeningBlock, Rules: []string{"rapid_fire"}}
+	svc := NewTransactionService(repo, publisher, reader, nil, nil, nil, FundingLimits{}, noLimits, screener, &mockReviews{})
+
+	_, err := svc.Transfer(context.Background(), TransferInput{UserID: "user1", ToAccountID: "acc456", Amount: money.MustParse("10.00")})
+	if !errors.Is(err, ErrTransferBlocked) {
+		t.Fatalf("expected ErrTransferBlocked, got %v", err)
+	}
+	if len(publisher.published) != 0 {
+		t.Error("expected a blocked transfer not to reach the balance workers")
+	}
+	if len(repo.created) != 1 || repo.created[0].Status != TransactionStatusFailed {
+		t.Fatalf("expected the blocked attempt to be recorded as failed, got %+v", repo.created)
+	}
+}
+
+func TestTransactionService_HoldIsAtomic(t *testing.T) {
+	repo := &mockRepo{}
+	idempotency := newMockIdempotency()
+	reviews := &mockReviews{repo: repo, createErr: errors.New("connection reset")}
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	screener := fixedScreener{Action: ScreeningHold, Rules: []string{"new_payee"}}
+	svc := NewTransactionService(repo, &mockPublisher{}, reader, idempotency, nil, nil, FundingLimits{}, nil, screener, reviews)
+
+	_, err := svc.Transfer(context.Background(), TransferInput{UserID: "user1", ToAccountID: "acc456", Amount: money.MustParse("10.00"), IdempotencyKey: "key1"})
+	if err == nil {
+		t.Fatal("expected the failed review to fail the transfer")
+	}
+	if len(repo.created) != 0 || len(reviews.created) != 0 {
+		t.Errorf("expected neither the held transfer nor its review to be stored, got %d and %d", len(repo.created), len(reviews.created))
+	}
+	if _, ok := idempotency.records["user1key1"]; ok {
+		t.Error("expected the idempotency key to be released for a retry")
+	}
+}
+
+func TestTransactionService_HeldTransferReview(t *testing.T) {
+	setup := func() (*mockRepo, *mockPublisher, *mockReviews, TransactionService, *Transaction) {
+		repo := &mockRepo{}
+		publisher := &mockPublisher{}
+		reviews := &mockReviews{repo: repo}
+		reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+		screener := fixedScreener{Action: ScreeningHold, Rules: []string{"new_payee"}}
+		svc := NewTransactionService(repo, publisher, reader, nil, nil, nil, FundingLimits{}, noLimits, screener, reviews)
+
+		tx, err := svc.Transfer(context.Background(), TransferInput{UserID: "user1", ToAccountID: "acc456", Amount: money.MustParse("10.00")})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tx.Status != TransactionStatusHeld || len(publisher.published) != 0 {
+			t.Fatalf("expected the transfer to be held without moving money, got %s", tx.Status)
+		}
+		if len(reviews.created) != 1 || reviews.created[0].TransactionID != tx.ID {
+			t.Fatalf("expected a review for the held transfer, got %+v", reviews.created)
+		}
+		return repo, publisher, reviews, svc, tx
+	}
+
+	t.Run("approve", func(t *testing.T) {
+		_, publisher, reviews, svc, held := setup()
+
+		tx, err := svc.ApproveHeld(context.Background(), ReviewDecision{TransactionID: held.ID, ReviewerID: "admin1"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tx.Status != TransactionStatusCompleted || len(publisher.published) != 1 {
+			t.Errorf("expected the approved transfer to complete, got %s after %d publishes", tx.Status, len(publisher.published))
+		}
+		if reviews.resolved[held.ID] != ReviewStatusApproved {
+			t.Errorf("expected the review to be approved, got %q", reviews.resolved[held.ID])
+		}
+
+		if _, err := svc.RejectHeld(context.Background(), ReviewDecision{TransactionID: held.ID}); !errors.Is(err, ErrReviewResolved) {
+			t.Errorf("expected ErrReviewResolved on a second decision, got %v", err)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		repo, publisher, reviews, svc, held := setup()
+
+		tx, err := svc.RejectHeld(context.Background(), ReviewDecision{TransactionID: held.ID, ReviewerID: "admin1", Note: "confirmed scam"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tx.Status != TransactionStatusFailed || tx.FailureReason != "rejected by fraud review: confirmed scam" {
+			t.Errorf("unexpected rejected transaction: %+v", tx)
+		}
+		if len(publisher.published) != 0 || reviews.resolved[held.ID] != ReviewStatusRejected {
+			t.Error("expected the rejected transfer not to move money")
+		}
+		if got := repo.statuses[held.ID]; !reflect.DeepEqual(got, []TransactionStatus{TransactionStatusFailed}) {
+			t.Errorf("expected held -> failed, got %v", got)
+		}
+	})
+
+	for _, decide := range []string{"approve", "reject"} {
+		t.Run(decide+" when the review cannot be resolved", func(t *testing.T) {
+			repo, _, reviews, svc, held := setup()
+			resolveErr := errors.New("connection reset")
+			reviews.resolveErr = resolveErr
+
+			decision := ReviewDecision{TransactionID: held.ID, ReviewerID: "admin1"}
+			var err error
+			if decide == "approve" {
+				_, err = svc.ApproveHeld(context.Background(), decision)
+			} else {
+				_, err = svc.RejectHeld(context.Background(), decision)
+			}
+			if !errors.Is(err, resolveErr) {
+				t.Fatalf("expected the resolve error, got %v", err)
+			}
+			if got := repo.statuses[held.ID]; len(got) != 0 {
+				t.Errorf("expected no status change without the review, got %v", got)
+			}
+			if stored, _ := repo.GetByID(context.Background(), held.ID); stored.Status != TransactionStatusHeld {
+				t.Errorf("expected the transfer to stay held, got %s", stored.Status)
+			}
+		})
+	}
+
+	t.Run("approve when the balance update fails", func(t *testing.T) {
+		repo, publisher, reviews, svc, held := setup()
+		errInsufficientFunds := errors.New("insufficient funds")
+		publisher.err = errInsufficientFunds
+
+		if _, err := svc.ApproveHeld(context.Background(), ReviewDecision{TransactionID: held.ID, ReviewerID: "admin1"}); !errors.Is(err, errInsufficientFunds) {
+			t.Fatalf("expected the balance update error, got %v", err)
+		}
+		if _, ok := reviews.resolved[held.ID]; ok || len(repo.statuses[held.ID]) != 0 {
+			t.Error("expected the review to stay pending and the transfer held")
+		}
+	})
+}
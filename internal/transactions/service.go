@@ -3,153 +3,688 @@ package transactions
 import (
 	"context"
 	"errors"
+	"fmt"
 	"go-bank-app/pkg/csvwriter"
-	"strconv"
+	"go-bank-app/pkg/database"
+	"go-bank-app/pkg/fx"
+	"go-bank-app/pkg/money"
+	"io"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jung-kurt/gofpdf"
 )
 
 type TransactionService interface {
-	Transfer(ctx context.Context, fromID, toID string, amount float64, currency, description, category string) (*Transaction, error)
+	Transfer(ctx context.Context, in TransferInput) (*Transaction, error)
+	// Deposit credits the user's account with money collected from an external source.
+	Deposit(ctx context.Context, in FundingInput) (*Transaction, error)
+	// CreditCollectedDeposits credits the deposits collected before the given time whose
+	// credit failed, and returns how many it credited.
+	CreditCollectedDeposits(ctx context.Context, before time.Time) (int, error)
+	// Withdraw debits the user's account and pays the money out to an external destination.
+	Withdraw(ctx context.Context, in FundingInput) (*Transaction, error)
+	// Reverse refunds a completed transfer, fully or in part, with a linked reversal.
+	Reverse(ctx context.Context, in ReversalInput) (*Transaction, error)
+	// ListReviews returns the fraud reviews in the given status with their transfers.
+	ListReviews(ctx context.Context, status ReviewStatus) ([]FraudReview, error)
+	// ApproveHeld releases a transfer held by fraud screening and executes it.
+	ApproveHeld(ctx context.Context, d ReviewDecision) (*Transaction, error)
+	// RejectHeld fails a transfer held by fraud screening.
+	RejectHeld(ctx context.Context, d ReviewDecision) (*Transaction, error)
 	GetByAccount(ctx context.Context, accountID string, filter TransactionFilter) ([]Transaction, error)
-	Transfer(ctx context.Context, fromID, toID string, amount float64, currency string) (*Transaction, error)
-	// GetByUser retrieves transactions for the account associated with the given user.
-	GetByUser(ctx context.Context, userID string) ([]Transaction, error)
-	GenerateStatementCSV(transactions []Transaction, filePath string) error
-	GenerateStatementPDF(transactions []Transaction, filePath string) error
+	// GetHistory returns a page of at most filter.Limit transactions of the user's account
+	// with the given ID, or of the user's default account, with a cursor to the next page
+	// when there is one.
+	GetHistory(ctx context.Context, userID, accountID string, filter TransactionFilter) (*HistoryPage, error)
+	// GetByUser retrieves the transactions of the user's account with the given ID, or of
+	// the user's default account.
+	GetByUser(ctx context.Context, userID, accountID string, filter TransactionFilter) ([]Transaction, error)
+	// GetStatement returns the settled transactions of the user's account over the period,
+	// with the balances it opened and closed the period with.
+	GetStatement(ctx context.Context, userID, accountID string, period StatementPeriod) (*Statement, error)
+	GenerateStatementCSV(w io.Writer, statement *Statement, opts csvwriter.Options) error
+	GenerateStatementPDF(w io.Writer, statement *Statement) error
+	GenerateStatementOFX(w io.Writer, statement *Statement) error
+	GenerateStatementQFX(w io.Writer, statement *Statement) error
+	// GenerateStatementCAMT053 renders the statement as an ISO 20022 camt.053 message.
+	GenerateStatementCAMT053(w io.Writer, statement *Statement) error
 }
 
 type transactionService struct {
-	repo      TransactionRepository
-	publisher AccountTransferPublisher
-	reader    AccountReader
+	repo        TransactionRepository
+	publisher   AccountTransferPublisher
+	reader      AccountReader
+	idempotency IdempotencyRepository
+	rates       fx.Provider
+	funding     FundingSource
+	limits      FundingLimits
+	// transferLimits caps transfers per account. Nil refuses every transfer.
+	transferLimits LimitPolicy
+	// screener screens transfers for fraud. Nil allows every transfer.
+	screener Screener
+	reviews  ReviewRepository
 }
 
+var ErrUnsupportedTransferCurrency = errors.New("currency must match the source or destination account")
+
+// ErrAccountNotFound is returned when the user has no account with the requested ID.
+var ErrAccountNotFound = errors.New("account not found")
+
+var (
+	ErrBeneficiaryNotFound = errors.New("beneficiary not found")
+	// ErrDestinationNotFound covers accounts that do not exist and accounts owned by the
+	// bank, which cannot receive transfers.
+	ErrDestinationNotFound = errors.New("destination account not found")
+	// ErrDestinationUnavailable is returned for frozen or closed destination accounts.
+	ErrDestinationUnavailable = errors.New("destination account cannot receive transfers")
+)
+
 // GetByAccount implements TransactionService.
 func (s *transactionService) GetByAccount(ctx context.Context, accountID string, filter TransactionFilter) ([]Transaction, error) {
 	return s.repo.GetByAccount(ctx, accountID, filter)
 
 }
 
+// HistoryPage is a page of an account's transaction history.
+type HistoryPage struct {
+	AccountID    string        `json:"account_id"`
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
+
+// GetHistory implements TransactionService. One row past the page is fetched to tell
+// whether another page follows.
+func (s *transactionService) GetHistory(ctx context.Context, userID, accountID string, filter TransactionFilter) (*HistoryPage, error) {
+	account, err := s.accountForUser(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Limit <= 0 || filter.Limit > MaxPageSize {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Sort == "" {
+		filter.Sort = SortNewest
+	}
+	pageSize := filter.Limit
+	filter.Limit++
+
+	transactions, err := s.repo.GetByAccount(ctx, account.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &HistoryPage{AccountID: account.ID, Transactions: transactions}
+	if len(transactions) > pageSize {
+		page.Transactions = transactions[:pageSize]
+		last := page.Transactions[pageSize-1]
+		cursor := Cursor{Sort: filter.Sort, ID: last.ID}
+		switch filter.Sort {
+		case SortLargestAmount, SortSmallestAmount:
+			cursor.Amount = accountAmount(&last, account.ID)
+		default:
+			cursor.CreatedAt = last.CreatedAt
+		}
+		page.NextCursor = cursor.Encode()
+	}
+	if page.Transactions == nil {
+		page.Transactions = []Transaction{}
+	}
+	return page, nil
+}
+
+// accountAmount is the amount of tx that moved in or out of the account, in its currency.
+func accountAmount(tx *Transaction, accountID string) money.Amount {
+	if tx.FromAccountID == accountID {
+		return tx.Amount
+	}
+	return tx.DestinationAmount
+}
+
 // GetByUser implements TransactionService.
-func (s *transactionService) GetByUser(ctx context.Context, userID string) ([]Transaction, error) {
-	acc, err := s.reader.GetAccountByUserID(ctx, userID)
-	if err != nil || acc == nil {
-		return nil, errors.New("account not found")
+func (s *transactionService) GetByUser(ctx context.Context, userID, accountID string, filter TransactionFilter) ([]Transaction, error) {
+	account, err := s.accountForUser(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
 	}
-	return s.repo.GetByAccount(ctx, acc.ID)
+	return s.repo.GetByAccount(ctx, account.ID, filter)
+}
 
+// accountForUser resolves the user's account with the given ID, or the user's default
+// account when accountID is empty.
+func (s *transactionService) accountForUser(ctx context.Context, userID, accountID string) (*AccountInfo, error) {
+	account, err := s.reader.GetAccountForUser(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+	return account, nil
 }
 
-// Transfer implements TransactionService.
-func (s *transactionService) Transfer(ctx context.Context, fromID string, toID string, amount float64, currency, description, category string) (*Transaction, error) {
-	if fromID == toID {
-		return nil, errors.New("cannot transfer to the same account")
+// Transfer implements TransactionService. When the input carries an idempotency key, a
+// repeated request returns the transaction created by the first one instead of moving
+// money again.
+func (s *transactionService) Transfer(ctx context.Context, in TransferInput) (*Transaction, error) {
+	return s.idempotent(ctx, in.UserID, in.IdempotencyKey, in.fingerprint(), func() (*Transaction, error) {
+		return s.transfer(ctx, in)
+	})
+}
+
+// Deposit implements TransactionService. A retry of a deposit that was collected but not
+// credited credits it without collecting the money again.
+func (s *transactionService) Deposit(ctx context.Context, in FundingInput) (*Transaction, error) {
+	tx, err := s.idempotent(ctx, in.UserID, in.IdempotencyKey, in.fingerprint(TransactionTypeDeposit), func() (*Transaction, error) {
+		return s.fund(ctx, TransactionTypeDeposit, in)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tx != nil && tx.Type == TransactionTypeDeposit && tx.Status == TransactionStatusProcessing {
+		return s.credit(context.WithoutCancel(ctx), tx)
 	}
+	return tx, nil
+}
 
-	if amount <= 0 {
+// Withdraw implements TransactionService.
+func (s *transactionService) Withdraw(ctx context.Context, in FundingInput) (*Transaction, error) {
+	return s.idempotent(ctx, in.UserID, in.IdempotencyKey, in.fingerprint(TransactionTypeWithdrawal), func() (*Transaction, error) {
+		return s.fund(ctx, TransactionTypeWithdrawal, in)
+	})
+}
+
+// idempotent runs the operation once per idempotency key. Without a key it simply runs it.
+func (s *transactionService) idempotent(ctx context.Context, userID, key, fingerprint string, run func() (*Transaction, error)) (*Transaction, error) {
+	if key == "" {
+		return run()
+	}
+
+	record, reserved, err := s.idempotency.Reserve(ctx, userID, key, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	if !reserved {
+		if record.Fingerprint != fingerprint {
+			return nil, ErrIdempotencyKeyReused
+		}
+		if record.TransactionID == "" {
+			return nil, ErrIdempotencyKeyInProgress
+		}
+		return s.repo.GetByID(ctx, record.TransactionID)
+	}
+
+	tx, err := run()
+	if err != nil {
+		// A cancelled request may still be applied by the balance worker, so the key
+		// stays reserved until it completes or expires.
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+		if releaseErr := s.idempotency.Release(ctx, userID, key); releaseErr != nil {
+			log.Printf("❌ Failed to release idempotency key %s: %v", key, releaseErr)
+		}
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+func (s *transactionService) transfer(ctx context.Context, in TransferInput) (*Transaction, error) {
+	if !in.Amount.IsPositive() {
 		return nil, errors.New("amount must be greater than zero")
 	}
 
-	account, err := s.reader.GetAccountByUserID(ctx, fromID)
-	if err != nil || account == nil {
-		return nil, errors.New("origin account not found")
+	account, err := s.accountForUser(ctx, in.UserID, in.FromAccountID)
+	if err != nil {
+		return nil, err
 	}
 
-	errChan := make(chan error)
-	cmd := UpdateAccountBalanceCommand{
-		FromAccountID: account.ID,
-		ToAccountID:   toID,
-		Amount:        amount,
-		ErrChan:       errChan,
+	toAccountID := in.ToAccountID
+	if in.BeneficiaryID != "" {
+		beneficiary, err := s.reader.GetBeneficiaryAccount(ctx, in.UserID, in.BeneficiaryID)
+		if err != nil {
+			return nil, err
+		}
+		if beneficiary == nil {
+			return nil, ErrBeneficiaryNotFound
+		}
+		if toAccountID != "" && toAccountID != beneficiary.ID {
+			return nil, errors.New("to_account_id does not match the beneficiary")
+		}
+		toAccountID = beneficiary.ID
 	}
 
-	if err := s.publisher.PublishTransfer(cmd); err != nil {
+	if account.ID == toAccountID {
+		return nil, errors.New("cannot transfer to the same account")
+	}
+
+	destination, err := lookupDestination(ctx, s.reader, toAccountID)
+	if err != nil {
 		return nil, err
 	}
 
-	if err := <-errChan; err != nil {
+	rate, err := s.rate(ctx, account.Currency, destination.Currency)
+	if err != nil {
 		return nil, err
 	}
 
+	debit, credit, err := convertTransferAmount(in.Amount, in.Currency, rate)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := s.checkTransferLimits(ctx, account, debit)
+	if err != nil {
+		return nil, err
+	}
+
+	decision, err := s.screen(ctx, ScreeningRequest{Account: *account, ToAccountID: destination.ID, Amount: debit, Limits: limits})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
 	tx := &Transaction{
-		ID:            uuid.New().String(),
+		ID:                  uuid.New().String(),
+		Type:                TransactionTypeTransfer,
+		Status:              TransactionStatusPending,
+		FromAccountID:       account.ID,
+		ToAccountID:         destination.ID,
+		Amount:              debit,
+		Currency:            account.Currency,
+		DestinationAmount:   credit,
+		DestinationCurrency: destination.Currency,
+		ExchangeRate:        rate.String(),
+		Description:         in.Description,
+		Category:            in.Category,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+
+	switch decision.Action {
+	case ScreeningBlock:
+		log.Printf("🚫 Transfer %s blocked by fraud rules %v", tx.ID, decision.Rules)
+		tx.Status, tx.FailureReason = TransactionStatusFailed, "blocked by fraud screening: "+joinRules(decision.Rules)
+		if err := s.repo.Create(ctx, tx); err != nil {
+			return nil, err
+		}
+		return nil, ErrTransferBlocked
+	case ScreeningHold:
+		log.Printf("✋ Transfer %s held for review by fraud rules %v", tx.ID, decision.Rules)
+		if err := s.hold(ctx, tx, decision.Rules, in.UserID, in.IdempotencyKey); err != nil {
+			return nil, err
+		}
+		return tx, nil
+	}
+
+	err = s.execute(ctx, tx, UpdateAccountBalanceCommand{
 		FromAccountID: account.ID,
-		ToAccountID:   toID,
-		Amount:        amount,
-		Currency:      currency,
-		Description:   description,
-		Category:      category,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		ToAccountID:   destination.ID,
+		Amount:        debit,
+		CreditAmount:  credit,
+		Reference:     tx.ID,
+		Record:        s.limitTransfer(tx, account, limits, s.completeTransaction(tx, in.UserID, in.IdempotencyKey)),
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	return tx, nil
+}
+
+// fund moves money between the user's account and the clearing account of its currency,
+// and between the bank and the funding source.
+func (s *transactionService) fund(ctx context.Context, txType TransactionType, in FundingInput) (*Transaction, error) {
+	if s.funding == nil {
+		return nil, ErrFundingUnavailable
+	}
+	if !in.Amount.IsPositive() {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	if in.Source == "" {
+		return nil, errors.New("funding source is required")
+	}
+	if err := s.limits.check(txType, in.Amount); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountForUser(ctx, in.UserID, in.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if in.Currency != "" && in.Currency != account.Currency {
+		return nil, fmt.Errorf("currency must match the account currency %s", account.Currency)
+	}
+
+	clearing, err := s.reader.GetClearingAccount(ctx, account.Currency)
+	if err != nil || clearing == nil {
+		return nil, fmt.Errorf("clearing account for %s not available: %v", account.Currency, err)
+	}
+
+	from, to := clearing.ID, account.ID
+	if txType == TransactionTypeWithdrawal {
+		from, to = account.ID, clearing.ID
+	}
+
+	now := time.Now()
+	tx := &Transaction{
+		ID:                  uuid.New().String(),
+		Type:                txType,
+		Status:              TransactionStatusPending,
+		FromAccountID:       from,
+		ToAccountID:         to,
+		Amount:              in.Amount,
+		Currency:            account.Currency,
+		DestinationAmount:   in.Amount,
+		DestinationCurrency: account.Currency,
+		ExchangeRate:        fx.Identity(account.Currency).String(),
+		Description:         in.Description,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
 	if err := s.repo.Create(ctx, tx); err != nil {
 		return nil, err
 	}
 
+	// From here on money moves outside the bank, so a client hanging up must not leave
+	// the operation half done.
+	ctx = context.WithoutCancel(ctx)
+	req := FundingRequest{
+		TransactionID: tx.ID,
+		AccountID:     account.ID,
+		Amount:        in.Amount,
+		Currency:      account.Currency,
+		Source:        in.Source,
+	}
+	if txType == TransactionTypeWithdrawal {
+		return s.withdraw(ctx, tx, req, in.UserID, in.IdempotencyKey)
+	}
+	return s.deposit(ctx, tx, req, in.UserID, in.IdempotencyKey)
+}
+
+// deposit settles a deposit in two phases. The idempotency key is linked to tx before
+// anything is collected, so a retry of the request never collects the money again. Once
+// the funding source has collected it, tx is left processing with its reference and the
+// account is credited in a balance update; the funding source is never called while the
+// balance update holds its row locks. A deposit whose credit fails stays processing and
+// is credited by a retry of the request or by CreditCollectedDeposits.
+func (s *transactionService) deposit(ctx context.Context, tx *Transaction, req FundingRequest, userID, idempotencyKey string) (*Transaction, error) {
+	if idempotencyKey != "" {
+		if err := s.idempotency.Complete(ctx, userID, idempotencyKey, tx.ID); err != nil {
+			s.fail(tx, err)
+			return nil, err
+		}
+	}
+
+	ref, err := s.funding.Collect(ctx, req)
+	if err != nil {
+		s.fail(tx, err)
+		return nil, err
+	}
+
+	tx.ExternalReference = ref
+	if err := setStatus(ctx, s.repo, tx, TransactionStatusProcessing, ""); err != nil {
+		log.Printf("❌ Deposit %s was collected as %s but not stored as processing: %v", tx.ID, ref, err)
+		return nil, err
+	}
+	return s.credit(ctx, tx)
+}
+
+// credit moves a collected deposit from the clearing account to the user's account and
+// completes it. A failed credit leaves the deposit processing to be credited again; the
+// status update only succeeds from processing, so a deposit is never credited twice.
+func (s *transactionService) credit(ctx context.Context, tx *Transaction) (*Transaction, error) {
+	err := s.await(ctx, UpdateAccountBalanceCommand{
+		FromAccountID: tx.FromAccountID,
+		ToAccountID:   tx.ToAccountID,
+		Amount:        tx.Amount,
+		CreditAmount:  tx.Amount,
+		Reference:     tx.ID,
+		// The idempotency key was linked before the deposit was collected.
+		Record: s.completeTransaction(tx, "", ""),
+	}, nil)
+	if err != nil {
+		// Another attempt may have credited it in the meantime.
+		if stored, getErr := s.repo.GetByID(ctx, tx.ID); getErr == nil && stored != nil && stored.Status == TransactionStatusCompleted {
+			return stored, nil
+		}
+		log.Printf("⚠️ Deposit %s was collected as %s but not credited yet, it stays processing: %v", tx.ID, tx.ExternalReference, err)
+		return nil, err
+	}
 	return tx, nil
 }
 
-func (s *transactionService) GenerateStatementCSV(transactions []Transaction, filePath string) error {
-	w := csvwriter.NewCSVWriter([]string{
-		"Transaction ID",
-		"From Account",
-		"To Account",
-		"Amount",
-		"Currency",
-		"Date",
-	})
+// CreditCollectedDeposits implements TransactionService.
+func (s *transactionService) CreditCollectedDeposits(ctx context.Context, before time.Time) (int, error) {
+	deposits, err := s.repo.GetCollectedDeposits(ctx, before)
+	if err != nil {
+		return 0, err
+	}
 
-	for _, t := range transactions {
-		row := []string{
-			t.ID,
-			t.FromAccountID,
-			t.ToAccountID,
-			strconv.FormatFloat(t.Amount, 'f', 2, 64),
-			t.Currency,
-			t.CreatedAt.Format("2006-01-02 15:04:05"),
+	credited := 0
+	for i := range deposits {
+		if _, err := s.credit(ctx, &deposits[i]); err != nil {
+			continue
 		}
+		credited++
+	}
+	return credited, nil
+}
 
-		if err := w.AddRow(row); err != nil {
-			return err
+// withdraw settles a withdrawal in two phases. The account is first debited into the
+// clearing account and the transaction left processing; the payout is then requested
+// outside the balance update, and the withdrawal completed, or refunded when the funding
+// source declines it. A payout with an unknown outcome stays processing for
+// reconciliation against the funding source.
+func (s *transactionService) withdraw(ctx context.Context, tx *Transaction, req FundingRequest, userID, idempotencyKey string) (*Transaction, error) {
+	err := s.dispatch(ctx, tx, UpdateAccountBalanceCommand{
+		FromAccountID: tx.FromAccountID,
+		ToAccountID:   tx.ToAccountID,
+		Amount:        tx.Amount,
+		CreditAmount:  tx.Amount,
+		Reference:     tx.ID,
+		Record:        s.recordTransaction(tx, TransactionStatusProcessing, userID, idempotencyKey),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := s.funding.Payout(ctx, req)
+	switch {
+	case errors.Is(err, ErrFundingDeclined):
+		if refundErr := s.refund(ctx, tx, err); refundErr != nil {
+			log.Printf("❌ Withdrawal %s was declined but not refunded: %v", tx.ID, refundErr)
+			return nil, fmt.Errorf("%w, the refund is pending", err)
 		}
+		return nil, err
+	case err != nil:
+		log.Printf("⚠️ Payout of withdrawal %s has an unknown outcome, it stays processing: %v", tx.ID, err)
+		return tx, nil
+	}
+
+	tx.ExternalReference = ref
+	if err := setStatus(ctx, s.repo, tx, TransactionStatusCompleted, ""); err != nil {
+		log.Printf("❌ Withdrawal %s was paid out as %s but not completed: %v", tx.ID, ref, err)
+	}
+	return tx, nil
+}
+
+// refund returns a declined withdrawal from the clearing account to the user's account
+// and marks it failed in the same database transaction.
+func (s *transactionService) refund(ctx context.Context, tx *Transaction, reason error) error {
+	errChan := make(chan error, 1)
+	err := s.publisher.PublishTransfer(ctx, UpdateAccountBalanceCommand{
+		FromAccountID: tx.ToAccountID,
+		ToAccountID:   tx.FromAccountID,
+		Amount:        tx.Amount,
+		CreditAmount:  tx.Amount,
+		Reference:     tx.ID,
+		Record: func(ctx context.Context, exec database.Executor) error {
+			return setStatus(ctx, s.repo.WithTx(exec), tx, TransactionStatusFailed, reason.Error())
+		},
+		ErrChan: errChan,
+	})
+	if err != nil {
+		return err
 	}
+	return <-errChan
+}
+
+// execute records tx as pending, hands the balance update to the accounts package and
+// waits for its result. Failures are persisted on the transaction with their reason.
+func (s *transactionService) execute(ctx context.Context, tx *Transaction, cmd UpdateAccountBalanceCommand) error {
+	if err := s.repo.Create(ctx, tx); err != nil {
+		return err
+	}
+	return s.dispatch(ctx, tx, cmd)
+}
 
-	return w.WriteToFile(filePath)
+// dispatch hands the balance update of a transaction already stored as pending to the
+// accounts package and waits for its result. Failures are persisted on tx.
+func (s *transactionService) dispatch(ctx context.Context, tx *Transaction, cmd UpdateAccountBalanceCommand) error {
+	return s.await(ctx, cmd, func(err error) { s.fail(tx, err) })
 }
 
-func (s *transactionService) GenerateStatementPDF(transactions []Transaction, filePath string) error {
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.AddPage()
-	pdf.SetFont("Arial", "", 12)
+// await hands the balance update to the accounts package and waits for its result. A
+// failure is passed to failed, when set, even if it is only known after ctx is done.
+func (s *transactionService) await(ctx context.Context, cmd UpdateAccountBalanceCommand, failed func(err error)) error {
+	errChan := make(chan error, 1)
+	cmd.ErrChan = errChan
 
-	headers := []string{"Transaction ID", "From", "To", "Amount", "Currency", "Date"}
-	for _, h := range headers {
-		pdf.CellFormat(32, 10, h, "1", 0, "", false, 0, "")
+	if err := s.publisher.PublishTransfer(ctx, cmd); err != nil {
+		if failed != nil {
+			failed(err)
+		}
+		return err
 	}
-	pdf.Ln(-1)
 
-	for _, t := range transactions {
-		row := []string{
-			t.ID,
-			t.FromAccountID,
-			t.ToAccountID,
-			strconv.FormatFloat(t.Amount, 'f', 2, 64),
-			t.Currency,
-			t.CreatedAt.Format("2006-01-02 15:04:05"),
+	select {
+	case err := <-errChan:
+		if err != nil && failed != nil {
+			failed(err)
 		}
-		for _, col := range row {
-			pdf.CellFormat(32, 10, col, "1", 0, "", false, 0, "")
+		return err
+	case <-ctx.Done():
+		// The worker may still apply the update, so the outcome is recorded once known.
+		if failed != nil {
+			go func() {
+				if err := <-errChan; err != nil {
+					failed(err)
+				}
+			}()
 		}
-		pdf.Ln(-1)
+		return ctx.Err()
+	}
+}
+
+// fail marks tx as failed with the reason of err. A failed balance update rolls back
+// everything its unit of work wrote, so tx may be ahead of its row: the transition starts
+// from the status stored in the row.
+func (s *transactionService) fail(tx *Transaction, err error) {
+	ctx := context.Background()
+	stored, getErr := s.repo.GetByID(ctx, tx.ID)
+	if getErr == nil && stored == nil {
+		getErr = ErrTransactionNotFound
+	}
+	if getErr != nil {
+		log.Printf("❌ Failed to mark transaction %s as failed: %v", tx.ID, getErr)
+		return
 	}
 
-	return pdf.OutputFileAndClose(filePath)
+	tx.Status = stored.Status
+	if statusErr := setStatus(ctx, s.repo, tx, TransactionStatusFailed, err.Error()); statusErr != nil {
+		log.Printf("❌ Failed to mark transaction %s as failed: %v", tx.ID, statusErr)
+	}
 }
 
-func NewTransactionService(repo TransactionRepository, publisher AccountTransferPublisher, reader AccountReader) TransactionService {
-	return &transactionService{repo: repo, publisher: publisher, reader: reader}
+// lookupDestination returns the account a transfer is sent to, once it is known to exist
+// and to be able to receive money. Checking before anything is queued keeps a mistyped
+// ID from failing deep in the balance update.
+func lookupDestination(ctx context.Context, reader AccountReader, accountID string) (*AccountInfo, error) {
+	destination, err := reader.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if destination == nil || destination.UserID == "" {
+		return nil, ErrDestinationNotFound
+	}
+	if destination.Status != "active" {
+		return nil, ErrDestinationUnavailable
+	}
+	return destination, nil
+}
+
+// rate returns the exchange rate from the source to the destination currency. Transfers
+// in a single currency do not need a provider.
+func (s *transactionService) rate(ctx context.Context, from, to money.Currency) (fx.Rate, error) {
+	if from == to {
+		return fx.Identity(from), nil
+	}
+	if s.rates == nil {
+		return fx.Rate{}, fmt.Errorf("%w: %s/%s", fx.ErrRateNotFound, from, to)
+	}
+	return s.rates.Rate(ctx, from, to)
+}
+
+// convertTransferAmount returns the amounts debited and credited by a transfer. The
+// requested amount is in the source currency unless currency names the destination one.
+func convertTransferAmount(amount money.Amount, currency money.Currency, rate fx.Rate) (debit, credit money.Amount, err error) {
+	switch currency {
+	case "", rate.From:
+		debit = amount
+		credit, err = rate.Convert(amount)
+	case rate.To:
+		credit = amount
+		debit, err = rate.Invert().Convert(amount)
+	default:
+		return 0, 0, ErrUnsupportedTransferCurrency
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !debit.IsPositive() || !credit.IsPositive() {
+		return 0, 0, errors.New("amount is too small to convert")
+	}
+	return debit, credit, nil
+}
+
+// completeTransaction returns the unit of work that the accounts package runs inside the
+// balance update: the transaction is completed and the idempotency key linked to it in
+// the same database transaction as the money movement.
+func (s *transactionService) completeTransaction(tx *Transaction, userID, idempotencyKey string) func(ctx context.Context, exec database.Executor) error {
+	return s.recordTransaction(tx, TransactionStatusCompleted, userID, idempotencyKey)
+}
+
+// recordTransaction is completeTransaction for balance updates that leave tx in another
+// status, such as a withdrawal still waiting for its payout.
+func (s *transactionService) recordTransaction(tx *Transaction, next TransactionStatus, userID, idempotencyKey string) func(ctx context.Context, exec database.Executor) error {
+	return func(ctx context.Context, exec database.Executor) error {
+		if err := setStatus(ctx, s.repo.WithTx(exec), tx, next, ""); err != nil {
+			return err
+		}
+
+		if idempotencyKey != "" {
+			return s.idempotency.WithTx(exec).Complete(ctx, userID, idempotencyKey, tx.ID)
+		}
+		return nil
+	}
+}
+
+func NewTransactionService(repo TransactionRepository, publisher AccountTransferPublisher, reader AccountReader, idempotency IdempotencyRepository, rates fx.Provider, funding FundingSource, limits FundingLimits, transferLimits LimitPolicy, screener Screener, reviews ReviewRepository) TransactionService {
+	return &transactionService{
+		repo:           repo,
+		publisher:      publisher,
+		reader:         reader,
+		idempotency:    idempotency,
+		rates:          rates,
+		funding:        funding,
+		limits:         limits,
+		transferLimits: transferLimits,
+		screener:       screener,
+		reviews:        reviews,
+	}
 }
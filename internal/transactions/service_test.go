@@ -2,36 +2,213 @@ package transactions
 
 import (
 	"context"
+	"errors"
+	"go-bank-app/pkg/database"
+	"go-bank-app/pkg/fx"
+	"go-bank-app/pkg/money"
+	"math/big"
 	"reflect"
 	"testing"
+	"time"
 )
 
 type mockRepo struct {
 	gotAccountID string
+	gotFilter    TransactionFilter
 	transactions []Transaction
+	// byAccount, when set, is the history GetByAccount returns for each account.
+	byAccount map[string][]Transaction
+	// posted holds the ledger postings of each account, for GetStatementLines.
+	posted    map[string][]StatementLine
+	gotPeriod StatementPeriod
+	created   []*Transaction
+	createErr error
+	// statuses records every status a transaction was moved to, in order.
+	statuses  map[string][]TransactionStatus
+	updateErr error
+	// updated, when set, receives the ID of every transaction whose status was stored.
+	updated chan string
 }
 
-func (m *mockRepo) Create(ctx context.Context, tx *Transaction) error { return nil }
-func (m *mockRepo) GetByAccount(ctx context.Context, accountID string) ([]Transaction, error) {
+func (m *mockRepo) WithTx(exec database.Executor) TransactionRepository { return m }
+
+func (m *mockRepo) Create(ctx context.Context, tx *Transaction) error {
+	if m.createErr != nil {
+		return m.createErr
+	}
+	m.created = append(m.created, tx)
+	return nil
+}
+
+func (m *mockRepo) UpdateStatus(ctx context.Context, tx *Transaction, from TransactionStatus) error {
+	if m.updateErr != nil {
+		return m.updateErr
+	}
+	if m.statuses == nil {
+		m.statuses = map[string][]TransactionStatus{}
+	}
+	m.statuses[tx.ID] = append(m.statuses[tx.ID], tx.Status)
+	if m.updated != nil {
+		m.updated <- tx.ID
+	}
+	return nil
+}
+
+func (m *mockRepo) GetByID(ctx context.Context, id string) (*Transaction, error) {
+	for _, tx := range m.created {
+		if tx.ID == id {
+			return tx, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockRepo) GetByIDForUpdate(ctx context.Context, id string) (*Transaction, error) {
+	return m.GetByID(ctx, id)
+}
+
+func (m *mockRepo) GetReversedAmounts(ctx context.Context, originalID string) (refunded, debited money.Amount, err error) {
+	for _, tx := range m.created {
+		if tx.OriginalTransactionID == originalID && tx.Status == TransactionStatusCompleted {
+			refunded, debited = refunded.Add(tx.DestinationAmount), debited.Add(tx.Amount)
+		}
+	}
+	return refunded, debited, nil
+}
+
+func (m *mockRepo) GetByAccount(ctx context.Context, accountID string, filter TransactionFilter) ([]Transaction, error) {
 	m.gotAccountID = accountID
+	m.gotFilter = filter
+	if m.byAccount != nil {
+		return m.byAccount[accountID], nil
+	}
 	return m.transactions, nil
 }
 
+func (m *mockRepo) GetCollectedDeposits(ctx context.Context, before time.Time) ([]Transaction, error) {
+	var deposits []Transaction
+	for _, tx := range m.created {
+		if tx.Type == TransactionTypeDeposit && tx.Status == TransactionStatusProcessing && tx.UpdatedAt.Before(before) {
+			deposits = append(deposits, *tx)
+		}
+	}
+	return deposits, nil
+}
+
+func (m *mockRepo) GetStatementLines(ctx context.Context, accountID string, period StatementPeriod) ([]StatementLine, error) {
+	m.gotAccountID = accountID
+	m.gotPeriod = period
+	var lines []StatementLine
+	for _, line := range m.posted[accountID] {
+		if !line.PostedAt.Before(period.Start) && line.PostedAt.Before(period.End) {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+func (m *mockRepo) SumOutgoingTransfers(ctx context.Context, accountID string, since time.Time) (money.Amount, error) {
+	var total money.Amount
+	for _, tx := range m.created {
+		counted := tx.Status != TransactionStatusFailed && tx.Status != TransactionStatusReversed
+		if tx.FromAccountID == accountID && tx.Type == TransactionTypeTransfer && counted && !tx.CreatedAt.Before(since) {
+			total = total.Add(tx.Amount)
+		}
+	}
+	return total, nil
+}
+
+func (m *mockRepo) SumSettledTransfers(ctx context.Context, accountID string, since time.Time) (money.Amount, error) {
+	var total money.Amount
+	for _, tx := range m.created {
+		if tx.FromAccountID == accountID && tx.Type == TransactionTypeTransfer && tx.Status == TransactionStatusCompleted && !tx.CreatedAt.Before(since) {
+			total = total.Add(tx.Amount)
+		}
+	}
+	return total, nil
+}
+
+func (m *mockRepo) CountOutgoingTransfers(ctx context.Context, accountID string, since time.Time) (int, error) {
+	count := 0
+	for _, tx := range m.created {
+		if tx.FromAccountID == accountID && tx.Type == TransactionTypeTransfer && tx.Status != TransactionStatusFailed && !tx.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockRepo) HasTransferredTo(ctx context.Context, fromAccountID, toAccountID string) (bool, error) {
+	for _, tx := range m.created {
+		if tx.FromAccountID == fromAccountID && tx.ToAccountID == toAccountID && tx.Status == TransactionStatusCompleted {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 type mockReader struct {
-	acc *AccountInfo
-	err error
+	acc          *AccountInfo
+	err          error
+	gotAccountID string
+	// destinations are returned by GetAccountByID. Unknown IDs resolve to an account in
+	// the currency of acc.
+	destinations  map[string]*AccountInfo
+	beneficiaries map[string]*AccountInfo
+	// balances are returned by GetBalanceAt, keyed by Unix time.
+	balances map[int64]money.Amount
+	holders  map[string]string
 }
 
-func (m *mockReader) GetAccountByUserID(ctx context.Context, userID string) (*AccountInfo, error) {
+func (m *mockReader) GetAccountForUser(ctx context.Context, userID, accountID string) (*AccountInfo, error) {
+	m.gotAccountID = accountID
 	return m.acc, m.err
 }
 
+func (m *mockReader) GetAccountByID(ctx context.Context, accountID string) (*AccountInfo, error) {
+	if acc, ok := m.destinations[accountID]; ok {
+		return acc, nil
+	}
+	info := &AccountInfo{ID: accountID, UserID: "someone", Status: "active"}
+	if m.acc != nil {
+		info.Currency = m.acc.Currency
+	}
+	return info, nil
+}
+
+func (m *mockReader) GetBeneficiaryAccount(ctx context.Context, userID, beneficiaryID string) (*AccountInfo, error) {
+	return m.beneficiaries[beneficiaryID], nil
+}
+
+func (m *mockReader) GetBalanceAt(ctx context.Context, accountID string, at time.Time) (money.Amount, error) {
+	return m.balances[at.Unix()], nil
+}
+
+func (m *mockReader) GetHolderName(ctx context.Context, userID string) (string, error) {
+	return m.holders[userID], nil
+}
+
+func (m *mockReader) GetClearingAccount(ctx context.Context, currency money.Currency) (*AccountInfo, error) {
+	return &AccountInfo{ID: "clearing-" + string(currency), Currency: currency}, nil
+}
+
+// fixedRates quotes every pair from a map keyed by "FROM/TO".
+type fixedRates map[string]*big.Rat
+
+func (f fixedRates) Rate(ctx context.Context, from, to money.Currency) (fx.Rate, error) {
+	value, ok := f[string(from)+"/"+string(to)]
+	if !ok {
+		return fx.Rate{}, fx.ErrRateNotFound
+	}
+	return fx.Rate{From: from, To: to, Value: value}, nil
+}
+
 func TestTransactionService_GetByUser(t *testing.T) {
 	repo := &mockRepo{transactions: []Transaction{{ID: "tx1"}}}
-	reader := &mockReader{acc: &AccountInfo{ID: "acc123"}}
-	svc := NewTransactionService(repo, nil, reader)
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	svc := NewTransactionService(repo, nil, reader, nil, nil, nil, FundingLimits{}, nil, nil, nil)
 
-	txs, err := svc.GetByUser(context.Background(), "user1")
+	txs, err := svc.GetByUser(context.Background(), "user1", "", TransactionFilter{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -46,10 +223,725 @@ func TestTransactionService_GetByUser(t *testing.T) {
 func TestTransactionService_GetByUser_NoAccount(t *testing.T) {
 	repo := &mockRepo{}
 	reader := &mockReader{acc: nil}
-	svc := NewTransactionService(repo, nil, reader)
+	svc := NewTransactionService(repo, nil, reader, nil, nil, nil, FundingLimits{}, nil, nil, nil)
 
-	_, err := svc.GetByUser(context.Background(), "user1")
-	if err == nil {
+	_, err := svc.GetByUser(context.Background(), "user1", "", TransactionFilter{})
+	if !errors.Is(err, ErrAccountNotFound) {
+		t.Fatalf("expected ErrAccountNotFound, got %v", err)
+	}
+}
+
+// userAccounts resolves accounts by owner, like the accounts service: the default
+// account when no ID is given, and nothing for accounts of other users.
+type userAccounts struct {
+	mockReader
+	accounts []AccountInfo
+	defaults map[string]string
+}
+
+func (u *userAccounts) GetAccountForUser(ctx context.Context, userID, accountID string) (*AccountInfo, error) {
+	if accountID == "" {
+		accountID = u.defaults[userID]
+	}
+	for i := range u.accounts {
+		if u.accounts[i].ID == accountID && u.accounts[i].UserID == userID {
+			return &u.accounts[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func TestTransactionService_GetHistory_ResolvesAccount(t *testing.T) {
+	reader := &userAccounts{
+		accounts: []AccountInfo{
+			{ID: "checking", UserID: "user1"},
+			{ID: "savings", UserID: "user1"},
+			{ID: "other", UserID: "user2"},
+		},
+		defaults: map[string]string{"user1": "checking", "user2": "other"},
+	}
+	repo := &mockRepo{byAccount: map[string][]Transaction{
+		"checking": {{ID: "tx-checking"}},
+		"savings":  {{ID: "tx-savings"}},
+		"other":    {{ID: "tx-other"}},
+	}}
+	svc := NewTransactionService(repo, nil, reader, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+
+	tests := []struct {
+		name      string
+		accountID string
+		want      string
+		wantErr   error
+	}{
+		{name: "default account", want: "checking"},
+		{name: "chosen account", accountID: "savings", want: "savings"},
+		{name: "someone else's account", accountID: "other", wantErr: ErrAccountNotFound},
+		{name: "unknown account", accountID: "missing", wantErr: ErrAccountNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := svc.GetHistory(context.Background(), "user1", tt.accountID, TransactionFilter{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if page.AccountID != tt.want {
+				t.Errorf("expected account %s, got %s", tt.want, page.AccountID)
+			}
+			if len(page.Transactions) != 1 || page.Transactions[0].ID != "tx-"+tt.want {
+				t.Errorf("expected the rows of %s, got %+v", tt.want, page.Transactions)
+			}
+
+			txs, err := svc.GetByUser(context.Background(), "user1", tt.accountID, TransactionFilter{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(txs) != 1 || txs[0].ID != "tx-"+tt.want {
+				t.Errorf("expected the statement rows of %s, got %+v", tt.want, txs)
+			}
+		})
+	}
+}
+
+func TestTransactionService_GetHistory_ReaderError(t *testing.T) {
+	readErr := errors.New("database down")
+	svc := NewTransactionService(&mockRepo{}, nil, &mockReader{err: readErr}, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+
+	_, err := svc.GetHistory(context.Background(), "user1", "", TransactionFilter{})
+	if !errors.Is(err, readErr) {
+		t.Errorf("expected the reader error, got %v", err)
+	}
+}
+
+func TestTransactionService_GetHistory_Paginates(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &mockRepo{transactions: []Transaction{
+		{ID: "tx3", CreatedAt: created.Add(2 * time.Hour)},
+		{ID: "tx2", CreatedAt: created.Add(time.Hour)},
+		{ID: "tx1", CreatedAt: created},
+	}}
+	svc := NewTransactionService(repo, nil, &mockReader{acc: &AccountInfo{ID: "acc123"}}, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+
+	page, err := svc.GetHistory(context.Background(), "user1", "", TransactionFilter{Limit: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.gotFilter.Limit != 3 {
+		t.Errorf("expected one row past the page to be fetched, got limit %d", repo.gotFilter.Limit)
+	}
+	if len(page.Transactions) != 2 || page.Transactions[1].ID != "tx2" {
+		t.Fatalf("expected tx3 and tx2, got %+v", page.Transactions)
+	}
+
+	cursor, err := DecodeCursor(page.NextCursor)
+	if err != nil {
+		t.Fatalf("unexpected cursor error: %v", err)
+	}
+	if cursor.ID != "tx2" || cursor.Sort != SortNewest || !cursor.CreatedAt.Equal(created.Add(time.Hour)) {
+		t.Errorf("expected cursor at tx2, got %+v", cursor)
+	}
+}
+
+func TestTransactionService_GetHistory_LastPage(t *testing.T) {
+	repo := &mockRepo{}
+	svc := NewTransactionService(repo, nil, &mockReader{acc: &AccountInfo{ID: "acc123"}}, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+
+	page, err := svc.GetHistory(context.Background(), "user1", "", TransactionFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if page.NextCursor != "" || page.Transactions == nil || len(page.Transactions) != 0 {
+		t.Errorf("expected an empty last page, got %+v", page)
+	}
+	if repo.gotFilter.Limit != DefaultPageSize+1 {
+		t.Errorf("expected default page size, got limit %d", repo.gotFilter.Limit)
+	}
+}
+
+func TestTransactionService_GetHistory_AmountCursor(t *testing.T) {
+	// The cursor carries the amount as seen by the account: what left it for outgoing
+	// transfers and what reached it for incoming ones.
+	repo := &mockRepo{transactions: []Transaction{
+		{ID: "tx1", FromAccountID: "acc123", Amount: money.FromMinor(5000), DestinationAmount: money.FromMinor(250)},
+		{ID: "tx2", ToAccountID: "acc123", Amount: money.FromMinor(100), DestinationAmount: money.FromMinor(2000)},
+		{ID: "tx3", ToAccountID: "acc123", Amount: money.FromMinor(100), DestinationAmount: money.FromMinor(1000)},
+	}}
+	svc := NewTransactionService(repo, nil, &mockReader{acc: &AccountInfo{ID: "acc123"}}, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+
+	for limit, want := range map[int]money.Amount{1: money.FromMinor(5000), 2: money.FromMinor(2000)} {
+		page, err := svc.GetHistory(context.Background(), "user1", "", TransactionFilter{Sort: SortLargestAmount, Limit: limit})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		cursor, err := DecodeCursor(page.NextCursor)
+		if err != nil {
+			t.Fatalf("unexpected cursor error: %v", err)
+		}
+		if cursor.Amount != want || cursor.Sort != SortLargestAmount {
+			t.Errorf("limit %d: expected cursor amount %s, got %+v", limit, want, cursor)
+		}
+	}
+}
+
+type mockPublisher struct {
+	published []UpdateAccountBalanceCommand
+	// err is the outcome reported by the worker; rejectErr refuses the command outright.
+	err       error
+	rejectErr error
+	// release, when set, delays the worker until it is closed.
+	release chan struct{}
+}
+
+// PublishTransfer emulates the balance worker: the unit of work only runs when the
+// balance update succeeds.
+func (m *mockPublisher) PublishTransfer(ctx context.Context, cmd UpdateAccountBalanceCommand) error {
+	if m.rejectErr != nil {
+		return m.rejectErr
+	}
+	m.published = append(m.published, cmd)
+	go func() {
+		if m.release != nil {
+			<-m.release
+		}
+		err := m.err
+		if err == nil && cmd.Record != nil {
+			err = cmd.Record(context.Background(), nil)
+		}
+		cmd.ErrChan <- err
+	}()
+	return nil
+}
+
+type mockIdempotency struct {
+	records map[string]*IdempotencyRecord
+}
+
+func newMockIdempotency() *mockIdempotency {
+	return &mockIdempotency{records: map[string]*IdempotencyRecord{}}
+}
+
+func (m *mockIdempotency) WithTx(exec database.Executor) IdempotencyRepository { return m }
+
+func (m *mockIdempotency) Reserve(ctx context.Context, userID, key, fingerprint string) (*IdempotencyRecord, bool, error) {
+	if rec, ok := m.records[userID+key]; ok {
+		return rec, false, nil
+	}
+	rec := &IdempotencyRecord{UserID: userID, Key: key, Fingerprint: fingerprint}
+	m.records[userID+key] = rec
+	return rec, true, nil
+}
+
+func (m *mockIdempotency) Complete(ctx context.Context, userID, key, transactionID string) error {
+	m.records[userID+key].TransactionID = transactionID
+	return nil
+}
+
+// Release frees the key unless it was linked to a transaction, like the real store.
+func (m *mockIdempotency) Release(ctx context.Context, userID, key string) error {
+	if rec, ok := m.records[userID+key]; ok && rec.TransactionID == "" {
+		delete(m.records, userID+key)
+	}
+	return nil
+}
+
+func TestTransactionService_Transfer_IdempotencyKey(t *testing.T) {
+	repo := &mockRepo{}
+	publisher := &mockPublisher{}
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	svc := NewTransactionService(repo, publisher, reader, newMockIdempotency(), nil, nil, FundingLimits{}, noLimits, nil, nil)
+
+	in := TransferInput{
+		UserID:         "user1",
+		ToAccountID:    "acc456",
+		Amount:         money.MustParse("100.00"),
+		Currency:       money.CurrencyMXN,
+		IdempotencyKey: "key-1",
+	}
+
+	first, err := svc.Transfer(context.Background(), in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	retry, err := svc.Transfer(context.Background(), in)
+	if err != nil {
+		t.Fatalf("unexpected error on retry: %v", err)
+	}
+	if retry.ID != first.ID {
+		t.Errorf("expected retry to return transaction %s, got %s", first.ID, retry.ID)
+	}
+	if len(publisher.published) != 1 {
+		t.Errorf("expected money to move once, got %d transfers", len(publisher.published))
+	}
+
+	in.Amount = money.MustParse("200.00")
+	_, err = svc.Transfer(context.Background(), in)
+	if !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("expected ErrIdempotencyKeyReused, got %v", err)
+	}
+}
+
+func TestTransferInput_Fingerprint_FreeText(t *testing.T) {
+	a := TransferInput{UserID: "user1", ToAccountID: "acc456", Description: "rent|march", Category: "home"}
+	b := TransferInput{UserID: "user1", ToAccountID: "acc456", Description: "rent", Category: "march|home"}
+
+	if a.fingerprint() == b.fingerprint() {
+		t.Error("requests differing only in how free text splits across fields share a fingerprint")
+	}
+	if a.fingerprint() != a.fingerprint() {
+		t.Error("the same request produced different fingerprints")
+	}
+}
+
+func TestTransactionService_Transfer_IdempotencyKeyReleasedOnFailure(t *testing.T) {
+	idempotency := newMockIdempotency()
+	publisher := &mockPublisher{err: errors.New("insufficient funds")}
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	svc := NewTransactionService(&mockRepo{}, publisher, reader, idempotency, nil, nil, FundingLimits{}, nil, nil, nil)
+
+	in := TransferInput{UserID: "user1", ToAccountID: "acc456", Amount: money.MustParse("10.00"), IdempotencyKey: "key-2"}
+
+	if _, err := svc.Transfer(context.Background(), in); err == nil {
 		t.Fatal("expected error, got nil")
 	}
+	if len(idempotency.records) != 0 {
+		t.Errorf("expected key to be released after a failed transfer")
+	}
+}
+
+func TestTransactionService_Transfer_RecordsInsideBalanceUpdate(t *testing.T) {
+	repo := &mockRepo{updateErr: errors.New("update failed")}
+	publisher := &mockPublisher{}
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	svc := NewTransactionService(repo, publisher, reader, nil, nil, nil, FundingLimits{}, noLimits, nil, nil)
+
+	_, err := svc.Transfer(context.Background(), TransferInput{UserID: "user1", ToAccountID: "acc456", Amount: money.MustParse("10.00")})
+	if err == nil {
+		t.Fatal("expected the failed status update to fail the transfer")
+	}
+
+	cmd := publisher.published[0]
+	if cmd.Record == nil {
+		t.Fatal("expected the transaction to be recorded by the balance update")
+	}
+	if cmd.Reference == "" {
+		t.Error("expected the balance update to reference the transaction ID")
+	}
+}
+
+func TestTransactionService_Transfer_UsesChosenSourceAccount(t *testing.T) {
+	publisher := &mockPublisher{}
+	reader := &mockReader{acc: &AccountInfo{ID: "acc-savings"}}
+	svc := NewTransactionService(&mockRepo{}, publisher, reader, nil, nil, nil, FundingLimits{}, noLimits, nil, nil)
+
+	tx, err := svc.Transfer(context.Background(), TransferInput{
+		UserID:        "user1",
+		FromAccountID: "acc-savings",
+		ToAccountID:   "acc456",
+		Amount:        money.MustParse("10.00"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reader.gotAccountID != "acc-savings" {
+		t.Errorf("expected the source account to be resolved for the user, got %q", reader.gotAccountID)
+	}
+	if tx.FromAccountID != "acc-savings" || publisher.published[0].FromAccountID != "acc-savings" {
+		t.Errorf("expected the transfer to debit acc-savings, got %s", tx.FromAccountID)
+	}
+
+	_, err = svc.Transfer(context.Background(), TransferInput{UserID: "user1", FromAccountID: "acc-savings", ToAccountID: "acc-savings", Amount: money.MustParse("10.00")})
+	if err == nil {
+		t.Error("expected a transfer to the same account to be rejected")
+	}
+}
+
+func TestTransactionService_Transfer_CrossCurrency(t *testing.T) {
+	rates := fixedRates{"USD/MXN": big.NewRat(1825, 100)}
+	reader := &mockReader{
+		acc:          &AccountInfo{ID: "acc-usd", Currency: money.CurrencyUSD},
+		destinations: map[string]*AccountInfo{"acc-mxn": {ID: "acc-mxn", UserID: "user2", Status: "active", Currency: money.CurrencyMXN}},
+	}
+
+	tests := []struct {
+		name        string
+		amount      string
+		currency    money.Currency
+		debit       string
+		credit      string
+		expectedErr error
+	}{
+		{name: "amount in source currency", amount: "10.00", currency: money.CurrencyUSD, debit: "10.00", credit: "182.50"},
+		{name: "currency defaults to source", amount: "10.00", debit: "10.00", credit: "182.50"},
+		{name: "amount in destination currency", amount: "100.00", currency: money.CurrencyMXN, debit: "5.48", credit: "100.00"},
+		{name: "unrelated currency", amount: "10.00", currency: money.CurrencyEUR, expectedErr: ErrUnsupportedTransferCurrency},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &mockRepo{}
+			publisher := &mockPublisher{}
+			svc := NewTransactionService(repo, publisher, reader, nil, rates, nil, FundingLimits{}, noLimits, nil, nil)
+
+			tx, err := svc.Transfer(context.Background(), TransferInput{
+				UserID:      "user1",
+				ToAccountID: "acc-mxn",
+				Amount:      money.MustParse(test.amount),
+				Currency:    test.currency,
+			})
+			if test.expectedErr != nil {
+				if !errors.Is(err, test.expectedErr) {
+					t.Fatalf("expected %v, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tx.Amount != money.MustParse(test.debit) || tx.Currency != money.CurrencyUSD {
+				t.Errorf("expected to debit %s USD, got %s %s", test.debit, tx.Amount, tx.Currency)
+			}
+			if tx.DestinationAmount != money.MustParse(test.credit) || tx.DestinationCurrency != money.CurrencyMXN {
+				t.Errorf("expected to credit %s MXN, got %s %s", test.credit, tx.DestinationAmount, tx.DestinationCurrency)
+			}
+			if tx.ExchangeRate != "18.25000000" {
+				t.Errorf("expected rate 18.25000000, got %s", tx.ExchangeRate)
+			}
+
+			cmd := publisher.published[0]
+			if cmd.Amount != tx.Amount || cmd.CreditAmount != tx.DestinationAmount {
+				t.Errorf("expected balance update of %s -> %s, got %s -> %s", tx.Amount, tx.DestinationAmount, cmd.Amount, cmd.CreditAmount)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Transfer_MissingRate(t *testing.T) {
+	reader := &mockReader{
+		acc:          &AccountInfo{ID: "acc-usd", Currency: money.CurrencyUSD},
+		destinations: map[string]*AccountInfo{"acc-gbp": {ID: "acc-gbp", UserID: "user2", Status: "active", Currency: money.CurrencyGBP}},
+	}
+	publisher := &mockPublisher{}
+	svc := NewTransactionService(&mockRepo{}, publisher, reader, nil, fixedRates{}, nil, FundingLimits{}, nil, nil, nil)
+
+	_, err := svc.Transfer(context.Background(), TransferInput{UserID: "user1", ToAccountID: "acc-gbp", Amount: money.MustParse("10.00")})
+	if !errors.Is(err, fx.ErrRateNotFound) {
+		t.Fatalf("expected ErrRateNotFound, got %v", err)
+	}
+	if len(publisher.published) != 0 {
+		t.Error("expected no balance update without a rate")
+	}
+}
+
+func TestTransactionService_Transfer_StatusLifecycle(t *testing.T) {
+	tests := []struct {
+		name          string
+		publishErr    error
+		expectedFinal TransactionStatus
+	}{
+		{name: "completed", expectedFinal: TransactionStatusCompleted},
+		{name: "failed", publishErr: errors.New("insufficient funds"), expectedFinal: TransactionStatusFailed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &mockRepo{}
+			reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+			svc := NewTransactionService(repo, &mockPublisher{err: test.publishErr}, reader, nil, nil, nil, FundingLimits{}, noLimits, nil, nil)
+
+			svc.Transfer(context.Background(), TransferInput{UserID: "user1", ToAccountID: "acc456", Amount: money.MustParse("10.00")})
+
+			if len(repo.created) != 1 || repo.created[0].Status != test.expectedFinal {
+				t.Fatalf("expected one %s transaction, got %v", test.expectedFinal, repo.created)
+			}
+			tx := repo.created[0]
+			if got := repo.statuses[tx.ID]; !reflect.DeepEqual(got, []TransactionStatus{test.expectedFinal}) {
+				t.Errorf("expected a single pending -> %s transition, got %v", test.expectedFinal, got)
+			}
+			if test.publishErr != nil && tx.FailureReason != test.publishErr.Error() {
+				t.Errorf("expected failure reason %q, got %q", test.publishErr, tx.FailureReason)
+			}
+		})
+	}
+}
+
+func TestTransactionStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from, to TransactionStatus
+		expected bool
+	}{
+		{from: TransactionStatusPending, to: TransactionStatusCompleted, expected: true},
+		{from: TransactionStatusPending, to: TransactionStatusFailed, expected: true},
+		{from: TransactionStatusCompleted, to: TransactionStatusReversed, expected: true},
+		{from: TransactionStatusCompleted, to: TransactionStatusFailed, expected: false},
+		{from: TransactionStatusFailed, to: TransactionStatusCompleted, expected: false},
+		{from: TransactionStatusReversed, to: TransactionStatusCompleted, expected: false},
+		{from: TransactionStatusPending, to: TransactionStatusReversed, expected: false},
+		{from: TransactionStatusHeld, to: TransactionStatusCompleted, expected: true},
+		{from: TransactionStatusHeld, to: TransactionStatusFailed, expected: true},
+		{from: TransactionStatusHeld, to: TransactionStatusPending, expected: false},
+		{from: TransactionStatusPending, to: TransactionStatusProcessing, expected: true},
+		{from: TransactionStatusProcessing, to: TransactionStatusCompleted, expected: true},
+		{from: TransactionStatusProcessing, to: TransactionStatusFailed, expected: true},
+		{from: TransactionStatusProcessing, to: TransactionStatusReversed, expected: false},
+	}
+
+	for _, test := range tests {
+		if got := test.from.CanTransitionTo(test.to); got != test.expected {
+			t.Errorf("%s -> %s: expected %v, got %v", test.from, test.to, test.expected, got)
+		}
+	}
+}
+
+// casRepo records the status every update expected to replace.
+type casRepo struct {
+	*mockRepo
+	from []TransactionStatus
+}
+
+func (r *casRepo) UpdateStatus(ctx context.Context, tx *Transaction, from TransactionStatus) error {
+	r.from = append(r.from, from)
+	return r.mockRepo.UpdateStatus(ctx, tx, from)
+}
+
+func TestTransactionService_Fail_StartsFromStoredStatus(t *testing.T) {
+	repo := &casRepo{mockRepo: &mockRepo{created: []*Transaction{{ID: "tx1", Status: TransactionStatusHeld}}}}
+	svc := NewTransactionService(repo, &mockPublisher{}, &mockReader{}, nil, nil, nil, FundingLimits{}, nil, nil, nil).(*transactionService)
+
+	// The caller's copy says pending, but the row is still held.
+	tx := &Transaction{ID: "tx1", Status: TransactionStatusPending}
+	svc.fail(tx, errors.New("review could not be stored"))
+
+	if !reflect.DeepEqual(repo.from, []TransactionStatus{TransactionStatusHeld}) {
+		t.Errorf("expected the update to start from held, got %v", repo.from)
+	}
+	if tx.Status != TransactionStatusFailed || tx.FailureReason != "review could not be stored" {
+		t.Errorf("expected a failed transaction with the reason, got %s %q", tx.Status, tx.FailureReason)
+	}
+}
+
+func TestSetStatus_RejectsIllegalTransition(t *testing.T) {
+	repo := &mockRepo{}
+	tx := &Transaction{ID: "tx1", Status: TransactionStatusFailed}
+
+	err := setStatus(context.Background(), repo, tx, TransactionStatusCompleted, "")
+	if !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Fatalf("expected ErrInvalidStatusTransition, got %v", err)
+	}
+	if tx.Status != TransactionStatusFailed || len(repo.statuses) != 0 {
+		t.Errorf("expected the transaction to be left untouched")
+	}
+}
+
+func TestTransactionService_Transfer_Destination(t *testing.T) {
+	beneficiary := &AccountInfo{ID: "acc-payee", UserID: "user2", Status: "active", Currency: money.CurrencyMXN}
+
+	tests := []struct {
+		name          string
+		toAccountID   string
+		beneficiaryID string
+		destinations  map[string]*AccountInfo
+		wantErr       error
+		wantTo        string
+	}{
+		{name: "missing account", toAccountID: "acc-typo", destinations: map[string]*AccountInfo{"acc-typo": nil}, wantErr: ErrDestinationNotFound},
+		{name: "bank account", toAccountID: "acc-bank", destinations: map[string]*AccountInfo{"acc-bank": {ID: "acc-bank", Status: "active"}}, wantErr: ErrDestinationNotFound},
+		{name: "frozen account", toAccountID: "acc-frozen", destinations: map[string]*AccountInfo{"acc-frozen": {ID: "acc-frozen", UserID: "user2", Status: "frozen"}}, wantErr: ErrDestinationUnavailable},
+		{name: "unknown beneficiary", beneficiaryID: "nope", wantErr: ErrBeneficiaryNotFound},
+		{name: "beneficiary", beneficiaryID: "rent", destinations: map[string]*AccountInfo{"acc-payee": beneficiary}, wantTo: "acc-payee"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &mockPublisher{}
+			reader := &mockReader{
+				acc:           &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN},
+				destinations:  tt.destinations,
+				beneficiaries: map[string]*AccountInfo{"rent": beneficiary},
+			}
+			svc := NewTransactionService(&mockRepo{}, publisher, reader, nil, nil, nil, FundingLimits{}, noLimits, nil, nil)
+
+			tx, err := svc.Transfer(context.Background(), TransferInput{
+				UserID:        "user1",
+				ToAccountID:   tt.toAccountID,
+				BeneficiaryID: tt.beneficiaryID,
+				Amount:        money.MustParse("10.00"),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				if len(publisher.published) != 0 {
+					t.Error("expected nothing to be queued")
+				}
+				return
+			}
+			if tx.ToAccountID != tt.wantTo {
+				t.Errorf("expected transfer to %s, got %s", tt.wantTo, tx.ToAccountID)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Transfer_Validation(t *testing.T) {
+	source := &AccountInfo{ID: "acc123", UserID: "user1", Status: "active", Currency: money.CurrencyMXN}
+	readErr := errors.New("database down")
+
+	tests := []struct {
+		name        string
+		reader      *mockReader
+		input       TransferInput
+		expectedErr error  // Checked with errors.Is when set
+		message     string // Checked otherwise
+	}{
+		{
+			name:    "zero amount",
+			input:   TransferInput{ToAccountID: "acc456"},
+			message: "amount must be greater than zero",
+		},
+		{
+			name:    "negative amount",
+			input:   TransferInput{ToAccountID: "acc456", Amount: money.MustParse("-5.00")},
+			message: "amount must be greater than zero",
+		},
+		{
+			name:        "no origin account",
+			reader:      &mockReader{},
+			input:       TransferInput{ToAccountID: "acc456", Amount: money.MustParse("5.00")},
+			expectedErr: ErrAccountNotFound,
+		},
+		{
+			name:        "origin lookup fails",
+			reader:      &mockReader{err: readErr},
+			input:       TransferInput{ToAccountID: "acc456", Amount: money.MustParse("5.00")},
+			expectedErr: readErr,
+		},
+		{
+			name:    "same account",
+			input:   TransferInput{ToAccountID: "acc123", Amount: money.MustParse("5.00")},
+			message: "cannot transfer to the same account",
+		},
+		{
+			name:        "unknown destination",
+			reader:      &mockReader{acc: source, destinations: map[string]*AccountInfo{"ghost": nil}},
+			input:       TransferInput{ToAccountID: "ghost", Amount: money.MustParse("5.00")},
+			expectedErr: ErrDestinationNotFound,
+		},
+		{
+			name:        "bank account destination",
+			reader:      &mockReader{acc: source, destinations: map[string]*AccountInfo{"clearing": {ID: "clearing", Status: "active"}}},
+			input:       TransferInput{ToAccountID: "clearing", Amount: money.MustParse("5.00")},
+			expectedErr: ErrDestinationNotFound,
+		},
+		{
+			name:        "frozen destination",
+			reader:      &mockReader{acc: source, destinations: map[string]*AccountInfo{"frozen": {ID: "frozen", UserID: "user2", Status: "frozen"}}},
+			input:       TransferInput{ToAccountID: "frozen", Amount: money.MustParse("5.00")},
+			expectedErr: ErrDestinationUnavailable,
+		},
+		{
+			name:        "unknown beneficiary",
+			input:       TransferInput{BeneficiaryID: "ben1", Amount: money.MustParse("5.00")},
+			expectedErr: ErrBeneficiaryNotFound,
+		},
+		{
+			name:    "beneficiary and account disagree",
+			reader:  &mockReader{acc: source, beneficiaries: map[string]*AccountInfo{"ben1": {ID: "acc789"}}},
+			input:   TransferInput{BeneficiaryID: "ben1", ToAccountID: "acc456", Amount: money.MustParse("5.00")},
+			message: "to_account_id does not match the beneficiary",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := test.reader
+			if reader == nil {
+				reader = &mockReader{acc: source}
+			}
+			repo := &mockRepo{}
+			publisher := &mockPublisher{}
+			svc := NewTransactionService(repo, publisher, reader, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+
+			in := test.input
+			in.UserID = "user1"
+			_, err := svc.Transfer(context.Background(), in)
+			switch {
+			case err == nil:
+				t.Fatal("expected error, got nil")
+			case test.expectedErr != nil && !errors.Is(err, test.expectedErr):
+				t.Fatalf("expected %v, got %v", test.expectedErr, err)
+			case test.expectedErr == nil && err.Error() != test.message:
+				t.Fatalf("expected %q, got %q", test.message, err)
+			}
+			if len(repo.created) != 0 || len(publisher.published) != 0 {
+				t.Errorf("expected nothing recorded or queued, got %d rows and %d balance updates", len(repo.created), len(publisher.published))
+			}
+		})
+	}
+}
+
+func TestTransactionService_Transfer_WorkerErrors(t *testing.T) {
+	errInsufficientFunds := errors.New("insufficient funds")
+
+	tests := []struct {
+		name      string
+		publisher *mockPublisher
+		expected  error
+		queued    bool
+	}{
+		{name: "worker rejects the update", publisher: &mockPublisher{err: errInsufficientFunds}, expected: errInsufficientFunds, queued: true},
+		{name: "queue is full", publisher: &mockPublisher{rejectErr: ErrTransferQueueFull}, expected: ErrTransferQueueFull},
+		{name: "shutting down", publisher: &mockPublisher{rejectErr: ErrTransfersUnavailable}, expected: ErrTransfersUnavailable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &mockRepo{}
+			reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+			svc := NewTransactionService(repo, test.publisher, reader, nil, nil, nil, FundingLimits{}, noLimits, nil, nil)
+
+			_, err := svc.Transfer(context.Background(), TransferInput{UserID: "user1", ToAccountID: "acc456", Amount: money.MustParse("10.00")})
+			if !errors.Is(err, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, err)
+			}
+			if queued := len(test.publisher.published) == 1; queued != test.queued {
+				t.Errorf("expected queued=%v, got %v", test.queued, queued)
+			}
+			if len(repo.created) != 1 {
+				t.Fatalf("expected the transfer to be recorded, got %d rows", len(repo.created))
+			}
+			tx := repo.created[0]
+			if tx.Status != TransactionStatusFailed || tx.FailureReason != test.expected.Error() {
+				t.Errorf("expected failed with %q, got %s with %q", test.expected, tx.Status, tx.FailureReason)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Transfer_WorkerErrorAfterCancel(t *testing.T) {
+	repo := &mockRepo{updated: make(chan string, 1)}
+	publisher := &mockPublisher{err: errors.New("insufficient funds"), release: make(chan struct{})}
+	reader := &mockReader{acc: &AccountInfo{ID: "acc123", Currency: money.CurrencyMXN}}
+	svc := NewTransactionService(repo, publisher, reader, nil, nil, nil, FundingLimits{}, noLimits, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := svc.Transfer(ctx, TransferInput{UserID: "user1", ToAccountID: "acc456", Amount: money.MustParse("10.00")})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	// The worker reports after the caller gave up; its outcome must still be recorded.
+	close(publisher.release)
+	select {
+	case id := <-repo.updated:
+		if got := repo.statuses[id]; !reflect.DeepEqual(got, []TransactionStatus{TransactionStatusFailed}) {
+			t.Errorf("expected the transfer to be marked failed, got %v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the late worker error to be recorded")
+	}
 }
@@ -0,0 +1,213 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-bank-app/pkg/csvwriter"
+	"go-bank-app/pkg/money"
+	"io"
+	"net/url"
+	"time"
+)
+
+// maxStatementDays bounds custom statement periods, which are not paginated.
+const maxStatementDays = 366
+
+// StatementPeriod is the time a statement covers, from Start inclusive to End exclusive.
+type StatementPeriod struct {
+	Start time.Time
+	End   time.Time
+}
+
+// MonthPeriod returns the period of the calendar month containing t.
+func MonthPeriod(t time.Time) StatementPeriod {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return StatementPeriod{Start: start, End: start.AddDate(0, 1, 0)}
+}
+
+// IsMonth reports whether the period is exactly one calendar month.
+func (p StatementPeriod) IsMonth() bool {
+	return p == MonthPeriod(p.Start)
+}
+
+// LastDay returns the last day included in the period.
+func (p StatementPeriod) LastDay() time.Time {
+	return p.End.AddDate(0, 0, -1)
+}
+
+// Label names the period: 2024-03 for a month, 2024-03-01_2024-03-15 otherwise.
+func (p StatementPeriod) Label() string {
+	if p.IsMonth() {
+		return p.Start.Format("2006-01")
+	}
+	return p.Start.Format(time.DateOnly) + "_" + p.LastDay().Format(time.DateOnly)
+}
+
+// ParseStatementPeriod reads the period from either month (YYYY-MM) or from and to
+// (YYYY-MM-DD, both inclusive). Without either it is the current month.
+func ParseStatementPeriod(query url.Values, now time.Time) (StatementPeriod, error) {
+	month, from, to := query.Get("month"), query.Get("from"), query.Get("to")
+
+	var period StatementPeriod
+	switch {
+	case month != "" && (from != "" || to != ""):
+		return period, errors.New("use either month or from and to")
+	case month != "":
+		start, err := time.ParseInLocation("2006-01", month, now.Location())
+		if err != nil {
+			return period, errors.New("month must be formatted as YYYY-MM")
+		}
+		period = MonthPeriod(start)
+	case from != "" || to != "":
+		start, err := time.ParseInLocation(time.DateOnly, from, now.Location())
+		if err != nil {
+			return period, errors.New("from must be formatted as YYYY-MM-DD")
+		}
+		end, err := time.ParseInLocation(time.DateOnly, to, now.Location())
+		if err != nil {
+			return period, errors.New("to must be formatted as YYYY-MM-DD")
+		}
+		period = StatementPeriod{Start: start, End: end.AddDate(0, 0, 1)}
+		if !period.Start.Before(period.End) {
+			return period, errors.New("from must not be after to")
+		}
+		if period.End.Sub(period.Start) > maxStatementDays*24*time.Hour {
+			return period, fmt.Errorf("statement period cannot exceed %d days", maxStatementDays)
+		}
+	default:
+		period = MonthPeriod(now)
+	}
+
+	if period.Start.After(now) {
+		return period, errors.New("statement period has not started yet")
+	}
+	return period, nil
+}
+
+// Statement lists the movements posted to the ledger of an account over a period.
+type Statement struct {
+	AccountID      string
+	HolderName     string
+	Currency       money.Currency
+	Period         StatementPeriod
+	OpeningBalance money.Amount
+	ClosingBalance money.Amount
+	// TotalCredits and TotalDebits add up the money received and sent, both positive.
+	TotalCredits money.Amount
+	TotalDebits  money.Amount
+	Lines        []StatementLine
+	GeneratedAt  time.Time
+}
+
+// StatementLine is a ledger posting of the statement's account, with the transaction
+// that caused it.
+type StatementLine struct {
+	Transaction Transaction
+	// Amount is positive for money received and negative for money sent.
+	Amount money.Amount
+	// Balance is the running balance after the posting.
+	Balance money.Amount
+	// PostedAt is when the posting was made, which can be well after the transaction was
+	// requested, e.g. for a held transfer.
+	PostedAt time.Time
+}
+
+// Filename returns the name the statement is downloaded as.
+func (st *Statement) Filename(extension string) string {
+	return fmt.Sprintf("statement-%s-%s.%s", st.AccountID, st.Period.Label(), extension)
+}
+
+// GetStatement implements TransactionService. Balances and lines both come from the
+// account's ledger, so the lines of a period always add up to its balances.
+func (s *transactionService) GetStatement(ctx context.Context, userID, accountID string, period StatementPeriod) (*Statement, error) {
+	account, err := s.accountForUser(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	opening, err := s.reader.GetBalanceAt(ctx, account.ID, period.Start)
+	if err != nil {
+		return nil, err
+	}
+	closing, err := s.reader.GetBalanceAt(ctx, account.ID, period.End)
+	if err != nil {
+		return nil, err
+	}
+	holder, err := s.reader.GetHolderName(ctx, account.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := s.repo.GetStatementLines(ctx, account.ID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &Statement{
+		AccountID:      account.ID,
+		HolderName:     holder,
+		Currency:       account.Currency,
+		Period:         period,
+		OpeningBalance: opening,
+		ClosingBalance: closing,
+		GeneratedAt:    time.Now(),
+	}
+	balance := opening
+	for _, line := range lines {
+		if line.Amount.IsNegative() {
+			statement.TotalDebits = statement.TotalDebits.Add(line.Amount.Neg())
+		} else {
+			statement.TotalCredits = statement.TotalCredits.Add(line.Amount)
+		}
+		balance = balance.Add(line.Amount)
+		line.Balance = balance
+		statement.Lines = append(statement.Lines, line)
+	}
+	return statement, nil
+}
+
+// GenerateStatementCSV implements TransactionService. Rows are streamed to w; the first
+// and last carry the opening and closing balances. Descriptions are written by users and
+// escaped so spreadsheets do not run them as formulas.
+func (s *transactionService) GenerateStatementCSV(w io.Writer, st *Statement, opts csvwriter.Options) error {
+	csv, err := csvwriter.NewStreamWriter(w, []string{
+		"Date",
+		"Transaction ID",
+		"Description",
+		"From Account",
+		"To Account",
+		"Amount",
+		"Currency",
+		"Balance",
+	}, opts)
+	if err != nil {
+		return err
+	}
+
+	currency := string(st.Currency)
+	if err := csv.WriteRow([]string{st.Period.Start.Format(time.DateOnly), "", "Opening balance", "", "", "", currency, st.OpeningBalance.String()}); err != nil {
+		return err
+	}
+	for _, line := range st.Lines {
+		t := line.Transaction
+		row := []string{
+			line.PostedAt.Format("2006-01-02 15:04:05"),
+			t.ID,
+			csvwriter.EscapeFormula(t.Description),
+			t.FromAccountID,
+			t.ToAccountID,
+			line.Amount.String(),
+			currency,
+			line.Balance.String(),
+		}
+		if err := csv.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	if err := csv.WriteRow([]string{st.Period.LastDay().Format(time.DateOnly), "", "Closing balance", "", "", "", currency, st.ClosingBalance.String()}); err != nil {
+		return err
+	}
+
+	return csv.Flush()
+}
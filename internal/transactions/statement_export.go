@@ -0,0 +1,369 @@
+package transactions
+
+import (
+	"encoding/xml"
+	"fmt"
+	"go-bank-app/pkg/money"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identify the bank in machine-readable statements.
+const (
+	statementBankName = "Go Bank"
+	statementBankID   = "GOBANK"
+	statementBIC      = "GOBKMXMMXXX"
+	// statementIntuitBankID is the INTU.BID Quicken looks up QFX files by.
+	statementIntuitBankID = "00000"
+)
+
+const ofxTimeLayout = "20060102150405"
+
+// ofxHeader declares an OFX 2.2 document, which Quicken also reads as QFX.
+const ofxHeader = xml.Header + `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+
+type ofxDocument struct {
+	XMLName xml.Name  `xml:"OFX"`
+	SignOn  ofxSignOn `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank    ofxBank   `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status    ofxStatus `xml:"STATUS"`
+	ServerAt  string    `xml:"DTSERVER"`
+	Language  string    `xml:"LANGUAGE"`
+	Org       string    `xml:"FI>ORG"`
+	FID       string    `xml:"FI>FID"`
+	IntuitBID string    `xml:"INTU.BID,omitempty"`
+}
+
+type ofxBank struct {
+	TransactionUID string       `xml:"TRNUID"`
+	Status         ofxStatus    `xml:"STATUS"`
+	Statement      ofxStatement `xml:"STMTRS"`
+}
+
+type ofxStatement struct {
+	Currency      money.Currency   `xml:"CURDEF"`
+	BankID        string           `xml:"BANKACCTFROM>BANKID"`
+	AccountID     string           `xml:"BANKACCTFROM>ACCTID"`
+	AccountType   string           `xml:"BANKACCTFROM>ACCTTYPE"`
+	Start         string           `xml:"BANKTRANLIST>DTSTART"`
+	End           string           `xml:"BANKTRANLIST>DTEND"`
+	Transactions  []ofxTransaction `xml:"BANKTRANLIST>STMTTRN"`
+	LedgerBalance money.Amount     `xml:"LEDGERBAL>BALAMT"`
+	LedgerAsOf    string           `xml:"LEDGERBAL>DTASOF"`
+}
+
+type ofxTransaction struct {
+	Type     string       `xml:"TRNTYPE"`
+	PostedAt string       `xml:"DTPOSTED"`
+	Amount   money.Amount `xml:"TRNAMT"`
+	ID       string       `xml:"FITID"`
+	Name     string       `xml:"NAME,omitempty"`
+	Memo     string       `xml:"MEMO,omitempty"`
+}
+
+// ofxNameLength is the longest NAME the OFX specification allows.
+const ofxNameLength = 32
+
+// GenerateStatementOFX implements TransactionService.
+func (s *transactionService) GenerateStatementOFX(w io.Writer, st *Statement) error {
+	return writeOFX(w, st, "")
+}
+
+// GenerateStatementQFX implements TransactionService. QFX is OFX with the Intuit bank ID
+// Quicken requires.
+func (s *transactionService) GenerateStatementQFX(w io.Writer, st *Statement) error {
+	return writeOFX(w, st, statementIntuitBankID)
+}
+
+func writeOFX(w io.Writer, st *Statement, intuitBankID string) error {
+	ok := ofxStatus{Code: 0, Severity: "INFO"}
+	doc := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:    ok,
+			ServerAt:  st.GeneratedAt.UTC().Format(ofxTimeLayout),
+			Language:  "ENG",
+			Org:       statementBankName,
+			FID:       statementBankID,
+			IntuitBID: intuitBankID,
+		},
+		Bank: ofxBank{
+			TransactionUID: "0",
+			Status:         ok,
+			Statement: ofxStatement{
+				Currency:      st.Currency,
+				BankID:        statementBankID,
+				AccountID:     st.AccountID,
+				AccountType:   "CHECKING",
+				Start:         st.Period.Start.UTC().Format(ofxTimeLayout),
+				End:           st.Period.End.UTC().Format(ofxTimeLayout),
+				LedgerBalance: st.ClosingBalance,
+				LedgerAsOf:    st.Period.End.UTC().Format(ofxTimeLayout),
+			},
+		},
+	}
+
+	for _, line := range st.Lines {
+		tx := line.Transaction
+		doc.Bank.Statement.Transactions = append(doc.Bank.Statement.Transactions, ofxTransaction{
+			Type:     ofxTransactionType(line),
+			PostedAt: line.PostedAt.UTC().Format(ofxTimeLayout),
+			Amount:   line.Amount,
+			ID:       tx.ID,
+			Name:     truncateRunes(tx.Description, ofxNameLength),
+			Memo:     tx.Description,
+		})
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+	return encodeXML(w, doc)
+}
+
+func ofxTransactionType(line StatementLine) string {
+	switch line.Transaction.Type {
+	case TransactionTypeTransfer:
+		return "XFER"
+	case TransactionTypeDeposit:
+		return "DEP"
+	case TransactionTypeFee:
+		return "FEE"
+	}
+	if line.Amount.IsNegative() {
+		return "DEBIT"
+	}
+	return "CREDIT"
+}
+
+// camt053Namespace is the version of ISO 20022 Bank to Customer Statement we produce.
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// camtRemittanceLength is the longest unstructured remittance information allowed.
+const camtRemittanceLength = 140
+
+// Longest identifiers camt.053 allows: Max35Text for message, statement and entry
+// references, Max34Text for account identifications.
+const (
+	camtReferenceLength = 35
+	camtAccountIDLength = 34
+)
+
+const (
+	camtDateLayout     = "2006-01-02"
+	camtDateTimeLayout = "2006-01-02T15:04:05Z07:00"
+)
+
+type camtDocument struct {
+	XMLName   xml.Name      `xml:"Document"`
+	Namespace string        `xml:"xmlns,attr"`
+	MessageID string        `xml:"BkToCstmrStmt>GrpHdr>MsgId"`
+	CreatedAt string        `xml:"BkToCstmrStmt>GrpHdr>CreDtTm"`
+	Statement camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	ID        string        `xml:"Id"`
+	CreatedAt string        `xml:"CreDtTm"`
+	From      string        `xml:"FrToDt>FrDtTm"`
+	To        string        `xml:"FrToDt>ToDtTm"`
+	Account   camtAccount   `xml:"Acct"`
+	Balances  []camtBalance `xml:"Bal"`
+	Summary   camtSummary   `xml:"TxsSummry"`
+	Entries   []camtEntry   `xml:"Ntry"`
+}
+
+type camtAccount struct {
+	ID       string         `xml:"Id>Othr>Id"`
+	Currency money.Currency `xml:"Ccy"`
+	Owner    *camtOwner     `xml:"Ownr,omitempty"`
+	BIC      string         `xml:"Svcr>FinInstnId>BIC"`
+}
+
+type camtOwner struct {
+	Name string `xml:"Nm"`
+}
+
+// camtPartyAccount is the account on the other side of an entry.
+type camtPartyAccount struct {
+	ID       string         `xml:"Id>Othr>Id"`
+	Currency money.Currency `xml:"Ccy,omitempty"`
+}
+
+type camtAmount struct {
+	Currency money.Currency `xml:"Ccy,attr"`
+	Value    money.Amount   `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Type   string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount camtAmount `xml:"Amt"`
+	Sign   string     `xml:"CdtDbtInd"`
+	Date   string     `xml:"Dt>Dt"`
+}
+
+type camtSummary struct {
+	Count   int          `xml:"TtlNtries>NbOfNtries"`
+	Sum     money.Amount `xml:"TtlNtries>Sum"`
+	Net     money.Amount `xml:"TtlNtries>TtlNetNtryAmt"`
+	NetSign string       `xml:"TtlNtries>CdtDbtInd"`
+	Credits camtTotal    `xml:"TtlCdtNtries"`
+	Debits  camtTotal    `xml:"TtlDbtNtries"`
+}
+
+type camtTotal struct {
+	Count int          `xml:"NbOfNtries"`
+	Sum   money.Amount `xml:"Sum"`
+}
+
+type camtEntry struct {
+	Reference   string     `xml:"NtryRef"`
+	Amount      camtAmount `xml:"Amt"`
+	Sign        string     `xml:"CdtDbtInd"`
+	Reversal    bool       `xml:"RvslInd,omitempty"`
+	Status      string     `xml:"Sts"`
+	BookedAt    string     `xml:"BookgDt>DtTm"`
+	ValueDate   string     `xml:"ValDt>Dt"`
+	ServicerRef string     `xml:"AcctSvcrRef"`
+	Code        string     `xml:"BkTxCd>Prtry>Cd"`
+	Details     camtDetail `xml:"NtryDtls>TxDtls"`
+}
+
+type camtDetail struct {
+	ServicerRef  string            `xml:"Refs>AcctSvcrRef"`
+	Debtor       *camtPartyAccount `xml:"RltdPties>DbtrAcct,omitempty"`
+	Creditor     *camtPartyAccount `xml:"RltdPties>CdtrAcct,omitempty"`
+	Unstructured string            `xml:"RmtInf>Ustrd,omitempty"`
+}
+
+// GenerateStatementCAMT053 implements TransactionService. Entries are booked, and
+// amounts are unsigned with a credit or debit indicator, as ISO 20022 expects.
+func (s *transactionService) GenerateStatementCAMT053(w io.Writer, st *Statement) error {
+	// Account and period do not fit in a Max35Text together, so the statement is
+	// identified by a name-based UUID of both, and the message by one that also covers
+	// when it was generated.
+	stmtID := fmt.Sprintf("%s-%s", st.AccountID, st.Period.Label())
+	createdAt := st.GeneratedAt.UTC().Format(camtDateTimeLayout)
+	doc := camtDocument{
+		Namespace: camt053Namespace,
+		MessageID: camtNameID("message:" + stmtID + ":" + createdAt),
+		CreatedAt: createdAt,
+		Statement: camtStatement{
+			ID:        camtNameID("statement:" + stmtID),
+			CreatedAt: createdAt,
+			From:      st.Period.Start.UTC().Format(camtDateTimeLayout),
+			To:        st.Period.End.Add(-time.Second).UTC().Format(camtDateTimeLayout),
+			Account: camtAccount{
+				ID:       camtID(st.AccountID, camtAccountIDLength),
+				Currency: st.Currency,
+				BIC:      statementBIC,
+			},
+			Balances: []camtBalance{
+				camtBalanceOf("OPBD", st.Currency, st.OpeningBalance, st.Period.Start),
+				camtBalanceOf("CLBD", st.Currency, st.ClosingBalance, st.Period.LastDay()),
+			},
+		},
+	}
+
+	if st.HolderName != "" {
+		doc.Statement.Account.Owner = &camtOwner{Name: st.HolderName}
+	}
+
+	summary := &doc.Statement.Summary
+	for _, line := range st.Lines {
+		tx := line.Transaction
+		amount, sign := camtSigned(line.Amount)
+		ref := camtID(tx.ID, camtReferenceLength)
+		entry := camtEntry{
+			Reference:   ref,
+			Amount:      camtAmount{Currency: st.Currency, Value: amount},
+			Sign:        sign,
+			Reversal:    tx.Type == TransactionTypeReversal,
+			Status:      "BOOK",
+			BookedAt:    line.PostedAt.UTC().Format(camtDateTimeLayout),
+			ValueDate:   line.PostedAt.UTC().Format(camtDateLayout),
+			ServicerRef: ref,
+			Code:        string(tx.Type),
+			Details:     camtDetail{ServicerRef: ref, Unstructured: truncateRunes(tx.Description, camtRemittanceLength)},
+		}
+		if sign == "DBIT" && tx.ToAccountID != "" {
+			entry.Details.Creditor = &camtPartyAccount{ID: camtID(tx.ToAccountID, camtAccountIDLength), Currency: tx.DestinationCurrency}
+		} else if sign == "CRDT" && tx.FromAccountID != "" {
+			entry.Details.Debtor = &camtPartyAccount{ID: camtID(tx.FromAccountID, camtAccountIDLength), Currency: tx.Currency}
+		}
+		doc.Statement.Entries = append(doc.Statement.Entries, entry)
+
+		summary.Count++
+		summary.Sum = summary.Sum.Add(amount)
+		if sign == "CRDT" {
+			summary.Credits.Count++
+			summary.Credits.Sum = summary.Credits.Sum.Add(amount)
+		} else {
+			summary.Debits.Count++
+			summary.Debits.Sum = summary.Debits.Sum.Add(amount)
+		}
+	}
+	summary.Net, summary.NetSign = camtSigned(summary.Credits.Sum.Sub(summary.Debits.Sum))
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return encodeXML(w, doc)
+}
+
+func camtBalanceOf(balanceType string, currency money.Currency, balance money.Amount, day time.Time) camtBalance {
+	amount, sign := camtSigned(balance)
+	return camtBalance{
+		Type:   balanceType,
+		Amount: camtAmount{Currency: currency, Value: amount},
+		Sign:   sign,
+		Date:   day.Format(camtDateLayout),
+	}
+}
+
+// camtSigned splits a signed amount into its absolute value and credit/debit indicator.
+func camtSigned(a money.Amount) (money.Amount, string) {
+	if a.IsNegative() {
+		return a.Neg(), "DBIT"
+	}
+	return a, "CRDT"
+}
+
+func encodeXML(w io.Writer, doc interface{}) error {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// camtID fits an identifier into a camt.053 field of at most n characters. UUIDs lose
+// their hyphens, which leaves 32.
+func camtID(id string, n int) string {
+	return truncateRunes(strings.ReplaceAll(id, "-", ""), n)
+}
+
+// camtNameID returns a 32 character identifier derived from name, the same every time.
+func camtNameID(name string) string {
+	return camtID(uuid.NewSHA1(uuid.NameSpaceURL, []byte(name)).String(), camtReferenceLength)
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
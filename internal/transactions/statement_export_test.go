@@ -0,0 +1,217 @@
+package transactions
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"go-bank-app/pkg/money"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Accounts are identified by UUIDs, as in the database.
+const (
+	exportAccountID      = "3f6c1a2e-8d4b-4c1f-9a7e-5b2d1c0e9f84"
+	exportCounterpartyID = "7a2b9c4d-1e3f-4a5b-8c6d-2e4f6a8b0c12"
+)
+
+// exportStatement has a deposit, a transfer out and a partial refund of it.
+func exportStatement() *Statement {
+	period := MonthPeriod(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	lines := []StatementLine{
+		{
+			Transaction: Transaction{ID: "0b7e6f1c-1d0a-4a57-9a43-1c2f0e5b7a01", Type: TransactionTypeDeposit, ToAccountID: exportAccountID, Currency: money.CurrencyMXN, DestinationCurrency: money.CurrencyMXN, Description: "Payroll March", CreatedAt: period.Start.Add(9 * time.Hour)},
+			Amount:      money.MustParse("15000.00"),
+			Balance:     money.MustParse("15100.00"),
+			PostedAt:    period.Start.Add(9 * time.Hour),
+		},
+		{
+			Transaction: Transaction{ID: "5c1d3e2f-7a6b-4c8d-9e0f-1a2b3c4d5e02", Type: TransactionTypeTransfer, FromAccountID: exportAccountID, ToAccountID: exportCounterpartyID, Currency: money.CurrencyMXN, DestinationCurrency: money.CurrencyMXN, Description: "Rent & utilities <March> for the apartment on 5th Avenue", CreatedAt: period.Start.Add(50 * time.Hour)},
+			Amount:      money.MustParse("-8500.00"),
+			Balance:     money.MustParse("6600.00"),
+			PostedAt:    period.Start.Add(50 * time.Hour),
+		},
+		{
+			Transaction: Transaction{ID: "9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b03", Type: TransactionTypeReversal, FromAccountID: exportCounterpartyID, ToAccountID: exportAccountID, Currency: money.CurrencyMXN, DestinationCurrency: money.CurrencyMXN, Description: "Refund of overcharge", CreatedAt: period.Start.Add(100 * time.Hour)},
+			Amount:      money.MustParse("250.00"),
+			Balance:     money.MustParse("6850.00"),
+			PostedAt:    period.Start.Add(100 * time.Hour),
+		},
+	}
+	return &Statement{
+		AccountID:      exportAccountID,
+		HolderName:     "Ana López",
+		Currency:       money.CurrencyMXN,
+		Period:         period,
+		OpeningBalance: money.MustParse("100.00"),
+		ClosingBalance: money.MustParse("6850.00"),
+		TotalCredits:   money.MustParse("15250.00"),
+		TotalDebits:    money.MustParse("8500.00"),
+		Lines:          lines,
+		GeneratedAt:    time.Date(2024, 4, 1, 6, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestTransactionService_StatementExports_Golden(t *testing.T) {
+	svc := NewTransactionService(&mockRepo{}, nil, &mockReader{}, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+
+	tests := []struct {
+		golden string
+		render func(*bytes.Buffer, *Statement) error
+	}{
+		{golden: "statement.ofx", render: func(b *bytes.Buffer, st *Statement) error { return svc.GenerateStatementOFX(b, st) }},
+		{golden: "statement.qfx", render: func(b *bytes.Buffer, st *Statement) error { return svc.GenerateStatementQFX(b, st) }},
+		{golden: "statement_camt053.xml", render: func(b *bytes.Buffer, st *Statement) error { return svc.GenerateStatementCAMT053(b, st) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.golden, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := test.render(&buf, exportStatement()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			golden := filepath.Join("testdata", test.golden)
+			if *updateGolden {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatalf("failed to update %s: %v", golden, err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", golden, err)
+			}
+			if buf.String() != string(want) {
+				t.Errorf("output does not match %s, rerun with -update to accept:\n%s", golden, buf.String())
+			}
+		})
+	}
+}
+
+// TestStatementOFX_Sample checks the sample file parses and balances.
+func TestStatementOFX_Sample(t *testing.T) {
+	for _, name := range []string{"statement.ofx", "statement.qfx"} {
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", name))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Contains(data, []byte(`<?OFX OFXHEADER="200" VERSION="220"`)) {
+				t.Error("expected an OFX 2.2 header")
+			}
+
+			var doc ofxDocument
+			if err := xml.Unmarshal(data, &doc); err != nil {
+				t.Fatalf("sample is not valid XML: %v", err)
+			}
+			if hasBID := doc.SignOn.IntuitBID != ""; hasBID != strings.HasSuffix(name, ".qfx") {
+				t.Errorf("expected INTU.BID only in QFX, got %q", doc.SignOn.IntuitBID)
+			}
+
+			st := exportStatement()
+			stmt := doc.Bank.Statement
+			if stmt.AccountID != st.AccountID || stmt.Currency != st.Currency || stmt.Start != "20240301000000" || stmt.End != "20240401000000" {
+				t.Errorf("unexpected account or period: %+v", stmt)
+			}
+			net := st.OpeningBalance
+			for _, tx := range stmt.Transactions {
+				if tx.ID == "" || len([]rune(tx.Name)) > ofxNameLength {
+					t.Errorf("invalid transaction %+v", tx)
+				}
+				net = net.Add(tx.Amount)
+			}
+			if len(stmt.Transactions) != len(st.Lines) || net != stmt.LedgerBalance || stmt.LedgerBalance != st.ClosingBalance {
+				t.Errorf("expected %d transactions from %s to %s, got %d ending at %s", len(st.Lines), st.OpeningBalance, st.ClosingBalance, len(stmt.Transactions), net)
+			}
+		})
+	}
+}
+
+// TestStatementCAMT053_Sample checks the sample file parses, uses the camt.053.001.02
+// namespace and that its balances, summary and entries agree.
+func TestStatementCAMT053_Sample(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "statement_camt053.xml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("sample is not valid XML: %v", err)
+	}
+	if doc.XMLName.Space != camt053Namespace {
+		t.Errorf("expected namespace %s, got %q", camt053Namespace, doc.XMLName.Space)
+	}
+
+	stmt := doc.Statement
+	if len(stmt.Balances) != 2 || stmt.Balances[0].Type != "OPBD" || stmt.Balances[1].Type != "CLBD" {
+		t.Fatalf("expected opening and closing balances, got %+v", stmt.Balances)
+	}
+
+	signed := func(a money.Amount, sign string) money.Amount {
+		if sign == "DBIT" {
+			return a.Neg()
+		}
+		return a
+	}
+	balance := signed(stmt.Balances[0].Amount.Value, stmt.Balances[0].Sign)
+	var credits, debits money.Amount
+	var reversals int
+	for _, entry := range stmt.Entries {
+		if entry.Status != "BOOK" || entry.Amount.Currency != stmt.Account.Currency || entry.Amount.Value.IsNegative() {
+			t.Errorf("invalid entry %+v", entry)
+		}
+		if entry.Reversal {
+			reversals++
+		}
+		if entry.Sign == "CRDT" {
+			credits = credits.Add(entry.Amount.Value)
+		} else {
+			debits = debits.Add(entry.Amount.Value)
+		}
+		balance = balance.Add(signed(entry.Amount.Value, entry.Sign))
+	}
+
+	if closing := signed(stmt.Balances[1].Amount.Value, stmt.Balances[1].Sign); balance != closing {
+		t.Errorf("entries lead to %s, closing balance is %s", balance, closing)
+	}
+	summary := stmt.Summary
+	if summary.Count != len(stmt.Entries) || summary.Credits.Sum != credits || summary.Debits.Sum != debits || summary.Sum != credits.Add(debits) {
+		t.Errorf("summary %+v does not match the entries", summary)
+	}
+	if signed(summary.Net, summary.NetSign) != credits.Sub(debits) {
+		t.Errorf("expected net %s, got %s %s", credits.Sub(debits), summary.NetSign, summary.Net)
+	}
+	if reversals != 1 {
+		t.Errorf("expected the refund to be flagged as a reversal, got %d", reversals)
+	}
+
+	// Max35Text and Max34Text in the camt.053.001.02 schema.
+	type field struct {
+		value string
+		max   int
+	}
+	fields := map[string]field{
+		"MsgId":        {doc.MessageID, 35},
+		"Stmt/Id":      {stmt.ID, 35},
+		"Stmt/Acct/Id": {stmt.Account.ID, 34},
+	}
+	for i, entry := range stmt.Entries {
+		fields[fmt.Sprintf("Ntry[%d]/NtryRef", i)] = field{entry.Reference, 35}
+		fields[fmt.Sprintf("Ntry[%d]/AcctSvcrRef", i)] = field{entry.ServicerRef, 35}
+		fields[fmt.Sprintf("Ntry[%d]/TxDtls/Refs/AcctSvcrRef", i)] = field{entry.Details.ServicerRef, 35}
+		for _, party := range []*camtPartyAccount{entry.Details.Debtor, entry.Details.Creditor} {
+			if party != nil {
+				fields[fmt.Sprintf("Ntry[%d]/RltdPties/Id", i)] = field{party.ID, 34}
+			}
+		}
+	}
+	for name, field := range fields {
+		if field.value == "" || len(field.value) > field.max {
+			t.Errorf("%s must have 1 to %d characters, got %q", name, field.max, field.value)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package transactions
+
+import (
+	"fmt"
+	"go-bank-app/pkg/money"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	pdfMargin       = 10.0
+	pdfRowHeight    = 6.0
+	pdfFooterHeight = 20.0
+)
+
+type pdfColumn struct {
+	title string
+	width float64
+	align string
+}
+
+// statementColumns span the 190mm between the margins of an A4 page.
+var statementColumns = []pdfColumn{
+	{title: "Date", width: 22, align: "L"},
+	{title: "Description", width: 70, align: "L"},
+	{title: "Reference", width: 26, align: "L"},
+	{title: "Debit", width: 24, align: "R"},
+	{title: "Credit", width: 24, align: "R"},
+	{title: "Balance", width: 24, align: "R"},
+}
+
+// GenerateStatementPDF implements TransactionService. The first page carries the account
+// details and a summary of the period; the transaction table continues over as many pages
+// as needed, repeating its header, and ends with the totals.
+func (s *transactionService) GenerateStatementPDF(w io.Writer, st *Statement) error {
+	r := &statementPDF{pdf: gofpdf.New("P", "mm", "A4", ""), st: st}
+	r.tr = r.pdf.UnicodeTranslatorFromDescriptor("")
+
+	r.pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	// Page breaks are handled by addRow so the table header can be repeated.
+	r.pdf.SetAutoPageBreak(false, 0)
+	r.pdf.AliasNbPages("")
+	r.pdf.SetFooterFunc(r.footer)
+
+	r.pdf.AddPage()
+	r.header()
+	r.summary()
+	r.tableHeader()
+
+	r.addRow(r.st.Period.Start.Format(time.DateOnly), "Opening balance", "", "", "", formatAmount(st.OpeningBalance), true)
+	for _, line := range st.Lines {
+		debit, credit := "", ""
+		if line.Amount.IsNegative() {
+			debit = formatAmount(line.Amount.Neg())
+		} else {
+			credit = formatAmount(line.Amount)
+		}
+		tx := line.Transaction
+		r.addRow(line.PostedAt.Format(time.DateOnly), tx.Description, shortReference(tx.ID), debit, credit, formatAmount(line.Balance), false)
+	}
+	if len(st.Lines) == 0 {
+		r.addRow("", "No transactions in this period", "", "", "", "", false)
+	}
+	r.totals()
+
+	return r.pdf.Output(w)
+}
+
+// statementPDF renders one statement.
+type statementPDF struct {
+	pdf  *gofpdf.Fpdf
+	tr   func(string) string
+	st   *Statement
+	rows int
+}
+
+func (r *statementPDF) periodText() string {
+	return fmt.Sprintf("%s to %s", r.st.Period.Start.Format(time.DateOnly), r.st.Period.LastDay().Format(time.DateOnly))
+}
+
+func (r *statementPDF) header() {
+	pdf := r.pdf
+	pdf.SetFont("Arial", "B", 18)
+	pdf.CellFormat(110, 10, "Account statement", "", 0, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(80, 10, r.periodText(), "", 1, "R", false, 0, "")
+
+	pdf.SetDrawColor(60, 60, 60)
+	pdf.Line(pdfMargin, pdf.GetY(), pdfMargin+190, pdf.GetY())
+	pdf.Ln(3)
+
+	holder := r.st.HolderName
+	if holder == "" {
+		holder = "-"
+	}
+	for _, field := range [][2]string{
+		{"Account holder", r.tr(holder)},
+		{"Account number", maskAccountID(r.st.AccountID)},
+		{"Currency", string(r.st.Currency)},
+	} {
+		pdf.SetFont("Arial", "", 10)
+		pdf.CellFormat(35, 6, field[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "B", 10)
+		pdf.CellFormat(155, 6, field[1], "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+}
+
+// summary draws the balances and the money in and out over the period.
+func (r *statementPDF) summary() {
+	pdf := r.pdf
+	credits, debits := 0, 0
+	for _, line := range r.st.Lines {
+		if line.Amount.IsNegative() {
+			debits++
+		} else {
+			credits++
+		}
+	}
+
+	boxes := [][2]string{
+		{"Opening balance", formatAmount(r.st.OpeningBalance)},
+		{fmt.Sprintf("Money in (%d)", credits), formatAmount(r.st.TotalCredits)},
+		{fmt.Sprintf("Money out (%d)", debits), formatAmount(r.st.TotalDebits)},
+		{"Closing balance", formatAmount(r.st.ClosingBalance)},
+	}
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetFont("Arial", "", 9)
+	for _, box := range boxes {
+		pdf.CellFormat(47.5, 6, box[0], "LTR", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Arial", "B", 12)
+	for _, box := range boxes {
+		pdf.CellFormat(47.5, 8, box[1], "LBR", 0, "C", true, 0, "")
+	}
+	pdf.Ln(12)
+}
+
+// continuation heads the pages after the first.
+func (r *statementPDF) continuation() {
+	pdf := r.pdf
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(110, 8, "Account statement (continued)", "", 0, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	pdf.CellFormat(80, 8, maskAccountID(r.st.AccountID)+"  |  "+r.periodText(), "", 1, "R", false, 0, "")
+	pdf.Ln(2)
+}
+
+func (r *statementPDF) tableHeader() {
+	pdf := r.pdf
+	pdf.SetFont("Arial", "B", 9)
+	pdf.SetFillColor(45, 62, 80)
+	pdf.SetTextColor(255, 255, 255)
+	for _, col := range statementColumns {
+		pdf.CellFormat(col.width, 7, col.title, "", 0, col.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// ensureRoom starts a new page with the table header when the next row would run into
+// the footer.
+func (r *statementPDF) ensureRoom(height float64) {
+	_, pageHeight := r.pdf.GetPageSize()
+	if r.pdf.GetY()+height <= pageHeight-pdfFooterHeight {
+		return
+	}
+	r.pdf.AddPage()
+	r.continuation()
+	r.tableHeader()
+}
+
+func (r *statementPDF) addRow(date, description, reference, debit, credit, balance string, bold bool) {
+	r.ensureRoom(pdfRowHeight)
+	pdf := r.pdf
+
+	style := ""
+	if bold {
+		style = "B"
+	}
+	pdf.SetFont("Arial", style, 9)
+	fill := r.rows%2 == 1
+	pdf.SetFillColor(246, 246, 246)
+	r.rows++
+
+	values := []string{date, r.fit(r.tr(description), statementColumns[1].width), reference, debit, credit, balance}
+	for i, col := range statementColumns {
+		pdf.CellFormat(col.width, pdfRowHeight, values[i], "B", 0, col.align, fill, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+func (r *statementPDF) totals() {
+	r.ensureRoom(pdfRowHeight + 2)
+	pdf := r.pdf
+	pdf.Ln(2)
+	pdf.SetFont("Arial", "B", 9)
+	pdf.SetFillColor(225, 225, 225)
+
+	labelWidth := statementColumns[0].width + statementColumns[1].width + statementColumns[2].width
+	pdf.CellFormat(labelWidth, pdfRowHeight+1, "Totals for the period", "T", 0, "L", true, 0, "")
+	pdf.CellFormat(statementColumns[3].width, pdfRowHeight+1, formatAmount(r.st.TotalDebits), "T", 0, "R", true, 0, "")
+	pdf.CellFormat(statementColumns[4].width, pdfRowHeight+1, formatAmount(r.st.TotalCredits), "T", 0, "R", true, 0, "")
+	pdf.CellFormat(statementColumns[5].width, pdfRowHeight+1, formatAmount(r.st.ClosingBalance), "T", 1, "R", true, 0, "")
+}
+
+func (r *statementPDF) footer() {
+	pdf := r.pdf
+	pdf.SetY(-15)
+	pdf.SetFont("Arial", "I", 8)
+	pdf.SetTextColor(110, 110, 110)
+	pdf.CellFormat(95, 5, fmt.Sprintf("Amounts in %s. Generated for account %s.", r.st.Currency, maskAccountID(r.st.AccountID)), "", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 5, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// fit shortens text with an ellipsis until it fits a cell of the given width.
+func (r *statementPDF) fit(text string, width float64) string {
+	const padding = 2
+	if r.pdf.GetStringWidth(text) <= width-padding {
+		return text
+	}
+	for len(text) > 0 && r.pdf.GetStringWidth(text+"...") > width-padding {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
+
+// maskAccountID shows only the last four characters of an account ID.
+func maskAccountID(id string) string {
+	id = strings.ReplaceAll(id, "-", "")
+	if len(id) <= 4 {
+		return id
+	}
+	return "**** " + strings.ToUpper(id[len(id)-4:])
+}
+
+// shortReference is the first block of a transaction ID, enough to find it again.
+func shortReference(id string) string {
+	if i := strings.IndexByte(id, '-'); i > 0 {
+		return strings.ToUpper(id[:i])
+	}
+	return strings.ToUpper(id)
+}
+
+// formatAmount groups the thousands of an amount: 1,234,567.89.
+func formatAmount(a money.Amount) string {
+	s := a.String()
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, fraction, _ := strings.Cut(s, ".")
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	if fraction != "" {
+		return sign + whole + "." + fraction
+	}
+	return sign + whole
+}
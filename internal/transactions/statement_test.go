@@ -0,0 +1,383 @@
+package transactions
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"go-bank-app/pkg/csvwriter"
+	"go-bank-app/pkg/money"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseStatementPeriod(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		query    url.Values
+		expected StatementPeriod
+		label    string
+		invalid  bool
+	}{
+		{name: "current month", query: url.Values{}, expected: StatementPeriod{day(2024, 3, 1), day(2024, 4, 1)}, label: "2024-03"},
+		{name: "month", query: url.Values{"month": {"2023-12"}}, expected: StatementPeriod{day(2023, 12, 1), day(2024, 1, 1)}, label: "2023-12"},
+		{name: "range", query: url.Values{"from": {"2024-02-10"}, "to": {"2024-03-09"}}, expected: StatementPeriod{day(2024, 2, 10), day(2024, 3, 10)}, label: "2024-02-10_2024-03-09"},
+		{name: "range of a whole month", query: url.Values{"from": {"2024-02-01"}, "to": {"2024-02-29"}}, expected: StatementPeriod{day(2024, 2, 1), day(2024, 3, 1)}, label: "2024-02"},
+		{name: "month and range", query: url.Values{"month": {"2024-01"}, "from": {"2024-01-01"}}, invalid: true},
+		{name: "bad month", query: url.Values{"month": {"March"}}, invalid: true},
+		{name: "open range", query: url.Values{"from": {"2024-01-01"}}, invalid: true},
+		{name: "reversed range", query: url.Values{"from": {"2024-02-10"}, "to": {"2024-02-01"}}, invalid: true},
+		{name: "range too long", query: url.Values{"from": {"2022-01-01"}, "to": {"2024-01-01"}}, invalid: true},
+		{name: "future month", query: url.Values{"month": {"2024-04"}}, invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			period, err := ParseStatementPeriod(test.query, now)
+			if test.invalid {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", period)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if period != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, period)
+			}
+			if period.Label() != test.label {
+				t.Errorf("expected label %s, got %s", test.label, period.Label())
+			}
+		})
+	}
+}
+
+func statementFixture() (StatementPeriod, *mockRepo, *mockReader) {
+	period := MonthPeriod(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	// Failed and pending transfers never moved money, so they have no postings.
+	repo := &mockRepo{posted: map[string][]StatementLine{
+		"acc123": {
+			{
+				Transaction: Transaction{ID: "tx1", Status: TransactionStatusCompleted, ToAccountID: "acc123", Amount: money.MustParse("20.00"), DestinationAmount: money.MustParse("365.00"), Description: "Deposit", CreatedAt: period.Start.Add(9 * time.Hour)},
+				Amount:      money.MustParse("365.00"),
+				PostedAt:    period.Start.Add(9 * time.Hour),
+			},
+			{
+				Transaction: Transaction{ID: "tx3", Status: TransactionStatusCompleted, FromAccountID: "acc123", ToAccountID: "acc456", Amount: money.MustParse("125.50"), DestinationAmount: money.MustParse("125.50"), Description: "Rent", CreatedAt: period.Start.Add(48 * time.Hour)},
+				Amount:      money.MustParse("-125.50"),
+				PostedAt:    period.Start.Add(48 * time.Hour),
+			},
+		},
+	}}
+	reader := &mockReader{
+		acc: &AccountInfo{ID: "acc123", UserID: "user1", Currency: money.CurrencyMXN},
+		balances: map[int64]money.Amount{
+			period.Start.Unix(): money.MustParse("100.00"),
+			period.End.Unix():   money.MustParse("339.50"),
+		},
+		holders: map[string]string{"user1": "Ana López"},
+	}
+	return period, repo, reader
+}
+
+func TestTransactionService_GetStatement(t *testing.T) {
+	period, repo, reader := statementFixture()
+	svc := NewTransactionService(repo, nil, reader, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+
+	st, err := svc.GetStatement(context.Background(), "user1", "acc123", period)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reader.gotAccountID != "acc123" {
+		t.Errorf("expected the account to be resolved for the user, got %q", reader.gotAccountID)
+	}
+	if repo.gotAccountID != "acc123" || repo.gotPeriod != period {
+		t.Errorf("expected the postings of acc123 in the period, got %s %+v", repo.gotAccountID, repo.gotPeriod)
+	}
+	if st.OpeningBalance != money.MustParse("100.00") || st.ClosingBalance != money.MustParse("339.50") {
+		t.Errorf("expected balances 100.00 -> 339.50, got %s -> %s", st.OpeningBalance, st.ClosingBalance)
+	}
+
+	if st.HolderName != "Ana López" {
+		t.Errorf("expected the holder name, got %q", st.HolderName)
+	}
+	if st.TotalCredits != money.MustParse("365.00") || st.TotalDebits != money.MustParse("125.50") {
+		t.Errorf("expected 365.00 in and 125.50 out, got %s and %s", st.TotalCredits, st.TotalDebits)
+	}
+
+	expected := []struct{ id, amount, balance string }{
+		{"tx1", "365.00", "465.00"},
+		{"tx3", "-125.50", "339.50"},
+	}
+	if len(st.Lines) != len(expected) {
+		t.Fatalf("expected %d lines, got %+v", len(expected), st.Lines)
+	}
+	for i, want := range expected {
+		line := st.Lines[i]
+		if line.Transaction.ID != want.id || line.Amount != money.MustParse(want.amount) || line.Balance != money.MustParse(want.balance) {
+			t.Errorf("line %d: expected %s %s -> %s, got %s %s -> %s", i, want.id, want.amount, want.balance, line.Transaction.ID, line.Amount, line.Balance)
+		}
+	}
+	if st.Filename("pdf") != "statement-acc123-2024-03.pdf" {
+		t.Errorf("unexpected filename %s", st.Filename("pdf"))
+	}
+}
+
+func TestTransactionService_GetStatement_PostedInAnotherPeriod(t *testing.T) {
+	period := MonthPeriod(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	repo := &mockRepo{posted: map[string][]StatementLine{
+		"acc123": {
+			// Held in February, approved and posted in March.
+			{
+				Transaction: Transaction{ID: "held", Status: TransactionStatusCompleted, FromAccountID: "acc123", ToAccountID: "acc456", Amount: money.MustParse("50.00"), CreatedAt: period.Start.Add(-2 * time.Hour)},
+				Amount:      money.MustParse("-50.00"),
+				PostedAt:    period.Start.Add(10 * time.Hour),
+			},
+			// Requested at the end of March, posted in April.
+			{
+				Transaction: Transaction{ID: "late", Status: TransactionStatusCompleted, FromAccountID: "acc123", ToAccountID: "acc456", Amount: money.MustParse("20.00"), CreatedAt: period.End.Add(-time.Second)},
+				Amount:      money.MustParse("-20.00"),
+				PostedAt:    period.End.Add(time.Second),
+			},
+		},
+	}}
+	reader := &mockReader{
+		acc: &AccountInfo{ID: "acc123", UserID: "user1", Currency: money.CurrencyMXN},
+		balances: map[int64]money.Amount{
+			period.Start.Unix(): money.MustParse("100.00"),
+			period.End.Unix():   money.MustParse("50.00"),
+		},
+	}
+	svc := NewTransactionService(repo, nil, reader, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+
+	st, err := svc.GetStatement(context.Background(), "user1", "acc123", period)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(st.Lines) != 1 || st.Lines[0].Transaction.ID != "held" {
+		t.Fatalf("expected only the transfer posted in March, got %+v", st.Lines)
+	}
+	if st.Lines[0].Balance != st.ClosingBalance || st.TotalDebits != money.MustParse("50.00") {
+		t.Errorf("expected the lines to add up to the closing balance %s, got %s", st.ClosingBalance, st.Lines[0].Balance)
+	}
+
+	var buf bytes.Buffer
+	if err := svc.GenerateStatementCSV(&buf, st, csvwriter.Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "2024-03-01 10:00:00,held,") {
+		t.Errorf("expected the line to be dated when it was posted, got:\n%s", buf.String())
+	}
+}
+
+func TestTransactionService_GenerateStatementCSV(t *testing.T) {
+	period, repo, reader := statementFixture()
+	svc := NewTransactionService(repo, nil, reader, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+	st, err := svc.GetStatement(context.Background(), "user1", "", period)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := svc.GenerateStatementCSV(&buf, st, csvwriter.Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := [][]string{
+		{"Date", "Transaction ID", "Description", "From Account", "To Account", "Amount", "Currency", "Balance"},
+		{"2024-03-01", "", "Opening balance", "", "", "", "MXN", "100.00"},
+		{"2024-03-01 09:00:00", "tx1", "Deposit", "", "acc123", "365.00", "MXN", "465.00"},
+		{"2024-03-03 00:00:00", "tx3", "Rent", "acc123", "acc456", "-125.50", "MXN", "339.50"},
+		{"2024-03-31", "", "Closing balance", "", "", "", "MXN", "339.50"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected %v, got %v", expected, records)
+	}
+}
+
+func TestTransactionService_GenerateStatementCSV_Options(t *testing.T) {
+	period, repo, reader := statementFixture()
+	repo.posted["acc123"][1].Transaction.Description = `=HYPERLINK("http://evil.example","Rent")`
+	svc := NewTransactionService(repo, nil, reader, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+	st, err := svc.GetStatement(context.Background(), "user1", "", period)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := svc.GenerateStatementCSV(&buf, st, csvwriter.Options{Delimiter: ';', BOM: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, ok := bytes.CutPrefix(buf.Bytes(), []byte("\xEF\xBB\xBF"))
+	if !ok {
+		t.Fatal("expected the output to start with a byte order mark")
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = ';'
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := records[3][2]; got != `'=HYPERLINK("http://evil.example","Rent")` {
+		t.Errorf("expected the formula to be escaped, got %s", got)
+	}
+	// Amounts are not user input and keep their sign.
+	if got := records[3][5]; got != "-125.50" {
+		t.Errorf("expected -125.50, got %s", got)
+	}
+}
+
+func TestTransactionService_GenerateStatementPDF(t *testing.T) {
+	period, repo, reader := statementFixture()
+	svc := NewTransactionService(repo, nil, reader, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+	withLines, err := svc.GetStatement(context.Background(), "user1", "", period)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statements := map[string]*Statement{
+		"empty": {AccountID: "acc123", Currency: money.CurrencyMXN, Period: period},
+		"lines": withLines,
+	}
+	for name, st := range statements {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := svc.GenerateStatementPDF(&buf, st); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+				t.Errorf("expected a PDF document, got %q", buf.Bytes()[:min(buf.Len(), 16)])
+			}
+		})
+	}
+}
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// longStatement spans more than one page.
+func longStatement() *Statement {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	st := &Statement{
+		AccountID:      "3f6c1a2e-8d4b-4c1f-9a7e-5b2d1c0e9f84",
+		HolderName:     "Ana María López",
+		Currency:       money.CurrencyMXN,
+		Period:         MonthPeriod(start),
+		OpeningBalance: money.MustParse("12500.00"),
+	}
+
+	balance := st.OpeningBalance
+	for i := 0; i < 45; i++ {
+		tx := Transaction{
+			ID:          fmt.Sprintf("%08x-0000-4000-8000-000000000000", i+1),
+			Description: fmt.Sprintf("Café payment %d", i+1),
+			CreatedAt:   start.Add(time.Duration(i) * 14 * time.Hour),
+		}
+		amount := money.FromMinor(int64(1000 + i*2575))
+		if i%3 == 0 {
+			st.TotalCredits = st.TotalCredits.Add(amount)
+			tx.Description = "Payroll deposit from a very long employer name that cannot fit in the column"
+		} else {
+			st.TotalDebits = st.TotalDebits.Add(amount)
+			amount = amount.Neg()
+		}
+		balance = balance.Add(amount)
+		st.Lines = append(st.Lines, StatementLine{Transaction: tx, Amount: amount, Balance: balance, PostedAt: tx.CreatedAt})
+	}
+	st.ClosingBalance = balance
+	return st
+}
+
+func TestTransactionService_GenerateStatementPDF_Golden(t *testing.T) {
+	svc := NewTransactionService(&mockRepo{}, nil, &mockReader{}, nil, nil, nil, FundingLimits{}, nil, nil, nil)
+
+	var buf bytes.Buffer
+	if err := svc.GenerateStatementPDF(&buf, longStatement()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := pdfText(t, buf.Bytes())
+
+	golden := filepath.Join("testdata", "statement_pdf.golden")
+	if *updateGolden {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatalf("failed to update %s: %v", golden, err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", golden, err)
+	}
+	if got != string(want) {
+		t.Errorf("PDF text does not match %s, rerun with -update to accept:\n%s", golden, got)
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := map[string]string{
+		"0.00":        "0.00",
+		"999.99":      "999.99",
+		"1000.00":     "1,000.00",
+		"-1234567.89": "-1,234,567.89",
+	}
+	for in, want := range tests {
+		if got := formatAmount(money.MustParse(in)); got != want {
+			t.Errorf("formatAmount(%s): expected %s, got %s", in, want, got)
+		}
+	}
+}
+
+var (
+	pdfStream = regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\nendstream`)
+	pdfShow   = regexp.MustCompile(`\(((?:\\.|[^\\)])*)\) ?Tj`)
+	pdfEscape = strings.NewReplacer(`\(`, "(", `\)`, ")", `\\`, `\`)
+)
+
+// pdfText returns the strings drawn by the page content streams of a PDF, one per line,
+// with a marker between pages. Text is converted back from the Latin-1 range of the core
+// fonts to UTF-8.
+func pdfText(t *testing.T, data []byte) string {
+	t.Helper()
+
+	var text strings.Builder
+	for _, match := range pdfStream.FindAllSubmatch(data, -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(match[1]))
+		if err != nil {
+			continue
+		}
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("failed to inflate stream: %v", err)
+		}
+		shown := pdfShow.FindAllSubmatch(content, -1)
+		if len(shown) == 0 {
+			continue
+		}
+		text.WriteString("--- page ---\n")
+		for _, s := range shown {
+			for _, b := range []byte(pdfEscape.Replace(string(s[1]))) {
+				text.WriteRune(rune(b))
+			}
+			text.WriteByte('\n')
+		}
+	}
+	return text.String()
+}
@@ -0,0 +1,72 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TransactionStatus tracks a transaction from the moment it is requested. Transactions
+// start pending and end completed or failed; completed ones can later be reversed.
+// Transfers held by fraud screening wait in held until a reviewer approves them, which
+// completes them with the balance update, or rejects them. Withdrawals are processing between the debit of the
+// account and the funding source confirming the payout, deposits between the funding
+// source collecting the money and the credit of the account.
+type TransactionStatus string
+
+const (
+	TransactionStatusPending    TransactionStatus = "pending"
+	TransactionStatusCompleted  TransactionStatus = "completed"
+	TransactionStatusFailed     TransactionStatus = "failed"
+	TransactionStatusReversed   TransactionStatus = "reversed"
+	TransactionStatusHeld       TransactionStatus = "held"
+	TransactionStatusProcessing TransactionStatus = "processing"
+)
+
+var ErrInvalidStatusTransition = errors.New("invalid transaction status transition")
+
+// statusTransitions lists the statuses each status can move to.
+var statusTransitions = map[TransactionStatus][]TransactionStatus{
+	TransactionStatusPending:    {TransactionStatusCompleted, TransactionStatusFailed, TransactionStatusProcessing},
+	TransactionStatusCompleted:  {TransactionStatusReversed},
+	TransactionStatusHeld:       {TransactionStatusCompleted, TransactionStatusFailed},
+	TransactionStatusProcessing: {TransactionStatusCompleted, TransactionStatusFailed},
+}
+
+// CanTransitionTo reports whether a transaction in status s may move to next.
+func (s TransactionStatus) CanTransitionTo(next TransactionStatus) bool {
+	for _, allowed := range statusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid reports whether s is a known status.
+func (s TransactionStatus) Valid() bool {
+	switch s {
+	case TransactionStatusPending, TransactionStatusCompleted, TransactionStatusFailed, TransactionStatusReversed, TransactionStatusHeld,
+		TransactionStatusProcessing:
+		return true
+	}
+	return false
+}
+
+// setStatus moves the transaction to next through repo, which may be bound to the
+// balance update's database transaction. The row is only updated if it is still in the
+// status the transaction was read with.
+func setStatus(ctx context.Context, repo TransactionRepository, tx *Transaction, next TransactionStatus, reason string) error {
+	if !tx.Status.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, tx.Status, next)
+	}
+
+	previous := tx.Status
+	tx.Status, tx.FailureReason, tx.UpdatedAt = next, reason, time.Now()
+	if err := repo.UpdateStatus(ctx, tx, previous); err != nil {
+		tx.Status = previous
+		return err
+	}
+	return nil
+}
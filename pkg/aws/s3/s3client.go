@@ -3,7 +3,7 @@ package s3
 import (
 	"bytes"
 	"context"
-	"fmt"
+	"errors"
 	"io"
 	"time"
 
@@ -13,54 +13,82 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
+// Config points the client at a bucket on AWS or on an S3-compatible server.
+type Config struct {
+	Bucket string
+	Region string
+	// Endpoint overrides the AWS endpoint, e.g. http://localhost:4566 for LocalStack.
+	Endpoint string
+	// PresignEndpoint is the endpoint written into presigned URLs, for when clients reach
+	// the server under another address than this service does. Defaults to Endpoint.
+	PresignEndpoint string
+	// UsePathStyle addresses buckets as endpoint/bucket instead of bucket.endpoint, which
+	// most S3-compatible servers need.
+	UsePathStyle bool
+	// Static credentials. When empty the default AWS credential chain is used.
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
 type Client struct {
 	s3Client *s3.Client
+	presign  *s3.PresignClient
 	bucket   string
 }
 
-func new(bucket string) (*Client, error) {
-	customResolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
-		if service == s3.ServiceID {
-			return aws.Endpoint{
-				URL:           "http://localhost:4566",
-				SigningRegion: "us-east-1",
-			}, nil
-		}
-		return aws.Endpoint{}, fmt.Errorf("unknown endpoint requested")
-	})
-
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion("us-east-1"),
-		config.WithEndpointResolverWithOptions(customResolver),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("test", "test", "")),
-	)
+func New(ctx context.Context, cfg Config) (*Client, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3: bucket is required")
+	}
 
+	opts := []func(*config.LoadOptions) error{config.WithRegion(cfg.Region)}
+	if cfg.AccessKeyID != "" {
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	client := s3.NewFromConfig(cfg)
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.UsePathStyle
+		// S3-compatible servers do not all understand the checksums newer SDKs add to
+		// every upload.
+		o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+	})
+
+	presign := s3.NewPresignClient(client, func(o *s3.PresignOptions) {
+		if cfg.PresignEndpoint != "" {
+			o.ClientOptions = append(o.ClientOptions, func(o *s3.Options) {
+				o.BaseEndpoint = aws.String(cfg.PresignEndpoint)
+			})
+		}
+	})
 
 	return &Client{
 		s3Client: client,
-		bucket:   bucket,
+		presign:  presign,
+		bucket:   cfg.Bucket,
 	}, nil
 }
 
-func (c *Client) UploadFile(ctx context.Context, key string, content []byte) error {
+func (c *Client) UploadFile(ctx context.Context, key string, content []byte, contentType string) error {
 	_, err := c.s3Client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(c.bucket),
-		Key:    aws.String(key),
-		Body:   bytes.NewReader(content),
+		Bucket:      aws.String(c.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(content),
+		ContentType: aws.String(contentType),
 	})
 
 	return err
 }
 
 func (c *Client) GeneratePresignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
-	ps := s3.NewPresignClient(c.s3Client)
-
-	resp, err := ps.PresignGetObject(ctx, &s3.GetObjectInput{
+	resp, err := c.presign.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(c.bucket),
 		Key:    aws.String(key),
 	}, s3.WithPresignExpires(expires))
@@ -0,0 +1,119 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go-bank-app/pkg/aws/s3/s3test"
+)
+
+func newTestClient(t *testing.T, server *s3test.Server, presignEndpoint string) *Client {
+	t.Helper()
+	client, err := New(context.Background(), Config{
+		Bucket:          "statements",
+		Region:          "us-east-1",
+		Endpoint:        server.URL,
+		PresignEndpoint: presignEndpoint,
+		UsePathStyle:    true,
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return client
+}
+
+func TestNew_RequiresBucket(t *testing.T) {
+	if _, err := New(context.Background(), Config{Region: "us-east-1"}); err == nil {
+		t.Fatal("expected an error without a bucket")
+	}
+}
+
+func TestClient_UploadAndDownload(t *testing.T) {
+	server := s3test.NewServer(t)
+	client := newTestClient(t, server, "")
+	ctx := context.Background()
+
+	if err := client.UploadFile(ctx, "statements/acc123/2024/2024-03.pdf", []byte("%PDF-1.3"), "application/pdf"); err != nil {
+		t.Fatalf("UploadFile() error = %v", err)
+	}
+
+	obj, ok := server.Object("statements", "statements/acc123/2024/2024-03.pdf")
+	if !ok {
+		t.Fatal("object was not stored in the bucket")
+	}
+	if obj.ContentType != "application/pdf" {
+		t.Errorf("content type = %q, want application/pdf", obj.ContentType)
+	}
+
+	content, err := client.Download(ctx, "statements/acc123/2024/2024-03.pdf")
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if string(content) != "%PDF-1.3" {
+		t.Errorf("Download() = %q, want %q", content, "%PDF-1.3")
+	}
+}
+
+func TestClient_DownloadMissing(t *testing.T) {
+	client := newTestClient(t, s3test.NewServer(t), "")
+
+	if _, err := client.Download(context.Background(), "missing.pdf"); err == nil {
+		t.Fatal("expected an error for a missing key")
+	}
+}
+
+func TestClient_GeneratePresignedURL(t *testing.T) {
+	server := s3test.NewServer(t)
+	client := newTestClient(t, server, "")
+	ctx := context.Background()
+
+	if err := client.UploadFile(ctx, "statements/acc123/2024/2024-03.pdf", []byte("%PDF-1.3"), "application/pdf"); err != nil {
+		t.Fatalf("UploadFile() error = %v", err)
+	}
+
+	link, err := client.GeneratePresignedURL(ctx, "statements/acc123/2024/2024-03.pdf", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("GeneratePresignedURL() error = %v", err)
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("invalid URL %q: %v", link, err)
+	}
+	if u.Path != "/statements/statements/acc123/2024/2024-03.pdf" {
+		t.Errorf("path = %q, want the key in the bucket", u.Path)
+	}
+	if got := u.Query().Get("X-Amz-Expires"); got != "900" {
+		t.Errorf("X-Amz-Expires = %q, want 900", got)
+	}
+
+	// The link works without credentials.
+	resp, err := http.Get(link)
+	if err != nil {
+		t.Fatalf("GET presigned URL: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "%PDF-1.3" {
+		t.Errorf("GET presigned URL = %d %q, want 200 with the object", resp.StatusCode, body)
+	}
+}
+
+func TestClient_GeneratePresignedURL_PresignEndpoint(t *testing.T) {
+	client := newTestClient(t, s3test.NewServer(t), "https://files.example.com")
+
+	link, err := client.GeneratePresignedURL(context.Background(), "statements/acc123/2024/2024-03.pdf", time.Minute)
+	if err != nil {
+		t.Fatalf("GeneratePresignedURL() error = %v", err)
+	}
+	if !strings.HasPrefix(link, "https://files.example.com/statements/statements/acc123/") {
+		t.Errorf("GeneratePresignedURL() = %q, want it on the presign endpoint", link)
+	}
+}
@@ -0,0 +1,116 @@
+// Package s3test provides an in-memory S3-compatible server for tests.
+package s3test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Object is a stored object.
+type Object struct {
+	Content     []byte
+	ContentType string
+}
+
+// Server stores objects put into any bucket and serves them back, addressed path style
+// as /bucket/key. Requests must be signed, either in the Authorization header or as a
+// presigned URL that has not expired; signatures themselves are not verified.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string]Object
+}
+
+// NewServer starts a server, closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	s := &Server{objects: make(map[string]Object)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Object returns the object stored under bucket and key.
+func (s *Server) Object(bucket, key string) (Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[bucket+"/"+key]
+	return obj, ok
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if code, msg := checkSigned(r); code != "" {
+		writeError(w, http.StatusForbidden, code, msg)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if !strings.Contains(path, "/") {
+		writeError(w, http.StatusNotImplemented, "NotImplemented", "only object operations are supported")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		content, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		s.mu.Lock()
+		s.objects[path] = Object{Content: content, ContentType: r.Header.Get("Content-Type")}
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		s.mu.Lock()
+		obj, ok := s.objects[path]
+		s.mu.Unlock()
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		w.Header().Set("Content-Type", obj.ContentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.Content)))
+		if r.Method == http.MethodGet {
+			w.Write(obj.Content)
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "method not allowed")
+	}
+}
+
+func checkSigned(r *http.Request) (code, msg string) {
+	if strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		return "", ""
+	}
+
+	query := r.URL.Query()
+	if query.Get("X-Amz-Signature") == "" {
+		return "AccessDenied", "request is not signed"
+	}
+	signedAt, err := time.Parse("20060102T150405Z", query.Get("X-Amz-Date"))
+	if err != nil {
+		return "AuthorizationQueryParametersError", "invalid X-Amz-Date"
+	}
+	expires, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+	if err != nil {
+		return "AuthorizationQueryParametersError", "invalid X-Amz-Expires"
+	}
+	if time.Now().After(signedAt.Add(time.Duration(expires) * time.Second)) {
+		return "AccessDenied", "Request has expired"
+	}
+	return "", ""
+}
+
+func writeError(w http.ResponseWriter, status int, code, msg string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, msg)
+}
@@ -3,9 +3,20 @@ package csvwriter
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 )
 
+// utf8BOM lets spreadsheet applications such as Excel detect UTF-8 files.
+const utf8BOM = "\xEF\xBB\xBF"
+
+// Options configure how rows are encoded.
+type Options struct {
+	Delimiter rune // Defaults to a comma
+	BOM       bool // Starts the output with a UTF-8 byte order mark
+}
+
 type CSVWriter struct {
 	headers []string
 	rows    [][]string
@@ -33,18 +44,72 @@ func (w *CSVWriter) WriteToFile(filepath string) error {
 	}
 	defer f.Close()
 
-	writer := csv.NewWriter(f)
-	defer writer.Flush()
+	return w.Write(f)
+}
 
-	if err := writer.Write(w.headers); err != nil {
+// Write writes the headers and the rows to out.
+func (w *CSVWriter) Write(out io.Writer) error {
+	stream, err := NewStreamWriter(out, w.headers, Options{})
+	if err != nil {
 		return err
 	}
 
 	for _, row := range w.rows {
-		if err := writer.Write(row); err != nil {
+		if err := stream.WriteRow(row); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return stream.Flush()
+}
+
+// StreamWriter writes rows to an io.Writer as they come instead of holding them in
+// memory, which suits large exports sent straight to an HTTP response.
+type StreamWriter struct {
+	writer  *csv.Writer
+	columns int
+}
+
+// NewStreamWriter writes the byte order mark, if asked for, and the headers to out.
+func NewStreamWriter(out io.Writer, headers []string, opts Options) (*StreamWriter, error) {
+	writer := csv.NewWriter(out)
+	if opts.Delimiter != 0 {
+		writer.Comma = opts.Delimiter
+	}
+
+	if opts.BOM {
+		if _, err := io.WriteString(out, utf8BOM); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &StreamWriter{writer: writer, columns: len(headers)}
+	if err := writer.Write(headers); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// WriteRow encodes one row. Rows are buffered in small chunks; Flush sends what is left.
+func (s *StreamWriter) WriteRow(row []string) error {
+	if len(row) != s.columns {
+		return fmt.Errorf("row length (%d) does not match header length (%d)", len(row), s.columns)
+	}
+	return s.writer.Write(row)
+}
+
+// Flush writes any buffered rows and reports the first error met while writing.
+func (s *StreamWriter) Flush() error {
+	s.writer.Flush()
+	return s.writer.Error()
+}
+
+// EscapeFormula neutralises values that spreadsheet applications would evaluate as a
+// formula, such as =HYPERLINK(...), by prefixing them with a single quote. Use it on
+// free text supplied by users, not on numbers, which may legitimately start with a sign.
+func EscapeFormula(value string) string {
+	if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return value
+	}
+	return "'" + value
 }
@@ -0,0 +1,82 @@
+package csvwriter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStreamWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{name: "defaults", expected: "Date,Description\n2024-03-01,\"Rent, March\"\n"},
+		{name: "semicolon", opts: Options{Delimiter: ';'}, expected: "Date;Description\n2024-03-01;Rent, March\n"},
+		{name: "tab and BOM", opts: Options{Delimiter: '\t', BOM: true}, expected: "\xEF\xBB\xBFDate\tDescription\n2024-03-01\tRent, March\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewStreamWriter(&buf, []string{"Date", "Description"}, test.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := w.WriteRow([]string{"2024-03-01", "Rent, March"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if buf.String() != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestStreamWriter_RowLength(t *testing.T) {
+	w, err := NewStreamWriter(&bytes.Buffer{}, []string{"a", "b"}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.WriteRow([]string{"only one"}); err == nil {
+		t.Error("expected error for a short row")
+	}
+}
+
+// failingWriter fails every write, like a client that went away.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, bytes.ErrTooLarge }
+
+func TestStreamWriter_ReportsWriteErrors(t *testing.T) {
+	w, err := NewStreamWriter(failingWriter{}, []string{"a"}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.WriteRow([]string{strings.Repeat("x", 10)})
+	if err := w.Flush(); err == nil {
+		t.Error("expected the write error to be reported")
+	}
+}
+
+func TestEscapeFormula(t *testing.T) {
+	tests := map[string]string{
+		"":                         "",
+		"Rent":                     "Rent",
+		"=HYPERLINK(\"http://x\")": "'=HYPERLINK(\"http://x\")",
+		"+1+1":                     "'+1+1",
+		"-2+3":                     "'-2+3",
+		"@SUM(A1:A2)":              "'@SUM(A1:A2)",
+		"\tcmd":                    "'\tcmd",
+		"Lunch = 12 + tip":         "Lunch = 12 + tip",
+	}
+	for in, want := range tests {
+		if got := EscapeFormula(in); got != want {
+			t.Errorf("EscapeFormula(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
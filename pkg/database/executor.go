@@ -0,0 +1,15 @@
+// Package database holds helpers shared by the Postgres repositories.
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Executor is implemented by both *sql.DB and *sql.Tx. Repositories that accept an
+// Executor can take part in a unit of work started by another package.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
@@ -0,0 +1,113 @@
+// Package fx provides exchange rates for converting money between currencies.
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-bank-app/pkg/money"
+	"math/big"
+	"os"
+	"time"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// RateDecimals is the precision rates are recorded with.
+const RateDecimals = 8
+
+// Rate converts amounts from one currency to another: 1 From = Value To.
+type Rate struct {
+	From  money.Currency
+	To    money.Currency
+	Value *big.Rat
+	AsOf  time.Time
+}
+
+// Identity returns the rate of a currency to itself.
+func Identity(currency money.Currency) Rate {
+	return Rate{From: currency, To: currency, Value: big.NewRat(1, 1), AsOf: time.Now()}
+}
+
+// Convert applies the rate to an amount in the From currency, rounding half to even.
+func (r Rate) Convert(amount money.Amount) (money.Amount, error) {
+	return money.Round(new(big.Rat).Mul(amount.Rat(), r.Value), money.RoundHalfEven)
+}
+
+// Invert returns the rate for the opposite direction.
+func (r Rate) Invert() Rate {
+	return Rate{From: r.To, To: r.From, Value: new(big.Rat).Inv(r.Value), AsOf: r.AsOf}
+}
+
+// String formats the rate with RateDecimals decimal places.
+func (r Rate) String() string {
+	return r.Value.FloatString(RateDecimals)
+}
+
+// Provider looks up the rate between two currencies.
+type Provider interface {
+	Rate(ctx context.Context, from, to money.Currency) (Rate, error)
+}
+
+// rateFile is the on-disk format read by FileProvider. Every rate is the price of one
+// unit of Base in the given currency.
+type rateFile struct {
+	Base  money.Currency            `json:"base"`
+	AsOf  time.Time                 `json:"as_of"`
+	Rates map[money.Currency]string `json:"rates"`
+}
+
+// FileProvider serves rates loaded from a local JSON file, quoted against a single base
+// currency. Cross rates are derived through the base.
+type FileProvider struct {
+	base  money.Currency
+	asOf  time.Time
+	rates map[money.Currency]*big.Rat
+}
+
+// NewFileProvider loads the rates in the JSON file at path.
+func NewFileProvider(path string) (*FileProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file rateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid rates file %s: %w", path, err)
+	}
+
+	p := &FileProvider{
+		base:  file.Base,
+		asOf:  file.AsOf,
+		rates: map[money.Currency]*big.Rat{file.Base: big.NewRat(1, 1)},
+	}
+	for currency, value := range file.Rates {
+		rate, ok := new(big.Rat).SetString(value)
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid rate %q for %s in %s", value, currency, path)
+		}
+		p.rates[currency] = rate
+	}
+
+	return p, nil
+}
+
+// Rate implements Provider.
+func (p *FileProvider) Rate(ctx context.Context, from, to money.Currency) (Rate, error) {
+	if from == to {
+		return Rate{From: from, To: to, Value: big.NewRat(1, 1), AsOf: p.asOf}, nil
+	}
+
+	fromRate, ok := p.rates[from]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w: %s/%s", ErrRateNotFound, from, to)
+	}
+	toRate, ok := p.rates[to]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w: %s/%s", ErrRateNotFound, from, to)
+	}
+
+	return Rate{From: from, To: to, Value: new(big.Rat).Quo(toRate, fromRate), AsOf: p.asOf}, nil
+}
@@ -0,0 +1,78 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"go-bank-app/pkg/money"
+	"testing"
+)
+
+func TestFileProvider_Rate(t *testing.T) {
+	provider, err := NewFileProvider("testdata/rates.json")
+	if err != nil {
+		t.Fatalf("unexpected error loading rates: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		from, to money.Currency
+		amount   string
+		expected string
+		rate     string
+	}{
+		{name: "from base", from: money.CurrencyUSD, to: money.CurrencyMXN, amount: "10.00", expected: "200.00", rate: "20.00000000"},
+		{name: "to base", from: money.CurrencyMXN, to: money.CurrencyUSD, amount: "200.00", expected: "10.00", rate: "0.05000000"},
+		{name: "cross rate", from: money.CurrencyEUR, to: money.CurrencyMXN, amount: "1.00", expected: "25.00", rate: "25.00000000"},
+		{name: "same currency", from: money.CurrencyMXN, to: money.CurrencyMXN, amount: "12.34", expected: "12.34", rate: "1.00000000"},
+		{name: "rounds half to even", from: money.CurrencyMXN, to: money.CurrencyUSD, amount: "0.10", expected: "0.00", rate: "0.05000000"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rate, err := provider.Rate(context.Background(), test.from, test.to)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rate.String() != test.rate {
+				t.Errorf("expected rate %s, got %s", test.rate, rate)
+			}
+
+			converted, err := rate.Convert(money.MustParse(test.amount))
+			if err != nil {
+				t.Fatalf("unexpected conversion error: %v", err)
+			}
+			if converted != money.MustParse(test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, converted)
+			}
+		})
+	}
+}
+
+func TestFileProvider_UnknownCurrency(t *testing.T) {
+	provider, err := NewFileProvider("testdata/rates.json")
+	if err != nil {
+		t.Fatalf("unexpected error loading rates: %v", err)
+	}
+
+	_, err = provider.Rate(context.Background(), money.CurrencyUSD, money.CurrencyGBP)
+	if !errors.Is(err, ErrRateNotFound) {
+		t.Errorf("expected ErrRateNotFound, got %v", err)
+	}
+}
+
+func TestRate_Invert(t *testing.T) {
+	provider, err := NewFileProvider("testdata/rates.json")
+	if err != nil {
+		t.Fatalf("unexpected error loading rates: %v", err)
+	}
+
+	rate, _ := provider.Rate(context.Background(), money.CurrencyUSD, money.CurrencyMXN)
+	inverse := rate.Invert()
+
+	if inverse.From != money.CurrencyMXN || inverse.To != money.CurrencyUSD {
+		t.Errorf("expected MXN/USD, got %s/%s", inverse.From, inverse.To)
+	}
+	if inverse.String() != "0.05000000" {
+		t.Errorf("expected 0.05000000, got %s", inverse)
+	}
+}
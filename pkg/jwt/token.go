@@ -10,10 +10,18 @@ import (
 
 var jwtKey = []byte(config.GetStringOrDefault("JWT_SECRET", "super-secret-dev-key"))
 
-func GenerateToken(userID, email string) (string, error) {
+// Claims are the fields the app reads back from a token.
+type Claims struct {
+	UserID string
+	Email  string
+	Role   string
+}
+
+func GenerateToken(userID, email, role string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"email":   email,
+		"role":    role,
 		"exp":     time.Now().Add(time.Hour * 72).Unix(),
 	}
 
@@ -23,6 +31,16 @@ func GenerateToken(userID, email string) (string, error) {
 }
 
 func ParseToken(tokenString string) (string, error) {
+	claims, err := ParseClaims(tokenString)
+	if err != nil {
+		return "", err
+	}
+	return claims.UserID, nil
+}
+
+// ParseClaims validates the token and returns its claims. Tokens issued before roles
+// existed have an empty Role.
+func ParseClaims(tokenString string) (*Claims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		// Validate method
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -32,18 +50,21 @@ func ParseToken(tokenString string) (string, error) {
 	})
 
 	if err != nil || !token.Valid {
-		return "", errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", errors.New("invalid claims")
+		return nil, errors.New("invalid claims")
 	}
 
 	userID, ok := claims["user_id"].(string)
 	if !ok {
-		return "", errors.New("user_id not found in token")
+		return nil, errors.New("user_id not found in token")
 	}
 
-	return userID, nil
+	email, _ := claims["email"].(string)
+	role, _ := claims["role"].(string)
+
+	return &Claims{UserID: userID, Email: email, Role: role}, nil
 }
@@ -0,0 +1,114 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// Config points the client at an SMTP server.
+type Config struct {
+	// Addr is the host:port of the server, e.g. smtp.example.com:587.
+	Addr string
+	// From is the address emails are sent from.
+	From string
+	// Credentials for PLAIN authentication. When empty the client does not authenticate.
+	Username string
+	Password string
+}
+
+// Client sends plain text emails over SMTP. Connections are upgraded with STARTTLS when
+// the server offers it.
+type Client struct {
+	addr string
+	host string
+	from string
+	auth smtp.Auth
+}
+
+func New(cfg Config) (*Client, error) {
+	if cfg.Addr == "" || cfg.From == "" {
+		return nil, errors.New("mail: server address and sender are required")
+	}
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("mail: invalid server address %q: %w", cfg.Addr, err)
+	}
+
+	c := &Client{addr: cfg.Addr, host: host, from: cfg.From}
+	if cfg.Username != "" {
+		c.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	return c, nil
+}
+
+// Send delivers an email to a single recipient. ctx bounds the whole SMTP conversation.
+func (c *Client) Send(ctx context.Context, to, subject, body string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.host}); err != nil {
+			return err
+		}
+	}
+	if c.auth != nil {
+		if err := client.Auth(c.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(c.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(c.message(to, subject, body, time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// message formats the email with its headers. The subject is encoded so it may hold any
+// UTF-8 text.
+func (c *Client) message(to, subject, body string, date time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", c.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(body)
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{Addr: "smtp.example.com:587"}); err == nil {
+		t.Error("expected an error without a sender")
+	}
+	if _, err := New(Config{Addr: "smtp.example.com", From: "bank@example.com"}); err == nil {
+		t.Error("expected an error for an address without port")
+	}
+}
+
+func TestClient_Message(t *testing.T) {
+	c, err := New(Config{Addr: "smtp.example.com:587", From: "bank@example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	date := time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC)
+	msg := string(c.message("user@example.com", "Transferencia programada — fallida", "Rent could not be sent.", date))
+
+	for _, header := range []string{
+		"From: bank@example.com\r\n",
+		"To: user@example.com\r\n",
+		"Subject: =?utf-8?q?Transferencia_programada_=E2=80=94_fallida?=\r\n",
+		"Date: Mon, 01 Jun 2026 12:00:00 +0000\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+	} {
+		if !strings.Contains(msg, header) {
+			t.Errorf("expected header %q in:\n%s", header, msg)
+		}
+	}
+	if !strings.HasSuffix(msg, "\r\n\r\nRent could not be sent.\r\n") {
+		t.Errorf("expected the body after the headers, got:\n%s", msg)
+	}
+}
@@ -9,7 +9,10 @@ import (
 
 type contextKey string
 
-const ContextUserIDKey = contextKey("userID")
+const (
+	ContextUserIDKey   = contextKey("userID")
+	ContextUserRoleKey = contextKey("userRole")
+)
 
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -27,15 +30,29 @@ func AuthMiddleware(next http.Handler) http.Handler {
 
 		tokenStr := tokenParts[1]
 
-		userID, err := jwt.ParseToken(tokenStr)
+		claims, err := jwt.ParseClaims(tokenStr)
 		if err != nil {
 			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
 			return
 		}
 
-		// Add userID to context
-		ctx := context.WithValue(r.Context(), ContextUserIDKey, userID)
+		// Add userID and role to context
+		ctx := context.WithValue(r.Context(), ContextUserIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, ContextUserRoleKey, claims.Role)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// RequireRole only lets through requests whose token carries the given role. It must be
+// wrapped by AuthMiddleware.
+func RequireRole(role string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userRole, _ := r.Context().Value(ContextUserRoleKey).(string); userRole != role {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
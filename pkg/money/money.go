@@ -0,0 +1,239 @@
+// Package money provides an exact monetary amount type so balances and transfers
+// never go through float64.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+const (
+	CurrencyMXN Currency = "MXN"
+	CurrencyUSD Currency = "USD"
+	CurrencyEUR Currency = "EUR"
+	CurrencyGBP Currency = "GBP"
+	CurrencyCAD Currency = "CAD"
+)
+
+// SupportedCurrencies lists the currencies accounts can be opened in. All of them use
+// two decimal places, which is what Scale and the database columns allow.
+var SupportedCurrencies = []Currency{CurrencyMXN, CurrencyUSD, CurrencyEUR, CurrencyGBP, CurrencyCAD}
+
+// Valid reports whether the currency is supported.
+func (c Currency) Valid() bool {
+	for _, supported := range SupportedCurrencies {
+		if c == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// Scale is the number of decimal places every Amount carries. It matches the
+// NUMERIC(14, 2) columns used for balances and transaction amounts.
+const Scale = 2
+
+const minorPerUnit = 100
+
+// maxMinor is the largest absolute value that fits in NUMERIC(14, 2).
+const maxMinor = 99_999_999_999_999
+
+var (
+	ErrInvalidAmount = errors.New("invalid amount")
+	ErrSubCent       = errors.New("amount has more than 2 decimal places")
+	ErrOutOfRange    = errors.New("amount is out of range")
+)
+
+var decimalPattern = regexp.MustCompile(`^[+-]?[0-9]+(\.[0-9]+)?$`)
+
+// Amount is an exact monetary value expressed in minor units (cents).
+type Amount int64
+
+// RoundingMode decides how values with more precision than Scale are rounded.
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds ties to the nearest even cent (banker's rounding). It is the
+	// default for derived values such as conversions because it does not bias totals.
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds ties away from zero.
+	RoundHalfUp
+	// RoundDown truncates towards zero.
+	RoundDown
+)
+
+// FromMinor returns the Amount for the given number of minor units.
+func FromMinor(minor int64) Amount {
+	return Amount(minor)
+}
+
+// Parse reads a decimal string such as "12.34" or "-5". Values with more than two
+// decimal places are rejected with ErrSubCent instead of being rounded silently.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	scaled := new(big.Rat).Mul(r, big.NewRat(minorPerUnit, 1))
+	if !scaled.IsInt() {
+		return 0, fmt.Errorf("%w: %q", ErrSubCent, s)
+	}
+
+	return fromInt(scaled.Num())
+}
+
+// MustParse is like Parse but panics on error. It is meant for constants and tests.
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// Round converts an arbitrary precision value to an Amount using the given rounding mode.
+func Round(r *big.Rat, mode RoundingMode) (Amount, error) {
+	scaled := new(big.Rat).Mul(r, big.NewRat(minorPerUnit, 1))
+
+	quo, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if rem.Sign() != 0 && mode != RoundDown {
+		// Compare twice the remainder with the denominator to detect ties.
+		twice := new(big.Int).Abs(rem)
+		twice.Lsh(twice, 1)
+		cmp := twice.Cmp(scaled.Denom())
+
+		roundAway := cmp > 0 || (cmp == 0 && (mode == RoundHalfUp || quo.Bit(0) == 1))
+		if roundAway {
+			quo.Add(quo, big.NewInt(int64(rem.Sign())))
+		}
+	}
+
+	return fromInt(quo)
+}
+
+func fromInt(i *big.Int) (Amount, error) {
+	if !i.IsInt64() || i.Int64() > maxMinor || i.Int64() < -maxMinor {
+		return 0, ErrOutOfRange
+	}
+	return Amount(i.Int64()), nil
+}
+
+// Minor returns the amount in minor units.
+func (a Amount) Minor() int64 {
+	return int64(a)
+}
+
+// Rat returns the amount as an exact rational number of units.
+func (a Amount) Rat() *big.Rat {
+	return big.NewRat(int64(a), minorPerUnit)
+}
+
+func (a Amount) Add(b Amount) Amount { return a + b }
+func (a Amount) Sub(b Amount) Amount { return a - b }
+func (a Amount) Neg() Amount         { return -a }
+
+func (a Amount) IsZero() bool     { return a == 0 }
+func (a Amount) IsPositive() bool { return a > 0 }
+func (a Amount) IsNegative() bool { return a < 0 }
+
+// String formats the amount with exactly two decimal places.
+func (a Amount) String() string {
+	sign := ""
+	minor := int64(a)
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/minorPerUnit, minor%minorPerUnit)
+}
+
+// MarshalJSON encodes the amount as a JSON number with two decimal places.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a quoted decimal string.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// MarshalText encodes the amount as a decimal with two places, e.g. for XML.
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText parses a decimal amount.
+func (a *Amount) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner for NUMERIC columns.
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return a.scanString(string(v))
+	case string:
+		return a.scanString(v)
+	case int64:
+		*a = Amount(v * minorPerUnit)
+		return nil
+	case float64:
+		rounded, err := Round(new(big.Rat).SetFloat64(v), RoundHalfEven)
+		if err != nil {
+			return err
+		}
+		*a = rounded
+		return nil
+	case nil:
+		*a = 0
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into money.Amount", src)
+	}
+}
+
+func (a *Amount) scanString(s string) error {
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, sending the amount as an exact decimal string.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
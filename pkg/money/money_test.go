@@ -0,0 +1,186 @@
+package money
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Amount
+		wantErr error
+	}{
+		{input: "12.34", want: 1234},
+		{input: "0.1", want: 10},
+		{input: "5", want: 500},
+		{input: "-7.05", want: -705},
+		{input: "1.00", want: 100},
+		{input: "0.001", wantErr: ErrSubCent},
+		{input: "10.005", wantErr: ErrSubCent},
+		{input: "abc", wantErr: ErrInvalidAmount},
+		{input: "1e3", wantErr: ErrInvalidAmount},
+		{input: "1/3", wantErr: ErrInvalidAmount},
+		{input: "1000000000000000", wantErr: ErrOutOfRange},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got, err := Parse(test.input)
+
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("expected error %v, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("expected %d minor units, got %d", test.want, got)
+			}
+		})
+	}
+}
+
+func TestAmount_String(t *testing.T) {
+	tests := map[Amount]string{
+		0:     "0.00",
+		5:     "0.05",
+		1234:  "12.34",
+		-1:    "-0.01",
+		-1050: "-10.50",
+	}
+
+	for amount, want := range tests {
+		if got := amount.String(); got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	}
+}
+
+func TestAmount_NoFloatDrift(t *testing.T) {
+	var total Amount
+	for i := 0; i < 10; i++ {
+		total = total.Add(MustParse("0.10"))
+	}
+
+	if total != MustParse("1.00") {
+		t.Errorf("expected 1.00, got %s", total)
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		name string
+		num  int64
+		den  int64
+		mode RoundingMode
+		want Amount
+	}{
+		{name: "half even rounds tie down to even", num: 10125, den: 1000, mode: RoundHalfEven, want: 1012},
+		{name: "half even rounds tie up to even", num: 10135, den: 1000, mode: RoundHalfEven, want: 1014},
+		{name: "half up rounds tie away from zero", num: 10125, den: 1000, mode: RoundHalfUp, want: 1013},
+		{name: "half up negative tie", num: -10125, den: 1000, mode: RoundHalfUp, want: -1013},
+		{name: "down truncates", num: 10199, den: 1000, mode: RoundDown, want: 1019},
+		{name: "one third", num: 1, den: 3, mode: RoundHalfEven, want: 33},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Round(big.NewRat(test.num, test.den), test.mode)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("expected %s, got %s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestAmount_JSON(t *testing.T) {
+	var payload struct {
+		Amount Amount `json:"amount"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount": 99.95}`), &payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payload.Amount != 9995 {
+		t.Errorf("expected 9995 minor units, got %d", payload.Amount)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount": "12.50"}`), &payload); err != nil {
+		t.Fatalf("unexpected error for quoted amount: %v", err)
+	}
+	if payload.Amount != 1250 {
+		t.Errorf("expected 1250 minor units, got %d", payload.Amount)
+	}
+
+	err := json.Unmarshal([]byte(`{"amount": 0.005}`), &payload)
+	if !errors.Is(err, ErrSubCent) {
+		t.Errorf("expected ErrSubCent, got %v", err)
+	}
+
+	out, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if string(out) != `{"amount":12.50}` {
+		t.Errorf("unexpected JSON: %s", out)
+	}
+}
+
+func TestAmount_XML(t *testing.T) {
+	type payload struct {
+		Amount   Amount `xml:"Amt"`
+		Negative Amount `xml:"Neg,attr"`
+	}
+
+	out, err := xml.Marshal(payload{Amount: 1250, Negative: -5})
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if string(out) != `<payload Neg="-0.05"><Amt>12.50</Amt></payload>` {
+		t.Errorf("unexpected XML: %s", out)
+	}
+
+	var in payload
+	if err := xml.Unmarshal(out, &in); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if in.Amount != 1250 || in.Negative != -5 {
+		t.Errorf("expected 12.50 and -0.05, got %s and %s", in.Amount, in.Negative)
+	}
+}
+
+func TestAmount_Scan(t *testing.T) {
+	tests := []struct {
+		name string
+		src  interface{}
+		want Amount
+	}{
+		{name: "numeric bytes", src: []byte("1500.25"), want: 150025},
+		{name: "string", src: "0.07", want: 7},
+		{name: "integer", src: int64(3), want: 300},
+		{name: "float", src: 0.1 + 0.2, want: 30},
+		{name: "null", src: nil, want: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var a Amount
+			if err := a.Scan(test.src); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if a != test.want {
+				t.Errorf("expected %s, got %s", test.want, a)
+			}
+		})
+	}
+}